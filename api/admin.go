@@ -0,0 +1,70 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+const adminTokenHeader = "X-Rpaas-Admin-Token"
+
+// adminOnly restricts the endpoints to the holders of the admin token. Since
+// the service API is reachable by every tsuru user through the instance
+// proxy, the API credentials alone aren't enough for these.
+func adminOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := config.Get().AdminToken
+		if token == "" || subtle.ConstantTimeCompare([]byte(c.Request().Header.Get(adminTokenHeader)), []byte(token)) != 1 {
+			return c.String(http.StatusForbidden, "admin token is missing or not valid")
+		}
+		return next(c)
+	}
+}
+
+func garbageCollect(c echo.Context) error {
+	dryRun := false
+	if value := c.QueryParam("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return c.String(http.StatusBadRequest, "dry_run is not valid")
+		}
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	collected, err := manager.GarbageCollect(c.Request().Context(), c.Param("instance"), dryRun)
+	if err != nil {
+		return err
+	}
+	if collected == nil {
+		collected = []rpaas.GarbageCollectedObject{}
+	}
+	return c.JSON(http.StatusOK, collected)
+}
+
+func updateBlockAcrossInstances(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	var block rpaas.ConfigurationBlock
+	if err = c.Bind(&block); err != nil {
+		return err
+	}
+
+	results, err := manager.UpdateBlockAcrossInstances(c.Request().Context(), c.QueryParam("selector"), block)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, results)
+}
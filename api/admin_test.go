@@ -0,0 +1,171 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_garbageCollect(t *testing.T) {
+	testCases := []struct {
+		name         string
+		adminToken   string
+		token        string
+		query        string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when admin token is not configured",
+			token:        "secret",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "admin token is missing or not valid",
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when admin token does not match",
+			adminToken:   "secret",
+			token:        "guess",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "admin token is missing or not valid",
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when dry run is not a boolean",
+			adminToken:   "secret",
+			token:        "secret",
+			query:        "?dry_run=maybe",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "dry_run is not valid",
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when listing the stale objects",
+			adminToken:   "secret",
+			token:        "secret",
+			query:        "?dry_run=true",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"kind":"ConfigMap","name":"my-instance-extra-files-abc"}]`,
+			manager: &fake.RpaasManager{
+				FakeGarbageCollect: func(instanceName string, dryRun bool) ([]rpaas.GarbageCollectedObject, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.True(t, dryRun)
+					return []rpaas.GarbageCollectedObject{{Kind: "ConfigMap", Name: "my-instance-extra-files-abc"}}, nil
+				},
+			},
+		},
+		{
+			name:         "when nothing is collected",
+			adminToken:   "secret",
+			token:        "secret",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+			manager: &fake.RpaasManager{
+				FakeGarbageCollect: func(instanceName string, dryRun bool) ([]rpaas.GarbageCollectedObject, error) {
+					assert.False(t, dryRun)
+					return nil, nil
+				},
+			},
+		},
+		{
+			name:         "when instance does not exist",
+			adminToken:   "secret",
+			token:        "secret",
+			expectedCode: http.StatusNotFound,
+			manager: &fake.RpaasManager{
+				FakeGarbageCollect: func(instanceName string, dryRun bool) ([]rpaas.GarbageCollectedObject, error) {
+					return nil, rpaas.NotFoundError{Msg: "rpaas instance \"my-instance\" not found"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Set(config.RpaasConfig{AdminToken: tt.adminToken})
+			defer config.Set(config.RpaasConfig{})
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/admin/resources/my-instance/garbage-collect%s", srv.URL, tt.query)
+			request, err := http.NewRequest(http.MethodPost, path, nil)
+			require.NoError(t, err)
+			request.Header.Set("X-Rpaas-Admin-Token", tt.token)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+		})
+	}
+}
+
+func Test_updateBlockAcrossInstances(t *testing.T) {
+	testCases := []struct {
+		name         string
+		query        string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the selector is missing",
+			requestBody:  "block_name=server&content=add_header+X-Frame-Options+DENY%3B",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "label selector is required",
+			manager: &fake.RpaasManager{
+				FakeUpdateBlockAcrossInstances: func(selector string, block rpaas.ConfigurationBlock) ([]rpaas.InstanceBlockResult, error) {
+					assert.Equal(t, "", selector)
+					return nil, rpaas.ValidationError{Msg: "label selector is required"}
+				},
+			},
+		},
+		{
+			name:         "when some instances fail",
+			query:        "?selector=tier%3Dfrontend",
+			requestBody:  "block_name=server&content=add_header+X-Frame-Options+DENY%3B",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"instance":"instance-1"},{"instance":"instance-2","error":"etcd is unavailable"}]`,
+			manager: &fake.RpaasManager{
+				FakeUpdateBlockAcrossInstances: func(selector string, block rpaas.ConfigurationBlock) ([]rpaas.InstanceBlockResult, error) {
+					assert.Equal(t, "tier=frontend", selector)
+					assert.Equal(t, rpaas.ConfigurationBlock{Name: "server", Content: "add_header X-Frame-Options DENY;"}, block)
+					return []rpaas.InstanceBlockResult{
+						{Instance: "instance-1"},
+						{Instance: "instance-2", Error: "etcd is unavailable"},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Set(config.RpaasConfig{AdminToken: "secret"})
+			defer config.Set(config.RpaasConfig{})
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/admin/resources/blocks%s", srv.URL, tt.query)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			request.Header.Set("X-Rpaas-Admin-Token", "secret")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+		})
+	}
+}
@@ -152,6 +152,9 @@ func errorMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		if rpaas.IsNotFoundError(err) {
 			return &echo.HTTPError{Code: http.StatusNotFound, Message: err}
 		}
+		if rpaas.IsTimeoutError(err) {
+			return &echo.HTTPError{Code: http.StatusGatewayTimeout, Message: err}
+		}
 		return err
 	}
 }
@@ -163,10 +166,13 @@ func newEcho() *echo.Echo {
 
 	e.Use(middleware.Recover())
 	e.Use(middleware.Logger())
+	e.Use(metricsMiddleware)
+	e.Use(tracingMiddleware)
 	e.Use(middleware.BasicAuthWithConfig(middleware.BasicAuthConfig{
 		Skipper: func(c echo.Context) bool {
 			conf := config.Get()
 			return c.Path() == "/healthcheck" ||
+				c.Path() == "/metrics" ||
 				(conf.APIUsername == "" && conf.APIPassword == "")
 		},
 		Validator: func(user, pass string, c echo.Context) (bool, error) {
@@ -179,21 +185,72 @@ func newEcho() *echo.Echo {
 	e.Use(errorMiddleware)
 
 	e.GET("/healthcheck", healthcheck)
+	e.GET("/metrics", metrics)
 	e.POST("/resources", serviceCreate)
+	e.POST("/resources/service-preview", servicePreview)
 	e.GET("/resources/flavors", getServiceFlavors)
 	e.GET("/resources/:instance/flavors", getInstanceFlavors)
+	e.GET("/resources/:instance/config/defaults", getConfigDefaults)
 	e.GET("/resources/plans", servicePlans)
+	e.GET("/resources/plans/snippets", getSnippets)
+	e.GET("/resources/plans/:plan", getPlan)
+	e.GET("/resources/blocks/available", getAllowedBlocks)
+	e.GET("/resources/node_status", servicesStatus)
 	e.GET("/resources/:instance/plans", servicePlans)
 	e.GET("/resources/:instance", serviceInfo)
 	e.PUT("/resources/:instance", serviceUpdate)
 	e.GET("/resources/:instance/node_status", serviceStatus)
+	e.GET("/resources/:instance/reload-status", getReloadStatus)
+	e.GET("/resources/:instance/nginx-status", getNginxStatus)
+	e.GET("/resources/:instance/nginx-info", getNginxInfo)
+	e.GET("/resources/:instance/resources", getInstanceResources)
+	e.GET("/resources/:instance/tags", getInstanceTags)
+	e.GET("/resources/:instance/export", exportInstance)
+	e.POST("/resources/:instance/import", importInstance)
+	e.POST("/resources/:instance/clone", cloneInstance)
 	e.DELETE("/resources/:instance", serviceDelete)
 	e.POST("/resources/:instance/bind-app", serviceBindApp)
 	e.DELETE("/resources/:instance/bind-app", serviceUnbindApp)
 	e.POST("/resources/:instance/bind", serviceBindUnit)
 	e.DELETE("/resources/:instance/bind", serviceUnbindUnit)
 	e.POST("/resources/:instance/scale", scale)
+	e.POST("/resources/:instance/server-tokens", updateServerTokens)
+	e.POST("/resources/:instance/proxy-protocol", updateProxyProtocol)
+	e.POST("/resources/:instance/reject-unknown-hosts", updateRejectUnknownHosts)
+	e.POST("/resources/:instance/request-id", updateRequestID)
+	e.POST("/resources/:instance/http3", updateHTTP3)
+	e.POST("/resources/:instance/external-hostname", updateExternalHostname)
+	e.POST("/resources/:instance/healthcheck", updateHealthCheck)
+	e.POST("/resources/:instance/compression", updateCompression)
+	e.POST("/resources/:instance/access-log", updateAccessLog)
+	e.POST("/resources/:instance/rate-limit", updateRateLimit)
+	e.GET("/resources/:instance/map", getMaps)
+	e.POST("/resources/:instance/map", updateMap)
+	e.DELETE("/resources/:instance/map/:variable", deleteMap)
+	e.POST("/resources/:instance/status-callback", updateStatusCallback)
+	e.POST("/resources/:instance/restart", restartInstance)
+	e.POST("/resources/:instance/sync", syncInstance)
+	e.GET("/resources/:instance/team", getInstanceTeam)
+	e.PUT("/resources/:instance/team", updateInstanceTeam)
+	e.POST("/resources/:instance/repair-labels", repairLabels)
+	e.GET("/resources/:instance/autoscale", getAutoscaleStatus)
+	e.PUT("/resources/:instance/autoscale", updateAutoscale)
+	e.POST("/resources/:instance/scale-to-zero", updateScaleToZero)
+	e.PUT("/resources/:instance/pdb", updatePodDisruptionBudget)
+	e.DELETE("/resources/:instance/pdb", deletePodDisruptionBudget)
+	e.POST("/resources/:instance/validate", validateInstanceConfig)
 	e.POST("/resources/:instance/certificate", updateCertificate)
+	e.POST("/resources/:instance/certificate/upload", uploadCertificate)
+	e.POST("/resources/:instance/certificate/pkcs12", uploadPKCS12Certificate)
+	e.GET("/resources/:instance/certificate/names", listCertificateNames)
+	e.GET("/resources/:instance/certificates", getCertificates)
+	e.POST("/resources/:instance/certificates", updateCertificates)
+	e.PUT("/resources/:instance/certificate/default", updateDefaultCertificate)
+	e.PUT("/resources/:instance/client-auth", updateClientAuth)
+	e.DELETE("/resources/:instance/client-auth", deleteClientAuth)
+	e.GET("/resources/:instance/tls-config", getTLSConfig)
+	e.PUT("/resources/:instance/tls-config", updateTLSConfig)
+	e.DELETE("/resources/:instance/tls-config", deleteTLSConfig)
 	e.GET("/resources/:instance/block", listBlocks)
 	e.POST("/resources/:instance/block", updateBlock)
 	e.DELETE("/resources/:instance/block/:block", deleteBlock)
@@ -208,7 +265,13 @@ func newEcho() *echo.Echo {
 	e.DELETE("/resources/:instance/route", deleteRoute)
 	e.GET("/resources/:instance/route", getRoutes)
 	e.POST("/resources/:instance/route", updateRoute)
+	e.POST("/resources/:instance/route/bulk", bulkUpdateRoutes)
+	e.POST("/resources/:instance/route/check", testRouteDestination)
 	e.POST("/resources/:instance/purge", cachePurge)
 
+	admin := e.Group("/admin", adminOnly)
+	admin.POST("/resources/:instance/garbage-collect", garbageCollect)
+	admin.POST("/resources/blocks", updateBlockAcrossInstances)
+
 	return e
 }
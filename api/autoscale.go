@@ -0,0 +1,45 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+func updateAutoscale(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	var autoscale rpaas.Autoscale
+	if err = c.Bind(&autoscale); err != nil {
+		return err
+	}
+
+	err = manager.UpdateAutoscale(c.Request().Context(), c.Param("instance"), autoscale)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func getAutoscaleStatus(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := manager.GetAutoscaleStatus(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
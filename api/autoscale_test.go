@@ -0,0 +1,151 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_getAutoscaleStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		instance     string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when manager is not set",
+			instance:     "my-instance",
+			expectedCode: http.StatusInternalServerError,
+			manager:      nil,
+		},
+		{
+			name:         "when autoscale is not enabled",
+			instance:     "my-instance",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "autoscale is not enabled",
+			manager: &fake.RpaasManager{
+				FakeGetAutoscaleStatus: func(instanceName string) (*rpaas.AutoscaleStatus, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return nil, rpaas.NotFoundError{Msg: "autoscale is not enabled"}
+				},
+			},
+		},
+		{
+			name:         "when successfully getting the autoscale status",
+			instance:     "my-instance",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"min_replicas":2,"max_replicas":10,"current_replicas":4,"desired_replicas":5,"metrics":[{"name":"cpu","current":"72%","target":"70%"}]}`,
+			manager: &fake.RpaasManager{
+				FakeGetAutoscaleStatus: func(instanceName string) (*rpaas.AutoscaleStatus, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return &rpaas.AutoscaleStatus{
+						MinReplicas:     2,
+						MaxReplicas:     10,
+						CurrentReplicas: 4,
+						DesiredReplicas: 5,
+						Metrics: []rpaas.AutoscaleMetricStatus{
+							{Name: "cpu", Current: "72%", Target: "70%"},
+						},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/%s/autoscale", srv.URL, tt.instance)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func Test_updateAutoscale(t *testing.T) {
+	tests := []struct {
+		name         string
+		instance     string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when manager is not set",
+			instance:     "my-instance",
+			requestBody:  `{"max_replicas": 10}`,
+			expectedCode: http.StatusInternalServerError,
+			manager:      nil,
+		},
+		{
+			name:         "when update autoscale returns a validation error",
+			instance:     "my-instance",
+			requestBody:  `{"max_replicas": 10, "metrics": [{"type": "object", "name": "requests", "target": 10}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `unknown metric type \"object\"`,
+			manager: &fake.RpaasManager{
+				FakeUpdateAutoscale: func(instanceName string, autoscale rpaas.Autoscale) error {
+					return rpaas.ValidationError{Msg: `unknown metric type "object"`}
+				},
+			},
+		},
+		{
+			name:         "when autoscale is successfully updated",
+			instance:     "my-instance",
+			requestBody:  `{"min_replicas": 2, "max_replicas": 10, "cpu": 70, "metrics": [{"type": "pods", "name": "requests_per_second", "target": 100}]}`,
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateAutoscale: func(instanceName string, autoscale rpaas.Autoscale) error {
+					assert.Equal(t, "my-instance", instanceName)
+					minReplicas, cpu := int32(2), int32(70)
+					assert.Equal(t, rpaas.Autoscale{
+						MinReplicas: &minReplicas,
+						MaxReplicas: 10,
+						CPU:         &cpu,
+						Metrics: []rpaas.AutoscaleMetric{
+							{Type: "pods", Name: "requests_per_second", Target: 100},
+						},
+					}, autoscale)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/%s/autoscale", srv.URL, tt.instance)
+			request, err := http.NewRequest(http.MethodPut, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -47,6 +47,26 @@ func listBlocks(c echo.Context) error {
 	}{blocks})
 }
 
+func getAllowedBlocks(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	names, err := manager.GetAllowedBlocks(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	if names == nil {
+		names = make([]string, 0)
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Blocks []string `json:"blocks"`
+	}{names})
+}
+
 func updateBlock(c echo.Context) error {
 	manager, err := getManager(c)
 	if err != nil {
@@ -134,6 +134,46 @@ func Test_listBlocks(t *testing.T) {
 	}
 }
 
+func Test_getAllowedBlocks(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when manager returns the allowed blocks",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"blocks":["http","root","server"]}`,
+			manager: &fake.RpaasManager{
+				FakeGetAllowedBlocks: func() ([]string, error) {
+					return []string{"http", "root", "server"}, nil
+				},
+			},
+		},
+		{
+			name:         "when there are no allowed blocks",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"blocks":[]}`,
+			manager:      &fake.RpaasManager{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/blocks/available", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.JSONEq(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
 func Test_updateBlock(t *testing.T) {
 	tests := []struct {
 		name         string
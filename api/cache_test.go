@@ -82,6 +82,22 @@ func Test_cachePurge(t *testing.T) {
 				},
 			},
 		},
+		{
+			description:  "passes the cache key variants to the manager",
+			instanceName: "my-instance",
+			requestBody:  "path=/index.html&query_string_variants=true",
+			expectedCode: http.StatusOK,
+			expectedBody: "Object purged on 2 servers",
+			manager: &fake.RpaasManager{
+				FakePurgeCache: func(instanceName string, args rpaas.PurgeCacheArgs) (int, error) {
+					assert.Equal(t, rpaas.PurgeCacheArgs{
+						Path:                "/index.html",
+						QueryStringVariants: true,
+					}, args)
+					return 2, nil
+				},
+			},
+		},
 	}
 
 	for _, tt := range testCases {
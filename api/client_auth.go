@@ -0,0 +1,45 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+func updateClientAuth(c echo.Context) error {
+	var clientAuth rpaas.ClientAuth
+	if err := c.Bind(&clientAuth); err != nil {
+		return c.String(http.StatusBadRequest, "client auth parameters are not valid")
+	}
+
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	err = manager.UpdateClientAuth(c.Request().Context(), c.Param("instance"), clientAuth)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func deleteClientAuth(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	err = manager.DeleteClientAuth(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
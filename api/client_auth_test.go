@@ -0,0 +1,112 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_updateClientAuth(t *testing.T) {
+	bundle := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the manager returns a validation error",
+			requestBody:  url.Values{"ca_bundle": []string{"not a bundle"}}.Encode(),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid CA bundle: could not decode PEM data",
+			manager: &fake.RpaasManager{
+				FakeUpdateClientAuth: func(instance string, clientAuth rpaas.ClientAuth) error {
+					return &rpaas.ValidationError{Msg: "invalid CA bundle: could not decode PEM data"}
+				},
+			},
+		},
+		{
+			name:         "when successfully updating the client authentication",
+			requestBody:  url.Values{"ca_bundle": []string{bundle}, "verify": []string{"optional"}}.Encode(),
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateClientAuth: func(instance string, clientAuth rpaas.ClientAuth) error {
+					assert.Equal(t, "my-instance", instance)
+					assert.Equal(t, rpaas.ClientAuth{CABundle: bundle, Verify: "optional"}, clientAuth)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/client-auth", srv.URL)
+			request, err := http.NewRequest(http.MethodPut, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func Test_deleteClientAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when client authentication is not set",
+			expectedCode: http.StatusNotFound,
+			manager: &fake.RpaasManager{
+				FakeDeleteClientAuth: func(instance string) error {
+					return &rpaas.NotFoundError{Msg: "client authentication not found"}
+				},
+			},
+		},
+		{
+			name:         "when successfully removing the client authentication",
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeDeleteClientAuth: func(instance string) error {
+					assert.Equal(t, "my-instance", instance)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/client-auth", srv.URL)
+			request, err := http.NewRequest(http.MethodDelete, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+type configDefaults struct {
+	ServiceName               string            `json:"service_name"`
+	ServiceAnnotations        map[string]string `json:"service_annotations,omitempty"`
+	Flavors                   []string          `json:"flavors"`
+	ReservedPaths             []string          `json:"reserved_paths"`
+	RouteContentInlineLimit   int               `json:"route_content_inline_limit"`
+	RouteContentMaxInlineSize int               `json:"route_content_max_inline_size"`
+}
+
+// getConfigDefaults returns the non-sensitive settings from the API
+// configuration which affect how the instances are created and validated.
+// Credentials and TLS key material must never be included here.
+func getConfigDefaults(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	instance, err := manager.GetInstance(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	conf := config.Get()
+	defaults := configDefaults{
+		ServiceName:               conf.ServiceName,
+		ServiceAnnotations:        conf.ServiceAnnotations,
+		Flavors:                   make([]string, 0, len(conf.Flavors)),
+		ReservedPaths:             rpaas.ReservedPaths(instance),
+		RouteContentInlineLimit:   conf.RouteContentInlineLimit,
+		RouteContentMaxInlineSize: rpaas.RouteContentMaxInlineSize(),
+	}
+	for _, f := range conf.Flavors {
+		defaults.Flavors = append(defaults.Flavors, f.Name)
+	}
+	sort.Strings(defaults.Flavors)
+	return c.JSON(http.StatusOK, defaults)
+}
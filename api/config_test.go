@@ -0,0 +1,97 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+)
+
+func Test_getConfigDefaults(t *testing.T) {
+	oldConfig := config.Get()
+	defer func() {
+		config.Set(oldConfig)
+	}()
+
+	tests := []struct {
+		name         string
+		conf         config.RpaasConfig
+		instance     *v1alpha1.RpaasInstance
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "when config is empty",
+			instance:     &v1alpha1.RpaasInstance{},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"service_name":"","flavors":[],"reserved_paths":["/_nginx_healthcheck"],"route_content_inline_limit":0,"route_content_max_inline_size":524288}`,
+		},
+		{
+			name: "when config has service settings and flavors, should not expose credentials",
+			conf: config.RpaasConfig{
+				ServiceName:        "rpaasv2",
+				ServiceAnnotations: map[string]string{"my-annotation": "value"},
+				TLSCertificate:     "my-certificate",
+				TLSKey:             "my-private-key",
+				Flavors: []config.FlavorConfig{
+					{Name: "strawberry"},
+					{Name: "orange"},
+				},
+				RouteContentInlineLimit:   1024,
+				RouteContentMaxInlineSize: 4096,
+			},
+			instance:     &v1alpha1.RpaasInstance{},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"service_name":"rpaasv2","service_annotations":{"my-annotation":"value"},"flavors":["orange","strawberry"],"reserved_paths":["/_nginx_healthcheck"],"route_content_inline_limit":1024,"route_content_max_inline_size":4096}`,
+		},
+		{
+			name: "when the instance has its own health check path",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{HealthCheckPath: "/healthz"},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"service_name":"","flavors":[],"reserved_paths":["/_nginx_healthcheck","/healthz"],"route_content_inline_limit":0,"route_content_max_inline_size":524288}`,
+		},
+		{
+			name:         "when the instance doesn't exist",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"Msg":"instance not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Set(tt.conf)
+			srv := newTestingServer(t, &fake.RpaasManager{
+				FakeGetInstance: func(instanceName string) (*v1alpha1.RpaasInstance, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					if tt.instance == nil {
+						return nil, &rpaas.NotFoundError{Msg: "instance not found"}
+					}
+					return tt.instance, nil
+				},
+			})
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/config/defaults", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			body := bodyContent(rsp)
+			assert.JSONEq(t, tt.expectedBody, body)
+			assert.NotContains(t, body, "my-certificate")
+			assert.NotContains(t, body, "my-private-key")
+		})
+	}
+}
@@ -6,17 +6,59 @@ package api
 
 import (
 	"crypto/tls"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"golang.org/x/crypto/pkcs12"
+	"sigs.k8s.io/yaml"
 )
 
 type scaleParameters struct {
 	Quantity int32 `form:"quantity"`
 }
 
+type serverTokensParameters struct {
+	Hide bool `form:"hide"`
+}
+
+type proxyProtocolParameters struct {
+	Enabled bool `form:"enabled"`
+}
+
+type teamParameters struct {
+	Team string `form:"team"`
+}
+
+type externalHostnameParameters struct {
+	Hostname string `form:"hostname"`
+}
+
+type rejectUnknownHostsParameters struct {
+	Enabled bool `form:"enabled"`
+}
+
+type requestIDParameters struct {
+	Enabled bool `form:"enabled"`
+}
+
+type http3Parameters struct {
+	Enabled bool `form:"enabled"`
+}
+
+type statusCallbackParameters struct {
+	URL string `form:"url"`
+}
+
+type healthCheckParameters struct {
+	Path   string `form:"path"`
+	Status int    `form:"status"`
+}
+
 func scale(c echo.Context) error {
 	var data scaleParameters
 	if err := c.Bind(&data); err != nil {
@@ -32,6 +74,246 @@ func scale(c echo.Context) error {
 	return c.NoContent(http.StatusCreated)
 }
 
+func restartInstance(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.RestartInstance(c.Request().Context(), c.Param("instance")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func syncInstance(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.SyncInstance(c.Request().Context(), c.Param("instance")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+func getInstanceTeam(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	metadata, err := manager.GetInstanceMetadata(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{"team": metadata.Team})
+}
+
+func updateInstanceTeam(c echo.Context) error {
+	var data teamParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "team is not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.SetTeam(c.Request().Context(), c.Param("instance"), data.Team); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func repairLabels(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.RepairLabels(c.Request().Context(), c.Param("instance")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateProxyProtocol(c echo.Context) error {
+	var data proxyProtocolParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "enabled is either missing or not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateProxyProtocol(c.Request().Context(), c.Param("instance"), data.Enabled); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateExternalHostname(c echo.Context) error {
+	var data externalHostnameParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "hostname is not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateExternalHostname(c.Request().Context(), c.Param("instance"), data.Hostname); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateRejectUnknownHosts(c echo.Context) error {
+	var data rejectUnknownHostsParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "enabled is either missing or not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateRejectUnknownHosts(c.Request().Context(), c.Param("instance"), data.Enabled); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateRequestID(c echo.Context) error {
+	var data requestIDParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "enabled is either missing or not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateRequestID(c.Request().Context(), c.Param("instance"), data.Enabled); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateHTTP3(c echo.Context) error {
+	var data http3Parameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "enabled is either missing or not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateHTTP3(c.Request().Context(), c.Param("instance"), data.Enabled); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateHealthCheck(c echo.Context) error {
+	var data healthCheckParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "status is not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateHealthCheck(c.Request().Context(), c.Param("instance"), data.Path, data.Status); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateStatusCallback(c echo.Context) error {
+	var data statusCallbackParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "url is not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateStatusCallback(c.Request().Context(), c.Param("instance"), data.URL); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateCompression(c echo.Context) error {
+	var data rpaas.Compression
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "compression parameters are not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateCompression(c.Request().Context(), c.Param("instance"), data); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateAccessLog(c echo.Context) error {
+	var data rpaas.AccessLog
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "access log parameters are not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateAccessLog(c.Request().Context(), c.Param("instance"), data); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateRateLimit(c echo.Context) error {
+	var data rpaas.RateLimit
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "rate limit parameters are not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateRateLimit(c.Request().Context(), c.Param("instance"), data); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateScaleToZero(c echo.Context) error {
+	var data rpaas.ScaleToZero
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "scale to zero parameters are not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateScaleToZero(c.Request().Context(), c.Param("instance"), data); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func updateServerTokens(c echo.Context) error {
+	var data serverTokensParameters
+	if err := c.Bind(&data); err != nil {
+		return c.String(http.StatusBadRequest, "hide is either missing or not valid")
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.UpdateServerTokens(c.Request().Context(), c.Param("instance"), data.Hide); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
 func updateCertificate(c echo.Context) error {
 	rawCertificate, err := getFormFileContent(c, "cert")
 	if err != nil {
@@ -51,16 +333,152 @@ func updateCertificate(c echo.Context) error {
 	if err != nil {
 		return c.String(http.StatusBadRequest, fmt.Sprintf("could not load the given certicate and key: %s", err))
 	}
+	return saveCertificate(c, certificate)
+}
+
+func uploadCertificate(c echo.Context) error {
+	rawCertificate, err := getRequiredFormFileContent(c, "certificate")
+	if err != nil {
+		return err
+	}
+	rawKey, err := getRequiredFormFileContent(c, "key")
+	if err != nil {
+		return err
+	}
+	certificate, err := rpaas.ParseCertificate(rawCertificate, rawKey)
+	if err != nil {
+		return err
+	}
+	return saveCertificate(c, certificate)
+}
+
+func uploadPKCS12Certificate(c echo.Context) error {
+	rawBundle, err := getRequiredFormFileContent(c, "bundle")
+	if err != nil {
+		return err
+	}
+	certificate, err := decodePKCS12(rawBundle, c.FormValue("passphrase"))
+	if err != nil {
+		return err
+	}
+	return saveCertificate(c, certificate)
+}
+
+func decodePKCS12(data []byte, passphrase string) (tls.Certificate, error) {
+	blocks, err := pkcs12.ToPEM(data, passphrase)
+	if err == pkcs12.ErrIncorrectPassword {
+		return tls.Certificate{}, &rpaas.ValidationError{Msg: "invalid PKCS#12 passphrase"}
+	}
+	if err != nil {
+		return tls.Certificate{}, &rpaas.ValidationError{Msg: fmt.Sprintf("could not decode the PKCS#12 bundle: %s", err)}
+	}
+	var rawCertificate, rawKey []byte
+	for _, block := range blocks {
+		// Bag attributes (e.g. friendlyName) are exported as PEM headers
+		// and aren't meaningful to nginx.
+		block.Headers = nil
+		switch block.Type {
+		case "CERTIFICATE":
+			rawCertificate = append(rawCertificate, pem.EncodeToMemory(block)...)
+		case "PRIVATE KEY":
+			rawKey = pem.EncodeToMemory(block)
+		}
+	}
+	certificate, err := tls.X509KeyPair(rawCertificate, rawKey)
+	if err != nil {
+		return tls.Certificate{}, &rpaas.ValidationError{Msg: fmt.Sprintf("could not load the certificate and key from the PKCS#12 bundle: %s", err)}
+	}
+	return certificate, nil
+}
+
+func saveCertificate(c echo.Context, certificate tls.Certificate) error {
 	manager, err := getManager(c)
 	if err != nil {
 		return err
 	}
 	instance := c.Param("instance")
 	certName := c.FormValue("name")
-	err = manager.UpdateCertificate(c.Request().Context(), instance, certName, certificate)
+	derived := certName == "" && c.FormValue("derive_name") == "true"
+	if derived {
+		certName, err = manager.DeriveCertificateName(c.Request().Context(), instance, certificate)
+		if err != nil {
+			return err
+		}
+	}
+	strict := c.QueryParam("strict") == "true"
+	warnings, err := manager.UpdateCertificate(c.Request().Context(), instance, certName, certificate, strict)
+	if err != nil {
+		return err
+	}
+	if derived {
+		// The derived name is reported first, so clients can refer to it.
+		warnings = append([]string{fmt.Sprintf("certificate stored as %q", certName)}, warnings...)
+	}
+	if len(warnings) > 0 {
+		return c.String(http.StatusOK, strings.Join(warnings, "\n"))
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+type certificatesParameters struct {
+	Certificates []rpaas.CertificateData `json:"certificates"`
+}
+
+func updateCertificates(c echo.Context) error {
+	var params certificatesParameters
+	if err := c.Bind(&params); err != nil {
+		return err
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	strict := c.QueryParam("strict") == "true"
+	results, err := manager.UpdateCertificates(c.Request().Context(), c.Param("instance"), params.Certificates, strict)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, results)
+}
+
+func listCertificateNames(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	names, err := manager.ListCertificateNames(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return c.JSON(http.StatusOK, names)
+}
+
+func getCertificates(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	certificates, err := manager.GetCertificates(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if certificates == nil {
+		certificates = []rpaas.CertificateInfo{}
+	}
+	return c.JSON(http.StatusOK, certificates)
+}
+
+func updateDefaultCertificate(c echo.Context) error {
+	manager, err := getManager(c)
 	if err != nil {
 		return err
 	}
+	if err = manager.UpdateDefaultCertificate(c.Request().Context(), c.Param("instance"), c.FormValue("name")); err != nil {
+		return err
+	}
 	return c.NoContent(http.StatusOK)
 }
 
@@ -81,6 +499,20 @@ func getFormFileContent(c echo.Context, key string) ([]byte, error) {
 	return rawContent, nil
 }
 
+func getRequiredFormFileContent(c echo.Context, key string) ([]byte, error) {
+	content, err := getFormFileContent(c, key)
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil, &rpaas.ValidationError{Msg: fmt.Sprintf("%s file is required", key)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, &rpaas.ValidationError{Msg: fmt.Sprintf("%s file is empty", key)}
+	}
+	return content, nil
+}
+
 func serviceStatus(c echo.Context) error {
 	manager, err := getManager(c)
 	if err != nil {
@@ -94,6 +526,158 @@ func serviceStatus(c echo.Context) error {
 	return c.JSON(200, podStatus)
 }
 
+func getReloadStatus(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	status, err := manager.GetReloadStatus(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+func getNginxInfo(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	info, err := manager.GetInstanceNginxInfo(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, info)
+}
+
+func getNginxStatus(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	status, err := manager.GetNginxStatus(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+func servicesStatus(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	instances := c.QueryParams()["instance"]
+	if len(instances) == 0 {
+		return c.String(http.StatusBadRequest, "at least one instance must be provided")
+	}
+	statuses, err := manager.GetInstancesStatus(c.Request().Context(), instances)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, statuses)
+}
+
+func getInstanceResources(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	resources, err := manager.GetInstanceResources(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resources)
+}
+
+func getInstanceTags(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	tags, err := manager.GetInstanceTags(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+func exportInstance(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "yaml" {
+		return &rpaas.ValidationError{Msg: fmt.Sprintf("invalid export format %q", format)}
+	}
+
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	export, err := manager.ExportInstance(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	// Redacted exports are meant for read-only clients, as the names of the
+	// Secrets and ConfigMaps are lost they can't be imported back.
+	if c.QueryParam("redact") == "true" {
+		export.Spec = rpaas.RedactInstanceSpec(export.Spec)
+	}
+
+	if format != "yaml" {
+		return c.JSON(http.StatusOK, export)
+	}
+
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, "application/x-yaml", data)
+}
+
+func cloneInstance(c echo.Context) error {
+	var args rpaas.CloneArgs
+	if err := c.Bind(&args); err != nil {
+		return err
+	}
+
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.CloneInstance(c.Request().Context(), c.Param("instance"), args); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusCreated)
+}
+
+func importInstance(c echo.Context) error {
+	data, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return &rpaas.ValidationError{Msg: "exported instance is required"}
+	}
+
+	// As JSON is a subset of YAML, both formats are accepted here.
+	var export rpaas.InstanceExport
+	if err = yaml.Unmarshal(data, &export); err != nil {
+		return &rpaas.ValidationError{Msg: fmt.Sprintf("could not parse the exported instance: %s", err)}
+	}
+
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	if err = manager.ImportInstance(c.Request().Context(), c.Param("instance"), export); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusCreated)
+}
+
 func healthcheck(c echo.Context) error {
 	return c.String(http.StatusOK, "OK")
 }
@@ -0,0 +1,63 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+func getMaps(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	maps, err := manager.GetMaps(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	if maps == nil {
+		maps = []rpaas.MapArgs{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"maps": maps})
+}
+
+func updateMap(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	var args rpaas.MapArgs
+	if err = c.Bind(&args); err != nil {
+		return err
+	}
+
+	err = manager.UpdateMap(c.Request().Context(), c.Param("instance"), args)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func deleteMap(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	err = manager.DeleteMap(c.Request().Context(), c.Param("instance"), c.Param("variable"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
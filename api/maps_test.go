@@ -0,0 +1,167 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_getMaps(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when instance has no maps",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"maps":[]}`,
+			manager: &fake.RpaasManager{
+				FakeGetMaps: func(instanceName string) ([]rpaas.MapArgs, error) {
+					return nil, nil
+				},
+			},
+		},
+		{
+			name:         "when instance has maps",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"maps":[{"variable":"backend","source":"$http_x_version","default":"v1","entries":[{"key":"beta","value":"v2"}]}]}`,
+			manager: &fake.RpaasManager{
+				FakeGetMaps: func(instanceName string) ([]rpaas.MapArgs, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return []rpaas.MapArgs{
+						{
+							Variable: "backend",
+							Source:   "$http_x_version",
+							Default:  "v1",
+							Entries:  []rpaas.MapEntry{{Key: "beta", Value: "v2"}},
+						},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/map", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.JSONEq(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
+func Test_updateMap(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the manager returns a validation error",
+			requestBody:  `{"variable":"rpaas_backend","source":"$host","entries":[{"key":"stable","value":"v1"}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "is reserved",
+			manager: &fake.RpaasManager{
+				FakeUpdateMap: func(instanceName string, args rpaas.MapArgs) error {
+					return &rpaas.ValidationError{Msg: `variable name "rpaas_backend" is reserved`}
+				},
+			},
+		},
+		{
+			name:         "when successfully updating the map",
+			requestBody:  `{"variable":"backend","source":"$http_x_version","default":"v1","entries":[{"key":"beta","value":"v2"}]}`,
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateMap: func(instanceName string, args rpaas.MapArgs) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, rpaas.MapArgs{
+						Variable: "backend",
+						Source:   "$http_x_version",
+						Default:  "v1",
+						Entries:  []rpaas.MapEntry{{Key: "beta", Value: "v2"}},
+					}, args)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/map", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func Test_deleteMap(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when map does not exist",
+			expectedCode: http.StatusNotFound,
+			manager: &fake.RpaasManager{
+				FakeDeleteMap: func(instanceName, variable string) error {
+					return &rpaas.NotFoundError{Msg: "map not found"}
+				},
+			},
+		},
+		{
+			name:         "when successfully deleting the map",
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeDeleteMap: func(instanceName, variable string) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, "backend", variable)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/map/backend", srv.URL)
+			request, err := http.NewRequest(http.MethodDelete, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tsuru/rpaas-operator/config"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rpaasv2",
+		Subsystem: "api",
+		Name:      "requests_total",
+		Help:      "Total number of requests handled by the API, partitioned by method, path and status code.",
+	}, []string{"method", "path", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "rpaasv2",
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the requests handled by the API, partitioned by method and path.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(requestsTotal, requestDuration)
+}
+
+func metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !config.Get().MetricsEnabled {
+			return next(c)
+		}
+
+		start := time.Now()
+		err := next(c)
+
+		code := c.Response().Status
+		if err != nil {
+			code = http.StatusInternalServerError
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				code = httpErr.Code
+			}
+		}
+
+		path := c.Path()
+		requestsTotal.WithLabelValues(c.Request().Method, path, strconv.Itoa(code)).Inc()
+		requestDuration.WithLabelValues(c.Request().Method, path).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+func metrics(c echo.Context) error {
+	if !config.Get().MetricsEnabled {
+		return echo.ErrNotFound
+	}
+	promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
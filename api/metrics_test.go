@@ -0,0 +1,58 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_metrics(t *testing.T) {
+	t.Run("when metrics are disabled", func(t *testing.T) {
+		config.Set(config.RpaasConfig{})
+		srv := newTestingServer(t, &fake.RpaasManager{})
+		defer srv.Close()
+		rsp, err := srv.Client().Get(fmt.Sprintf("%s/metrics", srv.URL))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+	})
+
+	t.Run("when metrics are enabled", func(t *testing.T) {
+		config.Set(config.RpaasConfig{
+			MetricsEnabled: true,
+			APIUsername:    "user",
+			APIPassword:    "pass",
+		})
+		defer config.Set(config.RpaasConfig{})
+		srv := newTestingServer(t, &fake.RpaasManager{})
+		defer srv.Close()
+
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/resources/my-instance/autoscale", srv.URL), nil)
+		require.NoError(t, err)
+		rsp, err := srv.Client().Do(request)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, rsp.StatusCode)
+
+		request, err = http.NewRequest(http.MethodGet, fmt.Sprintf("%s/healthcheck", srv.URL), nil)
+		require.NoError(t, err)
+		rsp, err = srv.Client().Do(request)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+		rsp, err = srv.Client().Get(fmt.Sprintf("%s/metrics", srv.URL))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		body := bodyContent(rsp)
+		assert.Contains(t, body, `rpaasv2_api_requests_total{code="401",method="GET",path="/resources/:instance/autoscale"}`)
+		assert.Contains(t, body, `rpaasv2_api_requests_total{code="200",method="GET",path="/healthcheck"}`)
+		assert.Contains(t, body, `rpaasv2_api_request_duration_seconds_count{method="GET",path="/healthcheck"}`)
+	})
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+func updatePodDisruptionBudget(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	var pdb rpaas.PodDisruptionBudget
+	if err = c.Bind(&pdb); err != nil {
+		return err
+	}
+
+	err = manager.UpdatePodDisruptionBudget(c.Request().Context(), c.Param("instance"), pdb)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func deletePodDisruptionBudget(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	err = manager.DeletePodDisruptionBudget(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
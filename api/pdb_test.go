@@ -0,0 +1,109 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_updatePodDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the manager returns a validation error",
+			requestBody:  "min_available=1&max_unavailable=1",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "exactly one of min available or max unavailable is required",
+			manager: &fake.RpaasManager{
+				FakeUpdatePodDisruptionBudget: func(instanceName string, pdb rpaas.PodDisruptionBudget) error {
+					return rpaas.ValidationError{Msg: "exactly one of min available or max unavailable is required"}
+				},
+			},
+		},
+		{
+			name:         "when successfully updating the pod disruption budget",
+			requestBody:  "max_unavailable=25%25",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdatePodDisruptionBudget: func(instanceName string, pdb rpaas.PodDisruptionBudget) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, rpaas.PodDisruptionBudget{MaxUnavailable: "25%"}, pdb)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/pdb", srv.URL)
+			request, err := http.NewRequest(http.MethodPut, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func Test_deletePodDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the pod disruption budget is not set",
+			expectedCode: http.StatusNotFound,
+			manager: &fake.RpaasManager{
+				FakeDeletePodDisruptionBudget: func(instanceName string) error {
+					return rpaas.NotFoundError{Msg: "pod disruption budget not found"}
+				},
+			},
+		},
+		{
+			name:         "when successfully removing the pod disruption budget",
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeDeletePodDisruptionBudget: func(instanceName string) error {
+					assert.Equal(t, "my-instance", instanceName)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/pdb", srv.URL)
+			request, err := http.NewRequest(http.MethodDelete, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+		})
+	}
+}
@@ -5,6 +5,9 @@
 package api
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -20,12 +23,16 @@ func deleteRoute(c echo.Context) error {
 		return err
 	}
 
-	path, err := formValue(c.Request(), "path")
+	paths, err := formValues(c.Request(), "path")
 	if err != nil {
 		return &rpaas.ValidationError{Msg: err.Error()}
 	}
 
-	err = manager.DeleteRoute(c.Request().Context(), c.Param("instance"), path)
+	if len(paths) == 1 {
+		err = manager.DeleteRoute(c.Request().Context(), c.Param("instance"), paths[0])
+	} else {
+		err = manager.DeleteRoutes(c.Request().Context(), c.Param("instance"), paths...)
+	}
 	if err != nil {
 		return err
 	}
@@ -39,7 +46,12 @@ func getRoutes(c echo.Context) error {
 		return err
 	}
 
-	routes, err := manager.GetRoutes(c.Request().Context(), c.Param("instance"))
+	var routes []rpaas.Route
+	if destination := c.QueryParam("destination"); destination != "" {
+		routes, err = manager.FindRoutesByDestination(c.Request().Context(), c.Param("instance"), destination)
+	} else {
+		routes, err = manager.GetRoutes(c.Request().Context(), c.Param("instance"))
+	}
 	if err != nil {
 		return err
 	}
@@ -72,32 +84,131 @@ func updateRoute(c echo.Context) error {
 	return c.NoContent(http.StatusCreated)
 }
 
+func testRouteDestination(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	check, err := manager.TestDestination(c.Request().Context(), c.Param("instance"), c.FormValue("destination"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+// maxBulkRouteLineSize bounds each NDJSON record, which must fit a route
+// whose content is stored in a ConfigMap.
+const maxBulkRouteLineSize = 2 * 1024 * 1024
+
+type bulkRouteResult struct {
+	Line  int    `json:"line"`
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// bulkUpdateRoutes applies newline-delimited JSON routes one at a time, so
+// the whole set is never buffered, and streams a result per record. Invalid
+// routes are reported and skipped, while a malformed line aborts the rest.
+// A path repeated within the batch is rejected rather than replacing the
+// route applied earlier, although a path whose route failed can be retried.
+func bulkUpdateRoutes(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	instanceName := c.Param("instance")
+	if _, err = manager.GetInstance(ctx, instanceName); err != nil {
+		return err
+	}
+
+	rsp := c.Response()
+	rsp.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	rsp.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(rsp)
+
+	scanner := bufio.NewScanner(c.Request().Body)
+	scanner.Buffer(make([]byte, 64*1024), maxBulkRouteLineSize)
+	line := 0
+	seenPaths := make(map[string]bool)
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var route rpaas.Route
+		if err = json.Unmarshal(data, &route); err != nil {
+			return writeBulkRouteResult(rsp, encoder, bulkRouteResult{Line: line, Error: fmt.Sprintf("malformed route: %v", err)})
+		}
+
+		result := bulkRouteResult{Line: line, Path: route.Path}
+		path := rpaas.NormalizeRoutePath(route.Path)
+		if seenPaths[path] {
+			result.Error = fmt.Sprintf("duplicate route path %q", path)
+		} else if err = manager.UpdateRoute(ctx, instanceName, route); err != nil {
+			result.Error = err.Error()
+		} else {
+			seenPaths[path] = true
+		}
+		if err = writeBulkRouteResult(rsp, encoder, result); err != nil {
+			return err
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return writeBulkRouteResult(rsp, encoder, bulkRouteResult{Line: line + 1, Error: fmt.Sprintf("could not read route: %v", err)})
+	}
+	return nil
+}
+
+func writeBulkRouteResult(rsp *echo.Response, encoder *json.Encoder, result bulkRouteResult) error {
+	if err := encoder.Encode(result); err != nil {
+		return err
+	}
+	rsp.Flush()
+	return nil
+}
+
 // formValue does the same as http.Request.FormValue method and works fine on
 // DELETE request as well.
 func formValue(req *http.Request, key string) (string, error) {
+	values, err := formValues(req, key)
+	if err != nil {
+		return "", err
+	}
+
+	return values[0], nil
+}
+
+func formValues(req *http.Request, key string) ([]string, error) {
 	if req.Header.Get("content-type") != echo.MIMEApplicationForm {
-		return "", fmt.Errorf("content-type is not application form")
+		return nil, fmt.Errorf("content-type is not application form")
 	}
 
 	rawBody, err := ioutil.ReadAll(req.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer req.Body.Close()
 
 	if len(rawBody) == 0 {
-		return "", fmt.Errorf("missing body message")
+		return nil, fmt.Errorf("missing body message")
 	}
 
 	queryByKey, err := url.ParseQuery(string(rawBody))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	values := queryByKey[key]
 	if len(values) == 0 {
-		return "", fmt.Errorf("missing key %q", key)
+		return nil, fmt.Errorf("missing key %q", key)
 	}
 
-	return values[0], nil
+	return values, nil
 }
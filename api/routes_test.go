@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
 )
 
 func Test_deleteRoute(t *testing.T) {
@@ -73,6 +74,31 @@ func Test_deleteRoute(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "when deleting several routes at once",
+			instance:     "my-instance",
+			requestBody:  "path=/path1&path=/path2",
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeDeleteRoutes: func(instanceName string, paths ...string) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, []string{"/path1", "/path2"}, paths)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when deleting several routes and some do not exist",
+			instance:     "my-instance",
+			requestBody:  "path=/path1&path=/path2",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "paths do not exist: /path2",
+			manager: &fake.RpaasManager{
+				FakeDeleteRoutes: func(instanceName string, paths ...string) error {
+					return &rpaas.NotFoundError{Msg: "paths do not exist: /path2"}
+				},
+			},
+		},
 		{
 			name:         "when request has no body message",
 			instance:     "my-instance",
@@ -102,6 +128,7 @@ func Test_getRoutes(t *testing.T) {
 	tests := []struct {
 		name           string
 		instance       string
+		query          string
 		expectedCode   int
 		expectedRoutes []rpaas.Route
 		manager        rpaas.RpaasManager
@@ -164,13 +191,41 @@ func Test_getRoutes(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "when filtering routes by destination",
+			instance:     "my-instance",
+			query:        "?destination=app2",
+			expectedCode: http.StatusOK,
+			expectedRoutes: []rpaas.Route{
+				{
+					Path:        "/path2",
+					Destination: "app2.tsuru.example.com",
+				},
+			},
+			manager: &fake.RpaasManager{
+				FakeGetRoutes: func(instanceName string) ([]rpaas.Route, error) {
+					t.Errorf("GetRoutes should not be called when filtering by destination")
+					return nil, nil
+				},
+				FakeFindRoutesByDestination: func(instanceName, destination string) ([]rpaas.Route, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, "app2", destination)
+					return []rpaas.Route{
+						{
+							Path:        "/path2",
+							Destination: "app2.tsuru.example.com",
+						},
+					}, nil
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			srv := newTestingServer(t, tt.manager)
 			defer srv.Close()
-			path := fmt.Sprintf("%s/resources/%s/route", srv.URL, tt.instance)
+			path := fmt.Sprintf("%s/resources/%s/route%s", srv.URL, tt.instance, tt.query)
 			request, err := http.NewRequest(http.MethodGet, path, nil)
 			require.NoError(t, err)
 			rsp, err := srv.Client().Do(request)
@@ -220,6 +275,119 @@ func Test_updateRoute(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "when update route disables buffering",
+			instance:     "my-instance",
+			requestBody:  "path=/events&destination=app1.tsuru.example.com&buffering=false",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, "my-instance", instanceName)
+					require.NotNil(t, route.Buffering)
+					assert.False(t, *route.Buffering)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when update route sets the load balancing method",
+			instance:     "my-instance",
+			requestBody:  "path=/app&destination=app1.tsuru.example.com&load_balancing=ip_hash",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, rpaas.Route{
+						Path:          "/app",
+						Destination:   "app1.tsuru.example.com",
+						LoadBalancing: "ip_hash",
+					}, route)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when update route sets a regex match type",
+			instance:     "my-instance",
+			requestBody:  "path=%5E%2Fapi%2Fv%5B0-9%5D%2B%2F&match_type=regex&destination=app1.tsuru.example.com",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, rpaas.Route{
+						Path:        "^/api/v[0-9]+/",
+						MatchType:   "regex",
+						Destination: "app1.tsuru.example.com",
+					}, route)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when update route restricts the source networks",
+			instance:     "my-instance",
+			requestBody:  "path=/internal&destination=app1.tsuru.example.com&allow_cidrs=10.0.0.0/8&allow_cidrs=172.16.0.0/12&deny_cidrs=192.168.0.0/16",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, rpaas.Route{
+						Path:        "/internal",
+						Destination: "app1.tsuru.example.com",
+						AllowCIDRs:  []string{"10.0.0.0/8", "172.16.0.0/12"},
+						DenyCIDRs:   []string{"192.168.0.0/16"},
+					}, route)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when update route presents a client certificate",
+			instance:     "my-instance",
+			requestBody:  "path=/secure&destination=app1.tsuru.example.com:8443&client_certificate=upstream",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, rpaas.Route{
+						Path:              "/secure",
+						Destination:       "app1.tsuru.example.com:8443",
+						ClientCertificate: "upstream",
+					}, route)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when update route verifies the upstream certificate",
+			instance:     "my-instance",
+			requestBody:  "path=/secure&destination=app1.tsuru.example.com:8443&upstream_tls_verify=true&upstream_trusted_ca=upstream-ca.crt",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, rpaas.Route{
+						Path:              "/secure",
+						Destination:       "app1.tsuru.example.com:8443",
+						UpstreamTLSVerify: true,
+						UpstreamTrustedCA: "upstream-ca.crt",
+					}, route)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when update route serves a static file",
+			instance:     "my-instance",
+			requestBody:  "path=/&match_type=exact&static_file=index.html",
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, rpaas.Route{
+						Path:       "/",
+						MatchType:  "exact",
+						StaticFile: "index.html",
+					}, route)
+					return nil
+				},
+			},
+		},
 		{
 			name:         "when update route returns some error",
 			instance:     "my-instance",
@@ -255,3 +423,173 @@ func Test_updateRoute(t *testing.T) {
 		})
 	}
 }
+
+func Test_bulkUpdateRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when instance is not found",
+			requestBody:  `{"path": "/a", "destination": "app1.tsuru.example.com"}`,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "rpaas instance not found",
+			manager: &fake.RpaasManager{
+				FakeGetInstance: func(instanceName string) (*v1alpha1.RpaasInstance, error) {
+					return nil, &rpaas.NotFoundError{Msg: "rpaas instance not found"}
+				},
+			},
+		},
+		{
+			name:         "when all routes are applied",
+			requestBody:  "{\"path\": \"/a\", \"destination\": \"app1.tsuru.example.com\"}\n\n{\"path\": \"/b\", \"destination\": \"app2.tsuru.example.com\"}\n",
+			expectedCode: http.StatusOK,
+			expectedBody: "{\"line\":1,\"path\":\"/a\"}\n{\"line\":3,\"path\":\"/b\"}\n",
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Contains(t, []string{"/a", "/b"}, route.Path)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when some route is invalid",
+			requestBody:  "{\"path\": \"/a\", \"destination\": \"app1.tsuru.example.com\"}\n{\"path\": \"/b\"}\n",
+			expectedCode: http.StatusOK,
+			expectedBody: "{\"line\":1,\"path\":\"/a\"}\n{\"line\":2,\"path\":\"/b\",\"error\":\"either content or destination are required\"}\n",
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					if route.Destination == "" {
+						return &rpaas.ValidationError{Msg: "either content or destination are required"}
+					}
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when a path is repeated",
+			requestBody:  "{\"path\": \"/dup\", \"destination\": \"app1.tsuru.example.com\"}\n{\"path\": \"/b\", \"destination\": \"app1.tsuru.example.com\"}\n{\"path\": \"/dup\", \"destination\": \"app2.tsuru.example.com\"}\n",
+			expectedCode: http.StatusOK,
+			expectedBody: "{\"line\":1,\"path\":\"/dup\"}\n{\"line\":2,\"path\":\"/b\"}\n{\"line\":3,\"path\":\"/dup\",\"error\":\"duplicate route path \\\"/dup\\\"\"}\n",
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, "app1.tsuru.example.com", route.Destination)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when a path is repeated after its route failed",
+			requestBody:  "{\"path\": \"/dup\"}\n{\"path\": \"/dup\", \"destination\": \"app1.tsuru.example.com\"}\n",
+			expectedCode: http.StatusOK,
+			expectedBody: "{\"line\":1,\"path\":\"/dup\",\"error\":\"either content or destination are required\"}\n{\"line\":2,\"path\":\"/dup\"}\n",
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					if route.Destination == "" {
+						return &rpaas.ValidationError{Msg: "either content or destination are required"}
+					}
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when some line is malformed",
+			requestBody:  "{\"path\": \"/a\", \"destination\": \"app1.tsuru.example.com\"}\n{\"path\": \n{\"path\": \"/c\", \"destination\": \"app1.tsuru.example.com\"}\n",
+			expectedCode: http.StatusOK,
+			expectedBody: "{\"line\":1,\"path\":\"/a\"}\n{\"line\":2,\"error\":\"malformed route: unexpected end of JSON input\"}\n",
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, "/a", route.Path)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/route/bulk", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, "application/x-ndjson")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, bodyContent(rsp))
+				return
+			}
+			assert.Regexp(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
+func Test_testRouteDestination(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the destination is reachable",
+			requestBody:  "destination=app.tsuru.example.com",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"destination":"app.tsuru.example.com","pod":"my-instance-pod-1","reachable":true,"latency_ms":1.5}
+`,
+			manager: &fake.RpaasManager{
+				FakeTestDestination: func(instanceName, destination string) (*rpaas.DestinationCheck, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, "app.tsuru.example.com", destination)
+					return &rpaas.DestinationCheck{Destination: destination, Pod: "my-instance-pod-1", Reachable: true, LatencyMs: 1.5}, nil
+				},
+			},
+		},
+		{
+			name:         "when the destination is not reachable",
+			requestBody:  "destination=app.tsuru.example.com:8081",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"destination":"app.tsuru.example.com:8081","pod":"my-instance-pod-1","reachable":false,"error":"connection refused"}
+`,
+			manager: &fake.RpaasManager{
+				FakeTestDestination: func(instanceName, destination string) (*rpaas.DestinationCheck, error) {
+					return &rpaas.DestinationCheck{Destination: destination, Pod: "my-instance-pod-1", Error: "connection refused"}, nil
+				},
+			},
+		},
+		{
+			name:         "when the instance has no running pods",
+			requestBody:  "destination=app.tsuru.example.com",
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"Msg":"instance has no running pods to check the destination from"}
+`,
+			manager: &fake.RpaasManager{
+				FakeTestDestination: func(instanceName, destination string) (*rpaas.DestinationCheck, error) {
+					return nil, &rpaas.ConflictError{Msg: "instance has no running pods to check the destination from"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/route/check", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
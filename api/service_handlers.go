@@ -7,10 +7,14 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func serviceCreate(c echo.Context) error {
@@ -23,13 +27,37 @@ func serviceCreate(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	err = manager.CreateInstance(c.Request().Context(), args)
+	args.DryRun = c.QueryParam("dry-run") == "true"
+	instance, err := manager.CreateInstance(c.Request().Context(), args)
 	if err != nil {
 		return err
 	}
+	if args.DryRun {
+		return c.JSON(http.StatusOK, instance)
+	}
 	return c.NoContent(http.StatusCreated)
 }
 
+func servicePreview(c echo.Context) error {
+	var args rpaas.CreateArgs
+	if err := c.Bind(&args); err != nil {
+		return err
+	}
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+	service, err := manager.PreviewService(c.Request().Context(), args)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, service)
+}
+
+// defaultDeleteWaitTimeout is how long a deletion with "wait=true" waits for
+// the instance teardown, unless the "timeout" parameter is set.
+const defaultDeleteWaitTimeout = 2 * time.Minute
+
 func serviceDelete(c echo.Context) error {
 	name := c.Param("instance")
 	if len(name) == 0 {
@@ -43,6 +71,17 @@ func serviceDelete(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.QueryParam("wait") == "true" {
+		timeout := defaultDeleteWaitTimeout
+		if value := c.QueryParam("timeout"); value != "" {
+			if timeout, err = time.ParseDuration(value); err != nil || timeout <= 0 {
+				return &rpaas.ValidationError{Msg: fmt.Sprintf("invalid timeout %q", value)}
+			}
+		}
+		if err = manager.WaitInstanceTeardown(c.Request().Context(), name, timeout); err != nil {
+			return err
+		}
+	}
 	return c.NoContent(http.StatusOK)
 }
 
@@ -57,10 +96,15 @@ func serviceUpdate(c echo.Context) error {
 		return err
 	}
 
-	if err = manager.UpdateInstance(c.Request().Context(), c.Param("instance"), args); err != nil {
+	args.DryRun = c.QueryParam("dry-run") == "true"
+	instance, err := manager.UpdateInstance(c.Request().Context(), c.Param("instance"), args)
+	if err != nil {
 		return err
 	}
 
+	if args.DryRun {
+		return c.JSON(http.StatusOK, instance)
+	}
 	return c.NoContent(http.StatusOK)
 }
 
@@ -97,6 +141,36 @@ func servicePlans(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+// planDetail is a plan along with its spec, after merging the flavor if any.
+type planDetail struct {
+	plan
+	Flavor string                 `json:"flavor,omitempty"`
+	Spec   v1alpha1.RpaasPlanSpec `json:"spec"`
+}
+
+func getPlan(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	flavor := c.QueryParam("flavor")
+	p, err := manager.GetPlan(c.Request().Context(), c.Param("plan"), flavor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, planDetail{
+		plan: plan{
+			Name:        p.Name,
+			Description: p.Spec.Description,
+			Default:     p.Spec.Default,
+		},
+		Flavor: flavor,
+		Spec:   p.Spec,
+	})
+}
+
 func serviceInfo(c echo.Context) error {
 	manager, err := getManager(c)
 	if err != nil {
@@ -107,9 +181,17 @@ func serviceInfo(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	replicas := "0"
+	var desired int32
 	if instance.Spec.Replicas != nil {
-		replicas = fmt.Sprintf("%d", *instance.Spec.Replicas)
+		desired = *instance.Spec.Replicas
+	}
+	replicas := fmt.Sprintf("%d", desired)
+	// The live count may differ from the desired one while scaling (e.g. by
+	// the autoscaler), so it's preferred whenever the status is available.
+	if podStatus, statusErr := manager.GetInstanceStatus(c.Request().Context(), instanceName); statusErr == nil && podStatus != nil {
+		if ready := countReadyPods(podStatus); ready != desired {
+			replicas = fmt.Sprintf("%d (desired: %d)", ready, desired)
+		}
 	}
 	address, err := manager.GetInstanceAddress(c.Request().Context(), instanceName)
 	if err != nil {
@@ -135,10 +217,56 @@ func serviceInfo(c echo.Context) error {
 			"label": "Routes",
 			"value": strings.Join(routes, "\n"),
 		},
+		{
+			"label": "Pending reconcile",
+			"value": strconv.FormatBool(instance.PendingReconcile()),
+		},
+	}
+	// The plan is only informative here, so failing to resolve it must not
+	// break the info screen.
+	requests, limits := "unknown", "unknown"
+	if resources, planErr := manager.GetInstancePlanResources(c.Request().Context(), instanceName); planErr == nil {
+		if resources == nil {
+			resources = &corev1.ResourceRequirements{}
+		}
+		requests = formatResourceList(resources.Requests)
+		limits = formatResourceList(resources.Limits)
 	}
+	ret = append(ret,
+		map[string]string{
+			"label": "Requests (CPU / Memory)",
+			"value": requests,
+		},
+		map[string]string{
+			"label": "Limits (CPU / Memory)",
+			"value": limits,
+		},
+	)
 	return c.JSON(http.StatusOK, ret)
 }
 
+// formatResourceList renders the CPU and memory quantities as e.g.
+// "500m / 512Mi", using "unset" for the missing ones.
+func formatResourceList(list corev1.ResourceList) string {
+	values := []string{"unset", "unset"}
+	for i, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if quantity, ok := list[name]; ok {
+			values[i] = quantity.String()
+		}
+	}
+	return strings.Join(values, " / ")
+}
+
+func countReadyPods(podStatus rpaas.PodStatusMap) int32 {
+	var ready int32
+	for _, st := range podStatus {
+		if st.Running && !st.Terminating {
+			ready++
+		}
+	}
+	return ready
+}
+
 func serviceBindApp(c echo.Context) error {
 	manager, err := getManager(c)
 	if err != nil {
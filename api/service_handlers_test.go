@@ -6,12 +6,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -20,11 +22,14 @@ import (
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_serviceCreate(t *testing.T) {
 	testCases := []struct {
+		query        string
 		requestBody  string
 		expectedCode int
 		expectedBody string
@@ -41,8 +46,8 @@ func Test_serviceCreate(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "name is required",
 			manager: &fake.RpaasManager{
-				FakeCreateInstance: func(rpaas.CreateArgs) error {
-					return rpaas.ValidationError{Msg: "name is required"}
+				FakeCreateInstance: func(rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					return nil, rpaas.ValidationError{Msg: "name is required"}
 				},
 			},
 		},
@@ -51,8 +56,8 @@ func Test_serviceCreate(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "plan is required",
 			manager: &fake.RpaasManager{
-				FakeCreateInstance: func(rpaas.CreateArgs) error {
-					return rpaas.ValidationError{Msg: "plan is required"}
+				FakeCreateInstance: func(rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					return nil, rpaas.ValidationError{Msg: "plan is required"}
 				},
 			},
 		},
@@ -61,8 +66,8 @@ func Test_serviceCreate(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "team name is required",
 			manager: &fake.RpaasManager{
-				FakeCreateInstance: func(rpaas.CreateArgs) error {
-					return rpaas.ValidationError{Msg: "team name is required"}
+				FakeCreateInstance: func(rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					return nil, rpaas.ValidationError{Msg: "team name is required"}
 				},
 			},
 		},
@@ -71,8 +76,8 @@ func Test_serviceCreate(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "invalid plan",
 			manager: &fake.RpaasManager{
-				FakeCreateInstance: func(rpaas.CreateArgs) error {
-					return rpaas.ValidationError{Msg: "invalid plan"}
+				FakeCreateInstance: func(rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					return nil, rpaas.ValidationError{Msg: "invalid plan"}
 				},
 			},
 		},
@@ -81,8 +86,8 @@ func Test_serviceCreate(t *testing.T) {
 			expectedCode: http.StatusConflict,
 			expectedBody: "firstinstance instance already exists",
 			manager: &fake.RpaasManager{
-				FakeCreateInstance: func(rpaas.CreateArgs) error {
-					return rpaas.ConflictError{Msg: "firstinstance instance already exists"}
+				FakeCreateInstance: func(rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					return nil, rpaas.ConflictError{Msg: "firstinstance instance already exists"}
 				},
 			},
 		},
@@ -92,13 +97,42 @@ func Test_serviceCreate(t *testing.T) {
 			expectedBody: "",
 			manager:      &fake.RpaasManager{},
 		},
+		{
+			requestBody:  "name=otherinstance&plan=myplan&team=myteam&node_selector=disktype%3Dssd%2Czone%3Da",
+			expectedCode: http.StatusCreated,
+			expectedBody: "",
+			manager: &fake.RpaasManager{
+				FakeCreateInstance: func(args rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					assert.Equal(t, rpaas.NodeSelector{"disktype": "ssd", "zone": "a"}, args.NodeSelector)
+					return nil, nil
+				},
+			},
+		},
+		{
+			requestBody:  "name=otherinstance&plan=myplan&team=myteam&node_selector=disktype",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid node selector",
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			query:        "?dry-run=true",
+			requestBody:  "name=otherinstance&plan=myplan&team=myteam",
+			expectedCode: http.StatusOK,
+			expectedBody: `"name":"otherinstance"`,
+			manager: &fake.RpaasManager{
+				FakeCreateInstance: func(args rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					assert.True(t, args.DryRun)
+					return &v1alpha1.RpaasInstance{ObjectMeta: metav1.ObjectMeta{Name: args.Name}}, nil
+				},
+			},
+		},
 	}
 
 	for _, tt := range testCases {
 		t.Run("", func(t *testing.T) {
 			srv := newTestingServer(t, tt.manager)
 			defer srv.Close()
-			path := fmt.Sprintf("%s/resources", srv.URL)
+			path := fmt.Sprintf("%s/resources%s", srv.URL, tt.query)
 			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
 			require.NoError(t, err)
 			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
@@ -110,9 +144,112 @@ func Test_serviceCreate(t *testing.T) {
 	}
 }
 
+func Test_servicePreview(t *testing.T) {
+	testCases := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the arguments are invalid",
+			requestBody:  "name=rpaas&plan=myplan",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "team name is required",
+			manager: &fake.RpaasManager{
+				FakePreviewService: func(rpaas.CreateArgs) (*nginxv1alpha1.NginxService, error) {
+					return nil, rpaas.ValidationError{Msg: "team name is required"}
+				},
+			},
+		},
+		{
+			name:         "when the Service is successfully computed",
+			requestBody:  "name=rpaas&plan=myplan&team=myteam&service_type=NodePort",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"type":"NodePort","labels":{"rpaas_instance":"rpaas"},"annotations":{"example.com/internal":"true"}}`,
+			manager: &fake.RpaasManager{
+				FakePreviewService: func(args rpaas.CreateArgs) (*nginxv1alpha1.NginxService, error) {
+					assert.Equal(t, rpaas.CreateArgs{Name: "rpaas", Plan: "myplan", Team: "myteam", ServiceType: "NodePort"}, args)
+					return &nginxv1alpha1.NginxService{
+						Type:        corev1.ServiceTypeNodePort,
+						Labels:      map[string]string{"rpaas_instance": "rpaas"},
+						Annotations: map[string]string{"example.com/internal": "true"},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/service-preview", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+		})
+	}
+}
+
+func Test_serviceCreate_planOverride(t *testing.T) {
+	testCases := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "with a structured plan override",
+			requestBody:  `{"name":"rpaas","plan":"myplan","team":"myteam","plan_override":{"image":"my.registry.test/nginx:latest"}}`,
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeCreateInstance: func(args rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					assert.Equal(t, &rpaas.PlanTemplate{Image: "my.registry.test/nginx:latest"}, args.PlanOverride)
+					return nil, nil
+				},
+			},
+		},
+		{
+			name:         "with an unknown plan override field",
+			requestBody:  `{"name":"rpaas","plan":"myplan","team":"myteam","plan_override":{"imagee":"my.registry.test/nginx:latest"}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `invalid plan override: json: unknown field \"imagee\"`,
+			manager: &fake.RpaasManager{
+				FakeCreateInstance: func(args rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
+					t.Error("instance should not be created")
+					return nil, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+		})
+	}
+}
+
 func Test_serviceDelete(t *testing.T) {
 	testCases := []struct {
 		instanceName string
+		query        string
 		expectedCode int
 		expectedBody string
 		manager      rpaas.RpaasManager
@@ -131,6 +268,43 @@ func Test_serviceDelete(t *testing.T) {
 			instanceName: "my-instance",
 			expectedCode: http.StatusOK,
 			expectedBody: "",
+			manager: &fake.RpaasManager{
+				FakeWaitInstanceTeardown: func(instance string, timeout time.Duration) error {
+					t.Error("teardown should not be waited")
+					return nil
+				},
+			},
+		},
+		{
+			instanceName: "my-instance",
+			query:        "?wait=true",
+			expectedCode: http.StatusOK,
+			expectedBody: "",
+			manager: &fake.RpaasManager{
+				FakeWaitInstanceTeardown: func(instance string, timeout time.Duration) error {
+					assert.Equal(t, "my-instance", instance)
+					assert.Equal(t, 2*time.Minute, timeout)
+					return nil
+				},
+			},
+		},
+		{
+			instanceName: "my-instance",
+			query:        "?wait=true&timeout=30s",
+			expectedCode: http.StatusGatewayTimeout,
+			expectedBody: `still present: pod \\"my-instance-abcde\\"`,
+			manager: &fake.RpaasManager{
+				FakeWaitInstanceTeardown: func(instance string, timeout time.Duration) error {
+					assert.Equal(t, 30*time.Second, timeout)
+					return &rpaas.TimeoutError{Msg: `timed out waiting for the teardown of instance "my-instance", still present: pod "my-instance-abcde"`}
+				},
+			},
+		},
+		{
+			instanceName: "my-instance",
+			query:        "?wait=true&timeout=forever",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `invalid timeout \\"forever\\"`,
 			manager:      &fake.RpaasManager{},
 		},
 	}
@@ -139,7 +313,7 @@ func Test_serviceDelete(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			srv := newTestingServer(t, tt.manager)
 			defer srv.Close()
-			path := fmt.Sprintf("%s/resources/%s", srv.URL, tt.instanceName)
+			path := fmt.Sprintf("%s/resources/%s%s", srv.URL, tt.instanceName, tt.query)
 			request, err := http.NewRequest(http.MethodDelete, path, nil)
 			require.NoError(t, err)
 			rsp, err := srv.Client().Do(request)
@@ -154,6 +328,7 @@ func Test_serviceUpdate(t *testing.T) {
 	tests := []struct {
 		name         string
 		instance     string
+		query        string
 		requestBody  string
 		expectedCode int
 		expectedBody string
@@ -172,7 +347,7 @@ func Test_serviceUpdate(t *testing.T) {
 			requestBody:  "description=some%20description&plan=huge&team=team-one&tags=tag1&tags=tag2",
 			expectedCode: http.StatusOK,
 			manager: &fake.RpaasManager{
-				FakeUpdateInstance: func(instanceName string, args rpaas.UpdateInstanceArgs) error {
+				FakeUpdateInstance: func(instanceName string, args rpaas.UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error) {
 					assert.Equal(t, "my-instance", instanceName)
 					assert.Equal(t, rpaas.UpdateInstanceArgs{
 						Description: "some description",
@@ -180,7 +355,21 @@ func Test_serviceUpdate(t *testing.T) {
 						Tags:        []string{"tag1", "tag2"},
 						Team:        "team-one",
 					}, args)
-					return nil
+					return &v1alpha1.RpaasInstance{}, nil
+				},
+			},
+		},
+		{
+			name:         "when running in dry-run mode",
+			instance:     "my-instance",
+			query:        "?dry-run=true",
+			requestBody:  "plan=huge",
+			expectedCode: http.StatusOK,
+			expectedBody: `"planName":"huge"`,
+			manager: &fake.RpaasManager{
+				FakeUpdateInstance: func(instanceName string, args rpaas.UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error) {
+					assert.Equal(t, rpaas.UpdateInstanceArgs{Plan: "huge", DryRun: true}, args)
+					return &v1alpha1.RpaasInstance{Spec: v1alpha1.RpaasInstanceSpec{PlanName: "huge"}}, nil
 				},
 			},
 		},
@@ -191,12 +380,12 @@ func Test_serviceUpdate(t *testing.T) {
 			expectedCode: http.StatusNotFound,
 			expectedBody: "some error",
 			manager: &fake.RpaasManager{
-				FakeUpdateInstance: func(instanceName string, args rpaas.UpdateInstanceArgs) error {
+				FakeUpdateInstance: func(instanceName string, args rpaas.UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error) {
 					assert.Equal(t, "my-instance2", instanceName)
 					assert.Equal(t, rpaas.UpdateInstanceArgs{
 						Plan: "not-found",
 					}, args)
-					return rpaas.NotFoundError{Msg: "some error"}
+					return nil, rpaas.NotFoundError{Msg: "some error"}
 				},
 			},
 		},
@@ -206,7 +395,7 @@ func Test_serviceUpdate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			srv := newTestingServer(t, tt.manager)
 			defer srv.Close()
-			path := fmt.Sprintf("%s/resources/%s", srv.URL, tt.instance)
+			path := fmt.Sprintf("%s/resources/%s%s", srv.URL, tt.instance, tt.query)
 			request, err := http.NewRequest(http.MethodPut, path, strings.NewReader(tt.requestBody))
 			require.NoError(t, err)
 			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
@@ -303,6 +492,74 @@ func Test_servicePlans(t *testing.T) {
 	}
 }
 
+func Test_getPlan(t *testing.T) {
+	manager := &fake.RpaasManager{
+		FakeGetPlan: func(name, flavor string) (*v1alpha1.RpaasPlan, error) {
+			if name != "my-plan" {
+				return nil, rpaas.NotFoundError{Msg: fmt.Sprintf("plan %q not found", name)}
+			}
+			spec := v1alpha1.RpaasPlanSpec{
+				Description: "Some description about my-plan.",
+				Image:       "nginx:1.17",
+				Config:      v1alpha1.NginxConfig{CacheEnabled: v1alpha1.Bool(true), CacheSize: "100M"},
+			}
+			switch flavor {
+			case "":
+			case "no-cache":
+				spec.Config.CacheEnabled = v1alpha1.Bool(false)
+			default:
+				return nil, rpaas.NotFoundError{Msg: fmt.Sprintf("flavor %q not found", flavor)}
+			}
+			return &v1alpha1.RpaasPlan{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}, nil
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "when getting the plan as is",
+			path:         "/resources/plans/my-plan",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"name":"my-plan","description":"Some description about my-plan.","default":false,"spec":{"image":"nginx:1.17","config":{"cacheEnabled":true,"cacheSize":"100M"},"description":"Some description about my-plan.","resources":{}}}`,
+		},
+		{
+			name:         "when getting the plan merged with a flavor",
+			path:         "/resources/plans/my-plan?flavor=no-cache",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"name":"my-plan","description":"Some description about my-plan.","default":false,"flavor":"no-cache","spec":{"image":"nginx:1.17","config":{"cacheEnabled":false,"cacheSize":"100M"},"description":"Some description about my-plan.","resources":{}}}`,
+		},
+		{
+			name:         "when the flavor does not exist",
+			path:         "/resources/plans/my-plan?flavor=strawberry",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"Msg":"flavor \"strawberry\" not found"}`,
+		},
+		{
+			name:         "when the plan does not exist",
+			path:         "/resources/plans/other-plan",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"Msg":"plan \"other-plan\" not found"}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, manager)
+			defer srv.Close()
+			request, err := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
+
 func Test_serviceInfo(t *testing.T) {
 	getAddressOfInt32 := func(n int32) *int32 {
 		return &n
@@ -330,6 +587,18 @@ func Test_serviceInfo(t *testing.T) {
 					"label": "Routes",
 					"value": "",
 				},
+				{
+					"label": "Pending reconcile",
+					"value": "false",
+				},
+				{
+					"label": "Requests (CPU / Memory)",
+					"value": "unset / unset",
+				},
+				{
+					"label": "Limits (CPU / Memory)",
+					"value": "unset / unset",
+				},
 			},
 			manager: &fake.RpaasManager{
 				FakeGetInstance: func(string) (*v1alpha1.RpaasInstance, error) {
@@ -365,6 +634,18 @@ func Test_serviceInfo(t *testing.T) {
 					"label": "Routes",
 					"value": "/status\n/admin",
 				},
+				{
+					"label": "Pending reconcile",
+					"value": "true",
+				},
+				{
+					"label": "Requests (CPU / Memory)",
+					"value": "500m / 512Mi",
+				},
+				{
+					"label": "Limits (CPU / Memory)",
+					"value": "1 / unset",
+				},
 			},
 			manager: &fake.RpaasManager{
 				FakeGetInstance: func(string) (*v1alpha1.RpaasInstance, error) {
@@ -374,7 +655,11 @@ func Test_serviceInfo(t *testing.T) {
 							Kind:       "RpaasInstance",
 						},
 						ObjectMeta: metav1.ObjectMeta{
-							Name: "my-instance",
+							Name:       "my-instance",
+							Generation: 3,
+						},
+						Status: v1alpha1.RpaasInstanceStatus{
+							ObservedGeneration: 2,
 						},
 						Spec: v1alpha1.RpaasInstanceSpec{
 							Replicas: getAddressOfInt32(5),
@@ -391,6 +676,121 @@ func Test_serviceInfo(t *testing.T) {
 				FakeInstanceAddress: func(string) (string, error) {
 					return "127.0.0.1", nil
 				},
+				FakeGetInstancePlanResources: func(string) (*corev1.ResourceRequirements, error) {
+					return &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("1"),
+						},
+					}, nil
+				},
+			},
+		},
+		{
+			instanceName: "my-instance",
+			expectedCode: http.StatusOK,
+			expectedInfo: []map[string]string{
+				{
+					"label": "Address",
+					"value": "127.0.0.1",
+				},
+				{
+					"label": "Instances",
+					"value": "2 (desired: 4)",
+				},
+				{
+					"label": "Routes",
+					"value": "",
+				},
+				{
+					"label": "Pending reconcile",
+					"value": "false",
+				},
+				{
+					"label": "Requests (CPU / Memory)",
+					"value": "unknown",
+				},
+				{
+					"label": "Limits (CPU / Memory)",
+					"value": "unknown",
+				},
+			},
+			manager: &fake.RpaasManager{
+				FakeGetInstance: func(string) (*v1alpha1.RpaasInstance, error) {
+					return &v1alpha1.RpaasInstance{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "my-instance",
+						},
+						Spec: v1alpha1.RpaasInstanceSpec{
+							Replicas: getAddressOfInt32(4),
+						},
+					}, nil
+				},
+				FakeInstanceAddress: func(string) (string, error) {
+					return "127.0.0.1", nil
+				},
+				FakeInstanceStatus: func(string) (rpaas.PodStatusMap, error) {
+					return rpaas.PodStatusMap{
+						"pod1": {Running: true},
+						"pod2": {Running: true},
+						"pod3": {Status: "ContainerCreating"},
+						"pod4": {Running: true, Terminating: true},
+					}, nil
+				},
+				FakeGetInstancePlanResources: func(string) (*corev1.ResourceRequirements, error) {
+					return nil, rpaas.NotFoundError{Msg: "plan \"my-plan\" not found"}
+				},
+			},
+		},
+		{
+			instanceName: "my-instance",
+			expectedCode: http.StatusOK,
+			expectedInfo: []map[string]string{
+				{
+					"label": "Address",
+					"value": "127.0.0.1",
+				},
+				{
+					"label": "Instances",
+					"value": "4",
+				},
+				{
+					"label": "Routes",
+					"value": "",
+				},
+				{
+					"label": "Pending reconcile",
+					"value": "false",
+				},
+				{
+					"label": "Requests (CPU / Memory)",
+					"value": "unset / unset",
+				},
+				{
+					"label": "Limits (CPU / Memory)",
+					"value": "unset / unset",
+				},
+			},
+			manager: &fake.RpaasManager{
+				FakeGetInstance: func(string) (*v1alpha1.RpaasInstance, error) {
+					return &v1alpha1.RpaasInstance{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "my-instance",
+						},
+						Spec: v1alpha1.RpaasInstanceSpec{
+							Replicas: getAddressOfInt32(4),
+						},
+					}, nil
+				},
+				FakeInstanceAddress: func(string) (string, error) {
+					return "127.0.0.1", nil
+				},
+				FakeInstanceStatus: func(string) (rpaas.PodStatusMap, error) {
+					return nil, errors.New("some error")
+				},
 			},
 		},
 	}
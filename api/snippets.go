@@ -0,0 +1,40 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/config"
+)
+
+type snippet struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Template    string   `json:"template"`
+	Parameters  []string `json:"parameters"`
+}
+
+func getSnippets(c echo.Context) error {
+	snippets := make([]snippet, 0)
+	for _, s := range config.Get().Snippets {
+		params := s.Parameters
+		if params == nil {
+			params = []string{}
+		}
+		snippets = append(snippets, snippet{
+			Name:        s.Name,
+			Description: s.Description,
+			Template:    s.Template,
+			Parameters:  params,
+		})
+	}
+
+	sort.SliceStable(snippets, func(i, j int) bool { return snippets[i].Name < snippets[j].Name })
+
+	return c.JSON(http.StatusOK, snippets)
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_getSnippets(t *testing.T) {
+	oldConfig := config.Get()
+	defer func() {
+		config.Set(oldConfig)
+	}()
+
+	tests := []struct {
+		name         string
+		conf         config.RpaasConfig
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "when no snippets are available, should return an empty array",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+		},
+		{
+			name: "when there are many snippets, should return them sorted by name",
+			conf: config.RpaasConfig{
+				Snippets: []config.SnippetConfig{
+					{
+						Name:        "security-headers",
+						Description: "Adds common security headers",
+						Template:    "add_header X-Frame-Options DENY;",
+					},
+					{
+						Name:        "gzip",
+						Description: "Enables gzip compression",
+						Template:    "gzip on;\ngzip_comp_level {{ .level }};",
+						Parameters:  []string{"level"},
+					},
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"name":"gzip","description":"Enables gzip compression","template":"gzip on;\ngzip_comp_level {{ .level }};","parameters":["level"]},{"name":"security-headers","description":"Adds common security headers","template":"add_header X-Frame-Options DENY;","parameters":[]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Set(tt.conf)
+			srv := newTestingServer(t, &fake.RpaasManager{})
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/plans/snippets", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.JSONEq(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
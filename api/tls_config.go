@@ -0,0 +1,59 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+func getTLSConfig(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := manager.GetTLSConfig(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tlsConfig)
+}
+
+func updateTLSConfig(c echo.Context) error {
+	var tlsConfig rpaas.TLSConfig
+	if err := c.Bind(&tlsConfig); err != nil {
+		return c.String(http.StatusBadRequest, "TLS config parameters are not valid")
+	}
+
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	err = manager.UpdateTLSConfig(c.Request().Context(), c.Param("instance"), tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func deleteTLSConfig(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	err = manager.DeleteTLSConfig(c.Request().Context(), c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
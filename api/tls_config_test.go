@@ -0,0 +1,130 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_getTLSConfig(t *testing.T) {
+	manager := &fake.RpaasManager{
+		FakeGetTLSConfig: func(instance string) (*rpaas.TLSConfig, error) {
+			assert.Equal(t, "my-instance", instance)
+			return &rpaas.TLSConfig{ECDHCurves: []string{"X25519", "secp384r1"}}, nil
+		},
+	}
+	srv := newTestingServer(t, manager)
+	defer srv.Close()
+	path := fmt.Sprintf("%s/resources/my-instance/tls-config", srv.URL)
+	request, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	rsp, err := srv.Client().Do(request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.JSONEq(t, `{"ecdh_curves":["X25519","secp384r1"]}`, bodyContent(rsp))
+}
+
+func Test_updateTLSConfig(t *testing.T) {
+	dhParam := "-----BEGIN DH PARAMETERS-----\nMIIBCAKCAQEA\n-----END DH PARAMETERS-----\n"
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when the manager returns a validation error",
+			requestBody:  url.Values{"ecdh_curves": []string{"brainpoolP256r1"}}.Encode(),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `unknown ECDH curve \"brainpoolP256r1\"`,
+			manager: &fake.RpaasManager{
+				FakeUpdateTLSConfig: func(instance string, tlsConfig rpaas.TLSConfig) error {
+					return &rpaas.ValidationError{Msg: `unknown ECDH curve "brainpoolP256r1"`}
+				},
+			},
+		},
+		{
+			name:         "when successfully updating the TLS config",
+			requestBody:  url.Values{"dhparam": []string{dhParam}, "ecdh_curves": []string{"X25519", "secp384r1"}}.Encode(),
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateTLSConfig: func(instance string, tlsConfig rpaas.TLSConfig) error {
+					assert.Equal(t, "my-instance", instance)
+					assert.Equal(t, rpaas.TLSConfig{DHParam: dhParam, ECDHCurves: []string{"X25519", "secp384r1"}}, tlsConfig)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/tls-config", srv.URL)
+			request, err := http.NewRequest(http.MethodPut, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func Test_deleteTLSConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when TLS config is not set",
+			expectedCode: http.StatusNotFound,
+			manager: &fake.RpaasManager{
+				FakeDeleteTLSConfig: func(instance string) error {
+					return &rpaas.NotFoundError{Msg: "TLS config not found"}
+				},
+			},
+		},
+		{
+			name:         "when successfully removing the TLS config",
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeDeleteTLSConfig: func(instance string) error {
+					assert.Equal(t, "my-instance", instance)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/tls-config", srv.URL)
+			request, err := http.NewRequest(http.MethodDelete, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+		})
+	}
+}
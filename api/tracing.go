@@ -0,0 +1,62 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+
+	"contrib.go.opencensus.io/exporter/ocagent"
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/config"
+	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
+	"go.opencensus.io/trace"
+)
+
+// SetupTracing registers the exporter which sends the trace spans to the
+// configured agent. When no agent is configured, spans are never sampled so
+// tracing has no overhead. The returned function flushes the exporter.
+func SetupTracing() (func(), error) {
+	conf := config.Get()
+	if conf.TracingAgentAddress == "" {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return func() {}, nil
+	}
+
+	exporter, err := ocagent.NewExporter(
+		ocagent.WithInsecure(),
+		ocagent.WithAddress(conf.TracingAgentAddress),
+		ocagent.WithServiceName(conf.ServiceName),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	trace.RegisterExporter(exporter)
+	return func() { exporter.Stop() }, nil
+}
+
+func tracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	format := &tracecontext.HTTPFormat{}
+	return func(c echo.Context) error {
+		req := c.Request()
+		name := fmt.Sprintf("rpaas.api %s %s", req.Method, c.Path())
+
+		var span *trace.Span
+		ctx := req.Context()
+		if parent, ok := format.SpanContextFromRequest(req); ok {
+			ctx, span = trace.StartSpanWithRemoteParent(ctx, name, parent, trace.WithSpanKind(trace.SpanKindServer))
+		} else {
+			ctx, span = trace.StartSpan(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+		}
+		defer span.End()
+
+		c.SetRequest(req.WithContext(ctx))
+		err := next(c)
+		if err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+		}
+		return err
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/config"
+	"go.opencensus.io/trace"
+)
+
+func Test_SetupTracing(t *testing.T) {
+	config.Set(config.RpaasConfig{})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+	stop, err := SetupTracing()
+	require.NoError(t, err)
+	require.NotNil(t, stop)
+	stop()
+
+	_, span := trace.StartSpan(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "rpaas.Test")
+	defer span.End()
+	assert.False(t, span.SpanContext().IsSampled())
+}
+
+func Test_tracingMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		assertion   func(t *testing.T, span *trace.Span)
+	}{
+		{
+			name: "without incoming trace context",
+			assertion: func(t *testing.T, span *trace.Span) {
+				require.NotNil(t, span)
+				assert.NotEqual(t, trace.TraceID{}, span.SpanContext().TraceID)
+			},
+		},
+		{
+			name:        "with incoming trace context",
+			traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			assertion: func(t *testing.T, span *trace.Span) {
+				require.NotNil(t, span)
+				assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID.String())
+				assert.True(t, span.SpanContext().IsSampled())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var span *trace.Span
+			e := echo.New()
+			e.Use(tracingMiddleware)
+			e.GET("/resources/:instance", func(c echo.Context) error {
+				span = trace.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/resources/my-instance", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rsp := httptest.NewRecorder()
+			e.ServeHTTP(rsp, req)
+			assert.Equal(t, http.StatusOK, rsp.Code)
+			tt.assertion(t, span)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+)
+
+func validateInstanceConfig(c echo.Context) error {
+	manager, err := getManager(c)
+	if err != nil {
+		return err
+	}
+
+	var desired rpaas.InstanceConfig
+	if err = c.Bind(&desired); err != nil {
+		return err
+	}
+
+	errs, err := manager.ValidateInstanceConfig(c.Request().Context(), c.Param("instance"), desired)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return c.JSON(http.StatusBadRequest, errs)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
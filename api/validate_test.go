@@ -0,0 +1,95 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+)
+
+func Test_validateInstanceConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		instance     string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when manager is not set",
+			instance:     "my-instance",
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "when instance is not found",
+			instance:     "not-found",
+			requestBody:  `{}`,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "instance not found",
+			manager: &fake.RpaasManager{
+				FakeValidateInstanceConfig: func(instanceName string, desired rpaas.InstanceConfig) ([]rpaas.ValidationError, error) {
+					return nil, rpaas.NotFoundError{Msg: "instance not found"}
+				},
+			},
+		},
+		{
+			name:         "when desired config is valid",
+			instance:     "my-instance",
+			requestBody:  `{"blocks": [{"block_name": "http", "content": "# some content"}], "routes": [{"path": "/app", "destination": "app.tsuru.example.com"}], "tags": ["flavor=strawberry"]}`,
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeValidateInstanceConfig: func(instanceName string, desired rpaas.InstanceConfig) ([]rpaas.ValidationError, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, rpaas.InstanceConfig{
+						Blocks: []rpaas.ConfigurationBlock{{Name: "http", Content: "# some content"}},
+						Routes: []rpaas.Route{{Path: "/app", Destination: "app.tsuru.example.com"}},
+						Tags:   []string{"flavor=strawberry"},
+					}, desired)
+					return []rpaas.ValidationError{}, nil
+				},
+			},
+		},
+		{
+			name:         "when desired config has problems",
+			instance:     "my-instance",
+			requestBody:  `{"routes": [{"path": "invalid"}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `[{"Msg":"routes[0]: invalid path format"},{"Msg":"tags: some error"}]`,
+			manager: &fake.RpaasManager{
+				FakeValidateInstanceConfig: func(instanceName string, desired rpaas.InstanceConfig) ([]rpaas.ValidationError, error) {
+					return []rpaas.ValidationError{
+						{Msg: "routes[0]: invalid path format"},
+						{Msg: "tags: some error"},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/%s/validate", srv.URL, tt.instance)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Contains(t, bodyContent(rsp), tt.expectedBody)
+			}
+		})
+	}
+}
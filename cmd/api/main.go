@@ -23,6 +23,11 @@ func startup() error {
 	if err != nil {
 		return err
 	}
+	stopTracing, err := api.SetupTracing()
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
 	manager, err := apis.NewManager()
 	if err != nil {
 		return err
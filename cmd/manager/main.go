@@ -30,6 +30,8 @@ import (
 	rpaasOperatorVersion "github.com/tsuru/rpaas-operator/version"
 	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	v1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -128,6 +130,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Register scheme for Ingress
+	if err = extensionsv1beta1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	// Register scheme for PDB
+	if err = policyv1beta1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	// Setup all Controllers
 	if err = controller.AddToManager(mgr); err != nil {
 		log.Error(err, "")
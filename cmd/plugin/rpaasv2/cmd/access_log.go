@@ -0,0 +1,102 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type accessLogArgs struct {
+	service           string
+	instance          string
+	sampleRate        int
+	excludeSuccessful bool
+	prox              *proxy.Proxy
+}
+
+var accessLogCmd = &cobra.Command{
+	Use:   "access-log",
+	Short: "Configures the access log sampling and filtering of the instance",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccessLog(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runAccessLog(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	sampleRate, err := cmd.Flags().GetInt("sample-rate")
+	if err != nil {
+		return err
+	}
+	excludeSuccessful, err := cmd.Flags().GetBool("exclude-successful")
+	if err != nil {
+		return err
+	}
+	accessLog := accessLogArgs{
+		service:           serviceName,
+		instance:          instanceName,
+		sampleRate:        sampleRate,
+		excludeSuccessful: excludeSuccessful,
+		prox:              proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareAccessLog(accessLog)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareAccessLog(accessLog accessLogArgs) (string, error) {
+	accessLog.prox.Path = "/resources/" + accessLog.instance + "/access-log"
+	accessLog.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{
+		"sample_rate":        []string{strconv.Itoa(accessLog.sampleRate)},
+		"exclude_successful": []string{strconv.FormatBool(accessLog.excludeSuccessful)},
+	}
+	accessLog.prox.Body = strings.NewReader(body.Encode())
+
+	return postAccessLog(accessLog.prox)
+}
+
+func postAccessLog(prox *proxy.Proxy) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	return "Access log successfully updated\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(accessLogCmd)
+
+	accessLogCmd.Flags().Int("sample-rate", 1, "Log only 1 in N requests")
+	accessLogCmd.Flags().Bool("exclude-successful", false, "Whether requests answered with a 2xx status should not be logged")
+	accessLogCmd.Flags().StringP("service", "s", "", "Service name")
+	accessLogCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	accessLogCmd.MarkFlagRequired("service")
+	accessLogCmd.MarkFlagRequired("instance")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+	"gotest.tools/assert"
+)
+
+func TestPostAccessLog(t *testing.T) {
+	testCase := struct {
+		name      string
+		args      accessLogArgs
+		handler   http.HandlerFunc
+		assertion func(t *testing.T, err error, output []byte)
+	}{
+		name: "when sampling and filtering the access log",
+		args: accessLogArgs{service: "fake-service", instance: "fake-instance",
+			prox: proxy.New("fake-service", "fake-instance", "POST", nil)},
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Method, "POST")
+			assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/access-log", r.URL.RequestURI())
+			assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+			assert.NilError(t, r.ParseForm())
+			assert.Equal(t, "10", r.PostForm.Get("sample_rate"))
+			assert.Equal(t, "true", r.PostForm.Get("exclude_successful"))
+			w.WriteHeader(http.StatusOK)
+		},
+		assertion: func(t *testing.T, err error, output []byte) {
+			assert.NilError(t, err)
+			assert.Equal(t, "Access log successfully updated\n", string(output))
+		},
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		ts := httptest.NewServer(testCase.handler)
+		testCase.args.prox.Server = &mockServer{ts: ts}
+		defer ts.Close()
+		saveStdout := os.Stdout
+		r, w, err := os.Pipe()
+		assert.NilError(t, err)
+		os.Stdout = w
+		err = runAccessLog(accessLogCmd, []string{"-s", "fake-service", "-i", "fake-instance", "--sample-rate=10", "--exclude-successful"}, testCase.args.prox.Server)
+		w.Close()
+		assert.NilError(t, err)
+		output, err := ioutil.ReadAll(r)
+		os.Stdout = saveStdout
+		testCase.assertion(t, err, output)
+	})
+}
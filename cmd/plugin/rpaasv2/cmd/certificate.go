@@ -24,9 +24,10 @@ func init() {
 	certificateCmd.Flags().StringP("certificate", "c", "", "Certificate file name")
 	certificateCmd.Flags().StringP("key", "k", "", "Key file name")
 	certificateCmd.Flags().StringP("name", "", "default", "Names the provided certificate-key file")
+	certificateCmd.Flags().StringP("pfx", "", "", "PKCS#12 bundle file name, instead of certificate and key files")
+	certificateCmd.Flags().StringP("passphrase", "", "", "Passphrase of the PKCS#12 bundle")
+	certificateCmd.Flags().Bool("derive-name", false, "Names the certificate after its primary DNS name, instead of using --name")
 	certificateCmd.MarkFlagRequired("service")
-	certificateCmd.MarkFlagRequired("certificate")
-	certificateCmd.MarkFlagRequired("key")
 	certificateCmd.MarkFlagRequired("instance")
 }
 
@@ -36,6 +37,9 @@ type certificateArgs struct {
 	certificate string
 	key         string
 	name        string
+	pfx         string
+	passphrase  string
+	deriveName  bool
 	prox        *proxy.Proxy
 }
 
@@ -43,6 +47,7 @@ var certificateCmd = &cobra.Command{
 	Use:   "certificate",
 	Short: "Sends certificate + private key to existing instance",
 	Long: `Given a certificate and private key located in the filesystem, send them both to the existing instance.
+Alternatively, a PKCS#12 bundle (.pfx/.p12) holding both can be sent with --pfx.
 The rpaas instance can now be accessed via HTTPS`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.ParseFlags(args)
@@ -51,6 +56,24 @@ The rpaas instance can now be accessed via HTTPS`,
 		certificate := cmd.Flag("certificate").Value.String()
 		key := cmd.Flag("key").Value.String()
 		name := cmd.Flag("name").Value.String()
+		pfx := cmd.Flag("pfx").Value.String()
+		passphrase := cmd.Flag("passphrase").Value.String()
+		if pfx == "" && (certificate == "" || key == "") {
+			return fmt.Errorf("either both certificate and key or pfx must be provided")
+		}
+		if pfx != "" && (certificate != "" || key != "") {
+			return fmt.Errorf("pfx cannot be used along with certificate and key")
+		}
+		deriveName, err := cmd.Flags().GetBool("derive-name")
+		if err != nil {
+			return err
+		}
+		if deriveName {
+			if cmd.Flags().Changed("name") {
+				return fmt.Errorf("name cannot be used along with derive-name")
+			}
+			name = ""
+		}
 
 		certInst := certificateArgs{
 			service:     service,
@@ -58,6 +81,9 @@ The rpaas instance can now be accessed via HTTPS`,
 			certificate: certificate,
 			key:         key,
 			name:        name,
+			pfx:         pfx,
+			passphrase:  passphrase,
+			deriveName:  deriveName,
 			prox:        proxy.New(service, instance, "POST", &proxy.TsuruServer{}),
 		}
 
@@ -99,6 +125,40 @@ func encodeBody(certInst certificateArgs) (string, string, error) {
 	}
 
 	writer.WriteField("name", certInst.name)
+	if certInst.deriveName {
+		writer.WriteField("derive_name", "true")
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", "", fmt.Errorf("Error while closing file: %v", err)
+	}
+
+	return body.String(), writer.Boundary(), nil
+}
+
+func encodePKCS12Body(certInst certificateArgs) (string, string, error) {
+	bundleBytes, err := ioutil.ReadFile(certInst.pfx)
+	if err != nil {
+		return "", "", fmt.Errorf("Error while trying to read PKCS#12 file: %v", err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	bundlePart, err := writer.CreateFormFile("bundle", certInst.pfx)
+	if err != nil {
+		return "", "", fmt.Errorf("Error while trying to create PKCS#12 form file: %v", err)
+	}
+	_, err = bundlePart.Write(bundleBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("Error while trying to write the PKCS#12 bundle to the file: %v", err)
+	}
+
+	writer.WriteField("passphrase", certInst.passphrase)
+	writer.WriteField("name", certInst.name)
+	if certInst.deriveName {
+		writer.WriteField("derive_name", "true")
+	}
 	err = writer.Close()
 	if err != nil {
 		return "", "", fmt.Errorf("Error while closing file: %v", err)
@@ -109,32 +169,43 @@ func encodeBody(certInst certificateArgs) (string, string, error) {
 
 func runCert(certInst certificateArgs) error {
 	certInst.prox.Path = "/resources/" + certInst.instance + "/certificate"
-	body, boundary, err := encodeBody(certInst)
+	encode := encodeBody
+	if certInst.pfx != "" {
+		certInst.prox.Path += "/pkcs12"
+		encode = encodePKCS12Body
+	}
+	body, boundary, err := encode(certInst)
 	if err != nil {
 		return err
 	}
-	if err := postCertificate(certInst.prox, body, boundary); err != nil {
+	output, err := postCertificate(certInst.prox, body, boundary)
+	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Certificate successfully updated\n")
+	if output != "" {
+		fmt.Println(output)
+	}
 	return nil
 }
 
-func postCertificate(prox *proxy.Proxy, body, boundary string) error {
+// postCertificate sends the certificate, returning the messages of the
+// response, e.g. the derived certificate name and host mismatch warnings.
+func postCertificate(prox *proxy.Proxy, body, boundary string) (string, error) {
 	prox.Body = strings.NewReader(body)
 	prox.Headers["Content-Type"] = "multipart/form-data; boundary=" + boundary
 	resp, err := prox.ProxyRequest()
 	if err != nil {
-		return err
+		return "", err
 	}
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if resp.StatusCode != http.StatusOK {
 		bodyString := string(respBody)
-		return fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
 	}
-	return nil
+	return strings.TrimSpace(string(respBody)), nil
 }
@@ -106,13 +106,77 @@ EKTcWGekdmdDPsHloRNtsiCa697B2O9IFA==
 -----END EC PRIVATE KEY-----`)
 		body, boundary, err := encodeBody(testCase.cert)
 		assert.NilError(t, err)
-		err = postCertificate(testCase.cert.prox, body, boundary)
+		_, err = postCertificate(testCase.cert.prox, body, boundary)
 		assert.NilError(t, err)
 		err = removeTmpFolder()
 		assert.NilError(t, err)
 	})
 }
 
+func TestPostPKCS12Certificate(t *testing.T) {
+	f, err := ioutil.TempFile("", "bundle*.pfx")
+	assert.NilError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte("some pkcs12 bundle"))
+	assert.NilError(t, err)
+	f.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, "POST")
+		assert.Equal(t, "/services/test-service/proxy/test-instance?callback=/resources/test-instance/certificate/pkcs12", r.URL.RequestURI())
+		bundle, _, err := r.FormFile("bundle")
+		assert.NilError(t, err)
+		bundleBytes, err := ioutil.ReadAll(bundle)
+		assert.NilError(t, err)
+		assert.Equal(t, "some pkcs12 bundle", string(bundleBytes))
+		assert.Equal(t, "secret", r.FormValue("passphrase"))
+		assert.Equal(t, "mycert", r.FormValue("name"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	certInst := certificateArgs{
+		service:    "test-service",
+		instance:   "test-instance",
+		name:       "mycert",
+		pfx:        f.Name(),
+		passphrase: "secret",
+		prox:       proxy.New("test-service", "test-instance", "POST", &mockServer{ts: ts}),
+	}
+	err = runCert(certInst)
+	assert.NilError(t, err)
+}
+
+func TestPostCertificateDerivingName(t *testing.T) {
+	f, err := ioutil.TempFile("", "bundle*.pfx")
+	assert.NilError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte("some pkcs12 bundle"))
+	assert.NilError(t, err)
+	f.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.FormValue("name"))
+		assert.Equal(t, "true", r.FormValue("derive_name"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`certificate stored as "www-example-com"`))
+	}))
+	defer ts.Close()
+
+	certInst := certificateArgs{
+		service:    "test-service",
+		instance:   "test-instance",
+		pfx:        f.Name(),
+		deriveName: true,
+		prox:       proxy.New("test-service", "test-instance", "POST", &mockServer{ts: ts}),
+	}
+	body, boundary, err := encodePKCS12Body(certInst)
+	assert.NilError(t, err)
+	output, err := postCertificate(certInst.prox, body, boundary)
+	assert.NilError(t, err)
+	assert.Equal(t, `certificate stored as "www-example-com"`, output)
+}
+
 func createCert(cert string) error {
 	if _, err := os.Stat("../tmp"); err != nil {
 		if os.IsNotExist(err) {
@@ -0,0 +1,164 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type certificatesArgs struct {
+	service   string
+	instance  string
+	directory string
+	prox      *proxy.Proxy
+}
+
+type namedCertificate struct {
+	Name        string `json:"name"`
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+}
+
+type certificateResult struct {
+	Name     string   `json:"name"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+var certificatesCmd = &cobra.Command{
+	Use:   "certificates",
+	Short: "Sends all the certificate + private key pairs of a directory to existing instance",
+	Long: `Given a directory holding certificate and private key pairs, named "<name>.crt" and
+"<name>.key", send them all at once to the existing instance, each one under its file name.
+Invalid certificates are reported without preventing the others from being updated.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCertificates(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runCertificates(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	directory := cmd.Flag("directory").Value.String()
+	certificates := certificatesArgs{
+		service:   serviceName,
+		instance:  instanceName,
+		directory: directory,
+		prox:      proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareCertificates(certificates)
+	if output != "" {
+		fmt.Fprint(os.Stdout, output)
+	}
+	return err
+}
+
+// readCertificates loads the "<name>.crt" and "<name>.key" pairs of the
+// directory, sorted by name. A certificate without its key is an error.
+func readCertificates(directory string) ([]namedCertificate, error) {
+	certFiles, err := filepath.Glob(filepath.Join(directory, "*.crt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(certFiles)
+
+	var certs []namedCertificate
+	for _, certFile := range certFiles {
+		name := strings.TrimSuffix(filepath.Base(certFile), ".crt")
+		certBytes, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error while trying to read certificate file: %v", err)
+		}
+		keyBytes, err := ioutil.ReadFile(filepath.Join(directory, name+".key"))
+		if err != nil {
+			return nil, fmt.Errorf("Error while trying to read key file of certificate %q: %v", name, err)
+		}
+		certs = append(certs, namedCertificate{Name: name, Certificate: string(certBytes), Key: string(keyBytes)})
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate files (*.crt) found in %s", directory)
+	}
+	return certs, nil
+}
+
+func prepareCertificates(certificates certificatesArgs) (string, error) {
+	certs, err := readCertificates(certificates.directory)
+	if err != nil {
+		return "", err
+	}
+
+	certificates.prox.Path = "/resources/" + certificates.instance + "/certificates"
+	certificates.prox.Headers["Content-Type"] = "application/json"
+	bodyReq, err := json.Marshal(map[string][]namedCertificate{"certificates": certs})
+	if err != nil {
+		return "", err
+	}
+	certificates.prox.Body = bytes.NewBuffer(bodyReq)
+
+	return postCertificates(certificates.prox)
+}
+
+func postCertificates(prox *proxy.Proxy) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+
+	var results []certificateResult
+	if err = json.Unmarshal(respBody, &results); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			fmt.Fprintf(&output, "Certificate %q failed: %s\n", result.Name, result.Error)
+			continue
+		}
+		fmt.Fprintf(&output, "Certificate %q successfully updated\n", result.Name)
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(&output, "  Warning: %s\n", warning)
+		}
+	}
+	if failed > 0 {
+		return output.String(), fmt.Errorf("%d of %d certificates could not be updated", failed, len(results))
+	}
+	return output.String(), nil
+}
+
+func init() {
+	rootCmd.AddCommand(certificatesCmd)
+
+	certificatesCmd.Flags().StringP("service", "s", "", "Service name")
+	certificatesCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	certificatesCmd.Flags().StringP("directory", "d", "", "Directory holding the <name>.crt and <name>.key files")
+	certificatesCmd.MarkFlagRequired("service")
+	certificatesCmd.MarkFlagRequired("instance")
+	certificatesCmd.MarkFlagRequired("directory")
+}
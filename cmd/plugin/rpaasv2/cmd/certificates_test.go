@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+	"gotest.tools/assert"
+)
+
+func TestReadCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certificates")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = readCertificates(dir)
+	assert.ErrorContains(t, err, "no certificate files (*.crt) found in "+dir)
+
+	files := map[string]string{
+		"example.com.crt": "example cert",
+		"example.com.key": "example key",
+		"default.crt":     "default cert",
+		"default.key":     "default key",
+		"README":          "ignored",
+	}
+	for name, content := range files {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	certs, err := readCertificates(dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []namedCertificate{
+		{Name: "default", Certificate: "default cert", Key: "default key"},
+		{Name: "example.com", Certificate: "example cert", Key: "example key"},
+	}, certs)
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "orphan.crt"), []byte("orphan cert"), 0600))
+	_, err = readCertificates(dir)
+	assert.ErrorContains(t, err, `Error while trying to read key file of certificate "orphan"`)
+}
+
+func TestPostCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certificates")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "default.crt"), []byte("default cert"), 0600))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "default.key"), []byte("default key"), 0600))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "broken.crt"), []byte("broken cert"), 0600))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "broken.key"), []byte("broken key"), 0600))
+
+	testCase := struct {
+		name      string
+		args      certificatesArgs
+		handler   http.HandlerFunc
+		assertion func(t *testing.T, err error, output []byte)
+	}{
+		name: "when a certificate of the batch fails",
+		args: certificatesArgs{service: "fake-service", instance: "fake-instance",
+			prox: proxy.New("fake-service", "fake-instance", "POST", nil)},
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Method, "POST")
+			assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/certificates", r.URL.RequestURI())
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			var body map[string][]namedCertificate
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.DeepEqual(t, []namedCertificate{
+				{Name: "broken", Certificate: "broken cert", Key: "broken key"},
+				{Name: "default", Certificate: "default cert", Key: "default key"},
+			}, body["certificates"])
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"name":"broken","error":"certificate file is not a valid PEM"},{"name":"default","warnings":["certificate \"default\" does not cover the instance host \"app.example.com\""]}]`))
+		},
+		assertion: func(t *testing.T, err error, output []byte) {
+			assert.Error(t, err, "1 of 2 certificates could not be updated")
+			assert.Equal(t, `Certificate "broken" failed: certificate file is not a valid PEM
+Certificate "default" successfully updated
+  Warning: certificate "default" does not cover the instance host "app.example.com"
+`, string(output))
+		},
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		ts := httptest.NewServer(testCase.handler)
+		testCase.args.prox.Server = &mockServer{ts: ts}
+		defer ts.Close()
+		saveStdout := os.Stdout
+		r, w, err := os.Pipe()
+		assert.NilError(t, err)
+		os.Stdout = w
+		runErr := runCertificates(certificatesCmd, []string{"-s", "fake-service", "-i", "fake-instance", "-d", dir}, testCase.args.prox.Server)
+		w.Close()
+		output, err := ioutil.ReadAll(r)
+		assert.NilError(t, err)
+		os.Stdout = saveStdout
+		testCase.assertion(t, runErr, output)
+	})
+}
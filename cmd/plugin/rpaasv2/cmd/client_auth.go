@@ -0,0 +1,117 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type clientAuthArgs struct {
+	service  string
+	instance string
+	caFile   string
+	verify   string
+	remove   bool
+	prox     *proxy.Proxy
+}
+
+var clientAuthCmd = &cobra.Command{
+	Use:   "client-auth",
+	Short: "Sets or removes the client certificate authentication of an instance",
+	Long:  `Requires the clients connecting over HTTPS to present a certificate issued by one of the CAs in the given PEM bundle (mTLS). The instance must have a default certificate.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runClientAuth(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runClientAuth(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	remove, err := cmd.Flags().GetBool("remove")
+	if err != nil {
+		return err
+	}
+	caFile := cmd.Flag("ca-file").Value.String()
+	if !remove && caFile == "" {
+		return fmt.Errorf("either ca-file or remove must be provided")
+	}
+	method := "PUT"
+	if remove {
+		method = "DELETE"
+	}
+	clientAuth := clientAuthArgs{
+		service:  serviceName,
+		instance: instanceName,
+		caFile:   caFile,
+		verify:   cmd.Flag("verify").Value.String(),
+		remove:   remove,
+		prox:     proxy.New(serviceName, instanceName, method, sv),
+	}
+
+	output, err := prepareClientAuth(clientAuth)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareClientAuth(clientAuth clientAuthArgs) (string, error) {
+	clientAuth.prox.Path = "/resources/" + clientAuth.instance + "/client-auth"
+	if !clientAuth.remove {
+		bundle, err := ioutil.ReadFile(clientAuth.caFile)
+		if err != nil {
+			return "", err
+		}
+		body := url.Values{
+			"ca_bundle": []string{string(bundle)},
+			"verify":    []string{clientAuth.verify},
+		}
+		clientAuth.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+		clientAuth.prox.Body = strings.NewReader(body.Encode())
+	}
+
+	return postClientAuth(clientAuth.prox, clientAuth.remove)
+}
+
+func postClientAuth(prox *proxy.Proxy, remove bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if remove {
+		return "Client authentication successfully removed\n", nil
+	}
+	return "Client authentication successfully updated\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(clientAuthCmd)
+
+	clientAuthCmd.Flags().String("ca-file", "", "PEM file with the certificates of the trusted CAs")
+	clientAuthCmd.Flags().String("verify", "required", "Whether a client certificate is required or optional")
+	clientAuthCmd.Flags().Bool("remove", false, "Removes the client certificate authentication")
+	clientAuthCmd.Flags().StringP("service", "s", "", "Service name")
+	clientAuthCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	clientAuthCmd.MarkFlagRequired("service")
+	clientAuthCmd.MarkFlagRequired("instance")
+}
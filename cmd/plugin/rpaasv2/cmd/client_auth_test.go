@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPostClientAuth(t *testing.T) {
+	caFile, err := ioutil.TempFile("", "ca-*.crt")
+	assert.NilError(t, err)
+	defer os.Remove(caFile.Name())
+	_, err = caFile.WriteString("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+	assert.NilError(t, err)
+	assert.NilError(t, caFile.Close())
+
+	testCases := []struct {
+		name      string
+		args      []string
+		handler   http.HandlerFunc
+		assertion func(t *testing.T, err error, output []byte)
+	}{
+		{
+			name: "when setting the trusted CAs",
+			args: []string{"-s", "fake-service", "-i", "fake-instance", "--ca-file", caFile.Name(), "--verify", "optional"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "PUT")
+				assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/client-auth", r.URL.RequestURI())
+				assert.NilError(t, r.ParseForm())
+				assert.Equal(t, "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n", r.PostForm.Get("ca_bundle"))
+				assert.Equal(t, "optional", r.PostForm.Get("verify"))
+				w.WriteHeader(http.StatusCreated)
+			},
+			assertion: func(t *testing.T, err error, output []byte) {
+				assert.NilError(t, err)
+				assert.Equal(t, "Client authentication successfully updated\n", string(output))
+			},
+		},
+		{
+			name: "when removing the client authentication",
+			args: []string{"-s", "fake-service", "-i", "fake-instance", "--remove"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "DELETE")
+				assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/client-auth", r.URL.RequestURI())
+				w.WriteHeader(http.StatusOK)
+			},
+			assertion: func(t *testing.T, err error, output []byte) {
+				assert.NilError(t, err)
+				assert.Equal(t, "Client authentication successfully removed\n", string(output))
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ts := httptest.NewServer(testCase.handler)
+			defer ts.Close()
+			saveStdout := os.Stdout
+			r, w, err := os.Pipe()
+			assert.NilError(t, err)
+			os.Stdout = w
+			err = runClientAuth(clientAuthCmd, testCase.args, &mockServer{ts: ts})
+			w.Close()
+			assert.NilError(t, err)
+			output, err := ioutil.ReadAll(r)
+			os.Stdout = saveStdout
+			testCase.assertion(t, err, output)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type compressionArgs struct {
+	service   string
+	instance  string
+	enabled   bool
+	minLength int
+	mimeTypes []string
+	brotli    bool
+	prox      *proxy.Proxy
+}
+
+var compressionCmd = &cobra.Command{
+	Use:   "compression",
+	Short: "Configures the response compression (gzip and brotli) of the instance",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompression(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runCompression(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	enabled, err := cmd.Flags().GetBool("enabled")
+	if err != nil {
+		return err
+	}
+	minLength, err := cmd.Flags().GetInt("min-length")
+	if err != nil {
+		return err
+	}
+	mimeTypes, err := cmd.Flags().GetStringSlice("mime-types")
+	if err != nil {
+		return err
+	}
+	brotli, err := cmd.Flags().GetBool("brotli")
+	if err != nil {
+		return err
+	}
+	compression := compressionArgs{
+		service:   serviceName,
+		instance:  instanceName,
+		enabled:   enabled,
+		minLength: minLength,
+		mimeTypes: mimeTypes,
+		brotli:    brotli,
+		prox:      proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareCompression(compression)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareCompression(compression compressionArgs) (string, error) {
+	compression.prox.Path = "/resources/" + compression.instance + "/compression"
+	compression.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{
+		"enabled":    []string{strconv.FormatBool(compression.enabled)},
+		"min_length": []string{strconv.Itoa(compression.minLength)},
+		"mime_types": compression.mimeTypes,
+		"brotli":     []string{strconv.FormatBool(compression.brotli)},
+	}
+	compression.prox.Body = strings.NewReader(body.Encode())
+
+	return postCompression(compression.prox, compression.enabled)
+}
+
+func postCompression(prox *proxy.Proxy, enabled bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if enabled {
+		return "Compression successfully enabled\n", nil
+	}
+	return "Compression successfully disabled\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(compressionCmd)
+
+	compressionCmd.Flags().Bool("enabled", true, "Whether the responses should be compressed")
+	compressionCmd.Flags().Int("min-length", 20, "Minimum response length (in bytes) to be compressed")
+	compressionCmd.Flags().StringSlice("mime-types", []string{}, "Response MIME types to be compressed (e.g. text/css,application/json)")
+	compressionCmd.Flags().Bool("brotli", false, "Whether the brotli compression should be enabled along with gzip")
+	compressionCmd.Flags().StringP("service", "s", "", "Service name")
+	compressionCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	compressionCmd.MarkFlagRequired("service")
+	compressionCmd.MarkFlagRequired("instance")
+}
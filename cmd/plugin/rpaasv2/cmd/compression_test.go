@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+	"gotest.tools/assert"
+)
+
+func TestPostCompression(t *testing.T) {
+	testCase := struct {
+		name      string
+		args      compressionArgs
+		handler   http.HandlerFunc
+		assertion func(t *testing.T, err error, output []byte)
+	}{
+		name: "when enabling compression with brotli",
+		args: compressionArgs{service: "fake-service", instance: "fake-instance",
+			prox: proxy.New("fake-service", "fake-instance", "POST", nil)},
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Method, "POST")
+			assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/compression", r.URL.RequestURI())
+			assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+			assert.NilError(t, r.ParseForm())
+			assert.Equal(t, "true", r.PostForm.Get("enabled"))
+			assert.Equal(t, "1024", r.PostForm.Get("min_length"))
+			assert.DeepEqual(t, []string{"text/css", "application/json"}, r.PostForm["mime_types"])
+			assert.Equal(t, "true", r.PostForm.Get("brotli"))
+			w.WriteHeader(http.StatusOK)
+		},
+		assertion: func(t *testing.T, err error, output []byte) {
+			assert.NilError(t, err)
+			assert.Equal(t, "Compression successfully enabled\n", string(output))
+		},
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		ts := httptest.NewServer(testCase.handler)
+		testCase.args.prox.Server = &mockServer{ts: ts}
+		defer ts.Close()
+		saveStdout := os.Stdout
+		r, w, err := os.Pipe()
+		assert.NilError(t, err)
+		os.Stdout = w
+		err = runCompression(compressionCmd, []string{"-s", "fake-service", "-i", "fake-instance", "--min-length=1024", "--mime-types=text/css,application/json", "--brotli"}, testCase.args.prox.Server)
+		w.Close()
+		assert.NilError(t, err)
+		output, err := ioutil.ReadAll(r)
+		os.Stdout = saveStdout
+		testCase.assertion(t, err, output)
+	})
+}
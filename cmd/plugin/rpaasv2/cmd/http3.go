@@ -0,0 +1,96 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type http3Args struct {
+	service  string
+	instance string
+	enabled  bool
+	prox     *proxy.Proxy
+}
+
+var http3Cmd = &cobra.Command{
+	Use:   "http3",
+	Short: "Enables or disables HTTP/3 (QUIC) on the HTTPS port",
+	Long: `Enables or disables HTTP/3 (QUIC) on the HTTPS port. It requires the default
+certificate to be set and the NGINX image to support HTTP/3.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHTTP3(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runHTTP3(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	enabled, err := cmd.Flags().GetBool("enabled")
+	if err != nil {
+		return err
+	}
+	http3 := http3Args{
+		service:  serviceName,
+		instance: instanceName,
+		enabled:  enabled,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareHTTP3(http3)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareHTTP3(http3 http3Args) (string, error) {
+	http3.prox.Path = "/resources/" + http3.instance + "/http3"
+	http3.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{"enabled": []string{strconv.FormatBool(http3.enabled)}}
+	http3.prox.Body = strings.NewReader(body.Encode())
+
+	return postHTTP3(http3.prox, http3.enabled)
+}
+
+func postHTTP3(prox *proxy.Proxy, enabled bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if enabled {
+		return "HTTP/3 successfully enabled\n", nil
+	}
+	return "HTTP/3 successfully disabled\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(http3Cmd)
+
+	http3Cmd.Flags().Bool("enabled", true, "Whether NGINX should serve HTTP/3 along with HTTPS")
+	http3Cmd.Flags().StringP("service", "s", "", "Service name")
+	http3Cmd.Flags().StringP("instance", "i", "", "Service instance name")
+	http3Cmd.MarkFlagRequired("service")
+	http3Cmd.MarkFlagRequired("instance")
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type pdbArgs struct {
+	service        string
+	instance       string
+	minAvailable   string
+	maxUnavailable string
+	remove         bool
+	prox           *proxy.Proxy
+}
+
+var pdbCmd = &cobra.Command{
+	Use:   "pdb",
+	Short: "Sets or removes the PodDisruptionBudget of an instance",
+	Long:  `Limits how many pods of the instance can be taken down at once by voluntary disruptions (e.g. node drains). Values may be integers or percentages.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPDB(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runPDB(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	remove, err := cmd.Flags().GetBool("remove")
+	if err != nil {
+		return err
+	}
+	method := "PUT"
+	if remove {
+		method = "DELETE"
+	}
+	pdb := pdbArgs{
+		service:        serviceName,
+		instance:       instanceName,
+		minAvailable:   cmd.Flag("min-available").Value.String(),
+		maxUnavailable: cmd.Flag("max-unavailable").Value.String(),
+		remove:         remove,
+		prox:           proxy.New(serviceName, instanceName, method, sv),
+	}
+
+	output, err := preparePDB(pdb)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func preparePDB(pdb pdbArgs) (string, error) {
+	pdb.prox.Path = "/resources/" + pdb.instance + "/pdb"
+	if !pdb.remove {
+		body := url.Values{}
+		if pdb.minAvailable != "" {
+			body.Set("min_available", pdb.minAvailable)
+		}
+		if pdb.maxUnavailable != "" {
+			body.Set("max_unavailable", pdb.maxUnavailable)
+		}
+		pdb.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+		pdb.prox.Body = strings.NewReader(body.Encode())
+	}
+
+	return postPDB(pdb.prox, pdb.remove)
+}
+
+func postPDB(prox *proxy.Proxy, remove bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if remove {
+		return "PodDisruptionBudget successfully removed\n", nil
+	}
+	return "PodDisruptionBudget successfully updated\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(pdbCmd)
+
+	pdbCmd.Flags().String("min-available", "", "Pods that must remain available (e.g. 1 or 50%)")
+	pdbCmd.Flags().String("max-unavailable", "", "Pods that may be unavailable (e.g. 1 or 50%)")
+	pdbCmd.Flags().Bool("remove", false, "Removes the PodDisruptionBudget")
+	pdbCmd.Flags().StringP("service", "s", "", "Service name")
+	pdbCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	pdbCmd.MarkFlagRequired("service")
+	pdbCmd.MarkFlagRequired("instance")
+}
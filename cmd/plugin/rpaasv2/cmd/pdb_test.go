@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPostPDB(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		handler   http.HandlerFunc
+		assertion func(t *testing.T, err error, output []byte)
+	}{
+		{
+			name: "when setting the max unavailable pods",
+			args: []string{"-s", "fake-service", "-i", "fake-instance", "--max-unavailable", "25%"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "PUT")
+				assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/pdb", r.URL.RequestURI())
+				assert.NilError(t, r.ParseForm())
+				assert.Equal(t, "25%", r.PostForm.Get("max_unavailable"))
+				assert.Equal(t, "", r.PostForm.Get("min_available"))
+				w.WriteHeader(http.StatusCreated)
+			},
+			assertion: func(t *testing.T, err error, output []byte) {
+				assert.NilError(t, err)
+				assert.Equal(t, "PodDisruptionBudget successfully updated\n", string(output))
+			},
+		},
+		{
+			name: "when removing the pod disruption budget",
+			args: []string{"-s", "fake-service", "-i", "fake-instance", "--remove"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "DELETE")
+				assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/pdb", r.URL.RequestURI())
+				w.WriteHeader(http.StatusOK)
+			},
+			assertion: func(t *testing.T, err error, output []byte) {
+				assert.NilError(t, err)
+				assert.Equal(t, "PodDisruptionBudget successfully removed\n", string(output))
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ts := httptest.NewServer(testCase.handler)
+			defer ts.Close()
+			saveStdout := os.Stdout
+			r, w, err := os.Pipe()
+			assert.NilError(t, err)
+			os.Stdout = w
+			err = runPDB(pdbCmd, testCase.args, &mockServer{ts: ts})
+			w.Close()
+			assert.NilError(t, err)
+			output, err := ioutil.ReadAll(r)
+			os.Stdout = saveStdout
+			testCase.assertion(t, err, output)
+		})
+	}
+}
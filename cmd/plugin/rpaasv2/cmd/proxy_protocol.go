@@ -0,0 +1,95 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type proxyProtocolArgs struct {
+	service  string
+	instance string
+	enabled  bool
+	prox     *proxy.Proxy
+}
+
+var proxyProtocolCmd = &cobra.Command{
+	Use:   "proxy-protocol",
+	Short: "Enables or disables the PROXY protocol on the instance listeners",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProxyProtocol(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runProxyProtocol(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	enabled, err := cmd.Flags().GetBool("enabled")
+	if err != nil {
+		return err
+	}
+	proxyProtocol := proxyProtocolArgs{
+		service:  serviceName,
+		instance: instanceName,
+		enabled:  enabled,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareProxyProtocol(proxyProtocol)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareProxyProtocol(proxyProtocol proxyProtocolArgs) (string, error) {
+	proxyProtocol.prox.Path = "/resources/" + proxyProtocol.instance + "/proxy-protocol"
+	proxyProtocol.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{"enabled": []string{strconv.FormatBool(proxyProtocol.enabled)}}
+	proxyProtocol.prox.Body = strings.NewReader(body.Encode())
+
+	return postProxyProtocol(proxyProtocol.prox, proxyProtocol.enabled)
+}
+
+func postProxyProtocol(prox *proxy.Proxy, enabled bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if enabled {
+		return "PROXY protocol successfully enabled\n", nil
+	}
+	return "PROXY protocol successfully disabled\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(proxyProtocolCmd)
+
+	proxyProtocolCmd.Flags().Bool("enabled", true, "Whether the PROXY protocol should be enabled")
+	proxyProtocolCmd.Flags().StringP("service", "s", "", "Service name")
+	proxyProtocolCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	proxyProtocolCmd.MarkFlagRequired("service")
+	proxyProtocolCmd.MarkFlagRequired("instance")
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type rejectUnknownHostsArgs struct {
+	service  string
+	instance string
+	enabled  bool
+	prox     *proxy.Proxy
+}
+
+var rejectUnknownHostsCmd = &cobra.Command{
+	Use:   "reject-unknown-hosts",
+	Short: "Enables or disables the rejection of requests for unknown hosts",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRejectUnknownHosts(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runRejectUnknownHosts(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	enabled, err := cmd.Flags().GetBool("enabled")
+	if err != nil {
+		return err
+	}
+	rejectUnknownHosts := rejectUnknownHostsArgs{
+		service:  serviceName,
+		instance: instanceName,
+		enabled:  enabled,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareRejectUnknownHosts(rejectUnknownHosts)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareRejectUnknownHosts(rejectUnknownHosts rejectUnknownHostsArgs) (string, error) {
+	rejectUnknownHosts.prox.Path = "/resources/" + rejectUnknownHosts.instance + "/reject-unknown-hosts"
+	rejectUnknownHosts.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{"enabled": []string{strconv.FormatBool(rejectUnknownHosts.enabled)}}
+	rejectUnknownHosts.prox.Body = strings.NewReader(body.Encode())
+
+	return postRejectUnknownHosts(rejectUnknownHosts.prox, rejectUnknownHosts.enabled)
+}
+
+func postRejectUnknownHosts(prox *proxy.Proxy, enabled bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if enabled {
+		return "Rejection of unknown hosts successfully enabled\n", nil
+	}
+	return "Rejection of unknown hosts successfully disabled\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(rejectUnknownHostsCmd)
+
+	rejectUnknownHostsCmd.Flags().Bool("enabled", true, "Whether requests for hosts other than the ingress host should be rejected")
+	rejectUnknownHostsCmd.Flags().StringP("service", "s", "", "Service name")
+	rejectUnknownHostsCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	rejectUnknownHostsCmd.MarkFlagRequired("service")
+	rejectUnknownHostsCmd.MarkFlagRequired("instance")
+}
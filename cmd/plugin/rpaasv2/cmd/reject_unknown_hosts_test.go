@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+	"gotest.tools/assert"
+)
+
+func TestPostRejectUnknownHosts(t *testing.T) {
+	testCase := struct {
+		name      string
+		args      rejectUnknownHostsArgs
+		handler   http.HandlerFunc
+		assertion func(t *testing.T, err error, output []byte)
+	}{
+		name: "when enabling the rejection of unknown hosts",
+		args: rejectUnknownHostsArgs{service: "fake-service", instance: "fake-instance",
+			prox: proxy.New("fake-service", "fake-instance", "POST", nil)},
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Method, "POST")
+			assert.Equal(t, "/services/fake-service/proxy/fake-instance?callback=/resources/fake-instance/reject-unknown-hosts", r.URL.RequestURI())
+			assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+			assert.NilError(t, r.ParseForm())
+			assert.Equal(t, "true", r.PostForm.Get("enabled"))
+			w.WriteHeader(http.StatusOK)
+		},
+		assertion: func(t *testing.T, err error, output []byte) {
+			assert.NilError(t, err)
+			assert.Equal(t, "Rejection of unknown hosts successfully enabled\n", string(output))
+		},
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		ts := httptest.NewServer(testCase.handler)
+		testCase.args.prox.Server = &mockServer{ts: ts}
+		defer ts.Close()
+		saveStdout := os.Stdout
+		r, w, err := os.Pipe()
+		assert.NilError(t, err)
+		os.Stdout = w
+		err = runRejectUnknownHosts(rejectUnknownHostsCmd, []string{"-s", "fake-service", "-i", "fake-instance", "--enabled=true"}, testCase.args.prox.Server)
+		w.Close()
+		assert.NilError(t, err)
+		output, err := ioutil.ReadAll(r)
+		os.Stdout = saveStdout
+		testCase.assertion(t, err, output)
+	})
+}
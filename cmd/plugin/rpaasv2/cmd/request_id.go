@@ -0,0 +1,95 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type requestIDArgs struct {
+	service  string
+	instance string
+	enabled  bool
+	prox     *proxy.Proxy
+}
+
+var requestIDCmd = &cobra.Command{
+	Use:   "request-id",
+	Short: "Enables or disables the propagation of the X-Request-ID header",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRequestID(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runRequestID(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	enabled, err := cmd.Flags().GetBool("enabled")
+	if err != nil {
+		return err
+	}
+	requestID := requestIDArgs{
+		service:  serviceName,
+		instance: instanceName,
+		enabled:  enabled,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareRequestID(requestID)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareRequestID(requestID requestIDArgs) (string, error) {
+	requestID.prox.Path = "/resources/" + requestID.instance + "/request-id"
+	requestID.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{"enabled": []string{strconv.FormatBool(requestID.enabled)}}
+	requestID.prox.Body = strings.NewReader(body.Encode())
+
+	return postRequestID(requestID.prox, requestID.enabled)
+}
+
+func postRequestID(prox *proxy.Proxy, enabled bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if enabled {
+		return "Request ID successfully enabled\n", nil
+	}
+	return "Request ID successfully disabled\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(requestIDCmd)
+
+	requestIDCmd.Flags().Bool("enabled", true, "Whether the X-Request-ID header should be generated when missing and sent to the upstreams")
+	requestIDCmd.Flags().StringP("service", "s", "", "Service name")
+	requestIDCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	requestIDCmd.MarkFlagRequired("service")
+	requestIDCmd.MarkFlagRequired("instance")
+}
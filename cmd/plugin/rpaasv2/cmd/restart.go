@@ -0,0 +1,78 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type restartArgs struct {
+	service  string
+	instance string
+	prox     *proxy.Proxy
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Performs a rolling restart of the instance's pods",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRestart(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runRestart(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	restart := restartArgs{
+		service:  serviceName,
+		instance: instanceName,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareRestart(restart)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareRestart(restart restartArgs) (string, error) {
+	restart.prox.Path = "/resources/" + restart.instance + "/restart"
+	return postRestart(restart.prox, restart.instance)
+}
+
+func postRestart(prox *proxy.Proxy, instance string) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	return fmt.Sprintf("Instance %s is being restarted\n", instance), nil
+}
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+
+	restartCmd.Flags().StringP("service", "s", "", "Service name")
+	restartCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	restartCmd.MarkFlagRequired("service")
+	restartCmd.MarkFlagRequired("instance")
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type routeDeleteArgs struct {
+	service  string
+	instance string
+	paths    []string
+	prox     *proxy.Proxy
+}
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Manages the routes of an instance",
+	Long:  ``,
+}
+
+var routeDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Removes one or more routes from an instance",
+	Long:  `Removes the routes matching the given paths. The --path flag may be repeated to remove several routes at once.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRouteDelete(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runRouteDelete(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	paths, err := cmd.Flags().GetStringArray("path")
+	if err != nil {
+		return err
+	}
+	routeDelete := routeDeleteArgs{
+		service:  serviceName,
+		instance: instanceName,
+		paths:    paths,
+		prox:     proxy.New(serviceName, instanceName, "DELETE", sv),
+	}
+
+	output, err := prepareRouteDelete(routeDelete)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareRouteDelete(routeDelete routeDeleteArgs) (string, error) {
+	routeDelete.prox.Path = "/resources/" + routeDelete.instance + "/route"
+	routeDelete.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{"path": routeDelete.paths}
+	routeDelete.prox.Body = strings.NewReader(body.Encode())
+
+	return deleteRoutes(routeDelete.prox, routeDelete.paths)
+}
+
+func deleteRoutes(prox *proxy.Proxy, paths []string) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	return fmt.Sprintf("Routes successfully removed: %s\n", strings.Join(paths, ", ")), nil
+}
+
+func init() {
+	rootCmd.AddCommand(routeCmd)
+	routeCmd.AddCommand(routeDeleteCmd)
+
+	routeDeleteCmd.Flags().StringP("service", "s", "", "Service name")
+	routeDeleteCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	routeDeleteCmd.Flags().StringArrayP("path", "p", nil, "Path of the route to be removed (can be repeated)")
+	routeDeleteCmd.MarkFlagRequired("service")
+	routeDeleteCmd.MarkFlagRequired("instance")
+	routeDeleteCmd.MarkFlagRequired("path")
+}
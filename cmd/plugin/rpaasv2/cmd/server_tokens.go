@@ -0,0 +1,95 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type serverTokensArgs struct {
+	service  string
+	instance string
+	hide     bool
+	prox     *proxy.Proxy
+}
+
+var serverTokensCmd = &cobra.Command{
+	Use:   "server-tokens",
+	Short: "Hides or shows the NGINX version on the \"Server\" header and error pages",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServerTokens(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runServerTokens(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	hide, err := cmd.Flags().GetBool("hide")
+	if err != nil {
+		return err
+	}
+	serverTokens := serverTokensArgs{
+		service:  serviceName,
+		instance: instanceName,
+		hide:     hide,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareServerTokens(serverTokens)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareServerTokens(serverTokens serverTokensArgs) (string, error) {
+	serverTokens.prox.Path = "/resources/" + serverTokens.instance + "/server-tokens"
+	serverTokens.prox.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	body := url.Values{"hide": []string{strconv.FormatBool(serverTokens.hide)}}
+	serverTokens.prox.Body = strings.NewReader(body.Encode())
+
+	return postServerTokens(serverTokens.prox, serverTokens.hide)
+}
+
+func postServerTokens(prox *proxy.Proxy, hide bool) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	if hide {
+		return "Server tokens successfully hidden\n", nil
+	}
+	return "Server tokens successfully shown\n", nil
+}
+
+func init() {
+	rootCmd.AddCommand(serverTokensCmd)
+
+	serverTokensCmd.Flags().Bool("hide", true, "Whether the NGINX version should be hidden")
+	serverTokensCmd.Flags().StringP("service", "s", "", "Service name")
+	serverTokensCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	serverTokensCmd.MarkFlagRequired("service")
+	serverTokensCmd.MarkFlagRequired("instance")
+}
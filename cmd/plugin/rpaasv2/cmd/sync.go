@@ -0,0 +1,79 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/proxy"
+)
+
+type syncArgs struct {
+	service  string
+	instance string
+	prox     *proxy.Proxy
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Forces the operator to reconcile the instance",
+	Long: `Forces the operator to reconcile the instance, e.g. after a resource it references
+was changed out-of-band. It doesn't wait for the reconciliation to finish.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSync(cmd, args, &proxy.TsuruServer{})
+	},
+}
+
+func runSync(cmd *cobra.Command, args []string, sv proxy.Server) error {
+	cmd.ParseFlags(args)
+	serviceName := cmd.Flag("service").Value.String()
+	instanceName := cmd.Flag("instance").Value.String()
+	sync := syncArgs{
+		service:  serviceName,
+		instance: instanceName,
+		prox:     proxy.New(serviceName, instanceName, "POST", sv),
+	}
+
+	output, err := prepareSync(sync)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func prepareSync(sync syncArgs) (string, error) {
+	sync.prox.Path = "/resources/" + sync.instance + "/sync"
+	return postSync(sync.prox, sync.instance)
+}
+
+func postSync(prox *proxy.Proxy, instance string) (string, error) {
+	resp, err := prox.ProxyRequest()
+	if err != nil {
+		return "", err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		bodyString := string(respBody)
+		return "", fmt.Errorf("Status Code: %v\nResponse Body:\n%v", resp.Status, bodyString)
+	}
+	return fmt.Sprintf("Instance %s is being synchronized\n", instance), nil
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().StringP("service", "s", "", "Service name")
+	syncCmd.Flags().StringP("instance", "i", "", "Service instance name")
+	syncCmd.MarkFlagRequired("service")
+	syncCmd.MarkFlagRequired("instance")
+}
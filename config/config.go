@@ -34,8 +34,74 @@ type RpaasConfig struct {
 	TLSKey             string                     `json:"tls-key"`
 	DefaultAffinity    *corev1.Affinity           `json:"default-affinity"`
 	TeamAffinity       map[string]corev1.Affinity `json:"team-affinity"`
+	HideServerTokens   bool                       `json:"hide-server-tokens"`
+
+	// RouteContentInlineLimit is the size (in bytes) above which route
+	// contents are stored in a ConfigMap instead of inline in the instance.
+	// Zero means they are always stored inline.
+	RouteContentInlineLimit int `json:"route-content-inline-limit"`
+
+	// RouteContentMaxInlineSize is the largest route content (in bytes)
+	// accepted inline in the instance, to keep it under the API server's
+	// object size limit. Defaults to 512KiB.
+	RouteContentMaxInlineSize int `json:"route-content-max-inline-size"`
+
+	// DefaultServiceType is the type of the Service created for new
+	// instances. Defaults to LoadBalancer.
+	DefaultServiceType corev1.ServiceType `json:"default-service-type"`
+
+	// HTTP3Enabled allows instances to serve HTTP/3 (QUIC). It must only be
+	// set when the NGINX image is built with the ngx_http_v3_module.
+	HTTP3Enabled bool `json:"http3-enabled"`
+
+	// MetricsEnabled exposes the API request metrics on /metrics.
+	MetricsEnabled bool `json:"metrics-enabled"`
+
+	// TracingAgentAddress is the address of the OpenCensus agent where the
+	// trace spans are sent to. Tracing is disabled when it's empty.
+	TracingAgentAddress string `json:"tracing-agent-address"`
+
+	// CallbackSecret is the key used to sign (HMAC-SHA256) the payloads
+	// sent to the instances' status callback URLs.
+	CallbackSecret string `json:"callback-secret"`
+
+	// AdminToken grants access to the administrative endpoints (under
+	// /admin) when sent on the X-Rpaas-Admin-Token header. They're
+	// disabled when it's empty.
+	AdminToken string `json:"admin-token"`
+
+	// SecurityPolicy sets the security minimums that neither flavors nor
+	// plan-overrides are allowed to weaken.
+	SecurityPolicy SecurityPolicyConfig `json:"security-policy"`
+
+	// DirectivePolicy restricts the NGINX directives users may write in
+	// their blocks and routes.
+	DirectivePolicy DirectivePolicyConfig `json:"directive-policy"`
 
 	Flavors []FlavorConfig
+
+	// Snippets are named block templates that users can reference instead
+	// of pasting the same configuration over and over.
+	Snippets []SnippetConfig
+}
+
+type SecurityPolicyConfig struct {
+	// MinTLSVersion is the oldest TLS version, e.g. "TLSv1.2", an instance
+	// may accept through its flavor or plan-override.
+	MinTLSVersion string `json:"min-tls-version"`
+}
+
+type DirectivePolicyConfig struct {
+	// Allow lists the only directives permitted, when not empty. A trailing
+	// "*" matches any directive with that prefix, e.g. "proxy_*".
+	Allow []string `json:"allow"`
+	// Deny lists the forbidden directives, e.g. "lua_*" or "perl". It takes
+	// precedence over Allow.
+	Deny []string `json:"deny"`
+	// LuaBlocks permits the lua-server and lua-worker blocks, whose Lua code
+	// can't be checked against the directives above. They're only rejected
+	// when Allow or Deny are set.
+	LuaBlocks bool `json:"lua-blocks"`
 }
 
 type FlavorConfig struct {
@@ -44,6 +110,16 @@ type FlavorConfig struct {
 	Spec        v1alpha1.RpaasPlanSpec
 }
 
+type SnippetConfig struct {
+	Name        string
+	Description string
+	// Template is a text/template whose data are the given parameters,
+	// e.g. "gzip_comp_level {{ .level }};".
+	Template string
+	// Parameters lists the parameters required to render the template.
+	Parameters []string
+}
+
 var rpaasConfig struct {
 	sync.RWMutex
 	conf RpaasConfig
@@ -75,9 +151,14 @@ func Init() error {
 	viper.BindEnv("service-annotations")
 	viper.BindEnv("tls-certificate")
 	viper.BindEnv("tls-key")
+	viper.BindEnv("callback-secret")
+	viper.BindEnv("admin-token")
 	viper.SetDefault("service-name", keyPrefix)
 	viper.SetDefault("tls-certificate", "")
 	viper.SetDefault("tls-key", "")
+	viper.SetDefault("hide-server-tokens", true)
+	viper.SetDefault("metrics-enabled", true)
+	viper.SetDefault("default-service-type", string(corev1.ServiceTypeLoadBalancer))
 	viper.AutomaticEnv()
 	err := readConfig()
 	if err != nil {
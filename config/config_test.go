@@ -25,7 +25,53 @@ func Test_Init(t *testing.T) {
 	}{
 		{
 			expected: RpaasConfig{
-				ServiceName: "rpaasv2",
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
+			},
+		},
+		{
+			config: `
+hide-server-tokens: false
+`,
+			expected: RpaasConfig{
+				ServiceName:        "rpaasv2",
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
+			},
+		},
+		{
+			config: `
+route-content-inline-limit: 4096
+`,
+			expected: RpaasConfig{
+				ServiceName:             "rpaasv2",
+				HideServerTokens:        true,
+				RouteContentInlineLimit: 4096,
+				MetricsEnabled:          true,
+				DefaultServiceType:      corev1.ServiceTypeLoadBalancer,
+			},
+		},
+		{
+			config: `
+default-service-type: ClusterIP
+`,
+			expected: RpaasConfig{
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeClusterIP,
+			},
+		},
+		{
+			config: `
+metrics-enabled: false
+`,
+			expected: RpaasConfig{
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
 			},
 		},
 		{
@@ -34,9 +80,47 @@ tls-certificate: /var/share/tls/mycert.pem
 tls-key: /var/share/tls/key.pem
 `,
 			expected: RpaasConfig{
-				ServiceName:    "rpaasv2",
-				TLSCertificate: "/var/share/tls/mycert.pem",
-				TLSKey:         "/var/share/tls/key.pem",
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
+				TLSCertificate:     "/var/share/tls/mycert.pem",
+				TLSKey:             "/var/share/tls/key.pem",
+			},
+		},
+		{
+			config: `
+security-policy:
+  min-tls-version: TLSv1.2
+`,
+			expected: RpaasConfig{
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
+				SecurityPolicy:     SecurityPolicyConfig{MinTLSVersion: "TLSv1.2"},
+			},
+		},
+		{
+			config: `
+directive-policy:
+  allow:
+  - proxy_*
+  - add_header
+  deny:
+  - proxy_pass
+  lua-blocks: true
+`,
+			expected: RpaasConfig{
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
+				DirectivePolicy: DirectivePolicyConfig{
+					Allow:     []string{"proxy_*", "add_header"},
+					Deny:      []string{"proxy_pass"},
+					LuaBlocks: true,
+				},
 			},
 		},
 		{
@@ -57,8 +141,11 @@ flavors:
       cacheEnabled: false
 `,
 			expected: RpaasConfig{
-				APIUsername: "u1",
-				ServiceName: "rpaasv2",
+				APIUsername:        "u1",
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
 				ServiceAnnotations: map[string]string{
 					"a": "b",
 					"c": "d",
@@ -97,9 +184,12 @@ flavors:
 				"RPAASV2_SERVICE_ANNOTATIONS": `{"x": "y"}`,
 			},
 			expected: RpaasConfig{
-				APIUsername: "u1",
-				APIPassword: "p1",
-				ServiceName: "rpaasv2be",
+				APIUsername:        "u1",
+				APIPassword:        "p1",
+				ServiceName:        "rpaasv2be",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
 				ServiceAnnotations: map[string]string{
 					"x": "y",
 				},
@@ -133,7 +223,10 @@ team-affinity:
             - dev
 `,
 			expected: RpaasConfig{
-				ServiceName: "rpaasv2",
+				ServiceName:        "rpaasv2",
+				HideServerTokens:   true,
+				MetricsEnabled:     true,
+				DefaultServiceType: corev1.ServiceTypeLoadBalancer,
 				DefaultAffinity: &corev1.Affinity{
 					NodeAffinity: &corev1.NodeAffinity{
 						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
@@ -3,6 +3,7 @@ module github.com/tsuru/rpaas-operator
 go 1.13
 
 require (
+	contrib.go.opencensus.io/exporter/ocagent v0.4.11
 	github.com/davecgh/go-spew v1.1.1
 	github.com/evanphx/json-patch v4.5.0+incompatible
 	github.com/fsnotify/fsnotify v1.4.7
@@ -13,17 +14,21 @@ require (
 	github.com/mitchellh/mapstructure v1.1.2
 	github.com/operator-framework/operator-sdk v0.9.0
 	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v1.0.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.4.0
 	github.com/stretchr/testify v1.4.0
 	github.com/tsuru/nginx-operator v0.2.1
+	go.opencensus.io v0.22.0
+	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4
 	k8s.io/api v0.0.0-20190726022912-69e1bce1dad5
 	k8s.io/apiextensions-apiserver v0.0.0-20190726024412-102230e288fd // indirect
 	k8s.io/apimachinery v0.0.0-20190727130956-f97a4e5b4abc
 	k8s.io/client-go v12.0.0+incompatible
 	k8s.io/kube-openapi v0.0.0-20190722073852-5e22f3d471e6
 	sigs.k8s.io/controller-runtime v0.1.10
+	sigs.k8s.io/yaml v1.1.0
 )
 
 // Pinned to kubernetes-1.13.4
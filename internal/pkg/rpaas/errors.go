@@ -5,9 +5,16 @@
 package rpaas
 
 import (
+	"errors"
+
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ValidationError, ConflictError and NotFoundError must be returned as
+// pointers (e.g. &NotFoundError{...}). The value forms are still recognized
+// by the Is* helpers below, but they are deprecated and will stop being
+// matched in a future release.
 type ValidationError struct {
 	Msg string
 }
@@ -19,6 +26,18 @@ func (e ValidationError) Error() string {
 	return e.Msg
 }
 
+// Is reports whether target is a ValidationError carrying the same message, no
+// matter whether either side is a value or a pointer.
+func (e ValidationError) Is(target error) bool {
+	switch t := target.(type) {
+	case ValidationError:
+		return e.Msg == t.Msg
+	case *ValidationError:
+		return t != nil && e.Msg == t.Msg
+	}
+	return false
+}
+
 type ConflictError struct {
 	Msg string
 }
@@ -30,6 +49,17 @@ func (e ConflictError) Error() string {
 	return e.Msg
 }
 
+// Is matches ConflictError values and pointers alike, see ValidationError.Is.
+func (e ConflictError) Is(target error) bool {
+	switch t := target.(type) {
+	case ConflictError:
+		return e.Msg == t.Msg
+	case *ConflictError:
+		return t != nil && e.Msg == t.Msg
+	}
+	return false
+}
+
 type NotFoundError struct {
 	Msg string
 }
@@ -41,29 +71,75 @@ func (e NotFoundError) Error() string {
 	return e.Msg
 }
 
+// Is matches NotFoundError values and pointers alike, see ValidationError.Is.
+func (e NotFoundError) Is(target error) bool {
+	switch t := target.(type) {
+	case NotFoundError:
+		return e.Msg == t.Msg
+	case *NotFoundError:
+		return t != nil && e.Msg == t.Msg
+	}
+	return false
+}
+
+// TimeoutError tells an operation didn't finish within its deadline.
+type TimeoutError struct {
+	Msg string
+}
+
+func (TimeoutError) IsTimeout() bool {
+	return true
+}
+func (e TimeoutError) Error() string {
+	return e.Msg
+}
+
 func IsValidationError(err error) bool {
-	if vErr, ok := err.(interface {
+	var vErr interface {
 		IsValidation() bool
-	}); ok {
+	}
+	if errors.As(err, &vErr) {
 		return vErr.IsValidation()
 	}
-	return k8sErrors.IsBadRequest(err)
+	return reasonForError(err) == metav1.StatusReasonBadRequest
 }
 
 func IsConflictError(err error) bool {
-	if vErr, ok := err.(interface {
+	var vErr interface {
 		IsConflict() bool
-	}); ok {
+	}
+	if errors.As(err, &vErr) {
 		return vErr.IsConflict()
 	}
-	return k8sErrors.IsConflict(err)
+	return reasonForError(err) == metav1.StatusReasonConflict
 }
 
 func IsNotFoundError(err error) bool {
-	if vErr, ok := err.(interface {
+	var vErr interface {
 		IsNotFound() bool
-	}); ok {
+	}
+	if errors.As(err, &vErr) {
 		return vErr.IsNotFound()
 	}
-	return k8sErrors.IsNotFound(err)
+	return reasonForError(err) == metav1.StatusReasonNotFound
+}
+
+func IsTimeoutError(err error) bool {
+	var vErr interface {
+		IsTimeout() bool
+	}
+	if errors.As(err, &vErr) {
+		return vErr.IsTimeout()
+	}
+	return reasonForError(err) == metav1.StatusReasonTimeout
+}
+
+// reasonForError works like k8sErrors.ReasonForError but also looks for API
+// status errors wrapped with fmt.Errorf("...: %w", err).
+func reasonForError(err error) metav1.StatusReason {
+	var status k8sErrors.APIStatus
+	if errors.As(err, &status) {
+		return status.Status().Reason
+	}
+	return metav1.StatusReasonUnknown
 }
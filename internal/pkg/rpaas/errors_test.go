@@ -0,0 +1,75 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsErrorHelpers(t *testing.T) {
+	tests := []struct {
+		err          error
+		isValidation bool
+		isConflict   bool
+		isNotFound   bool
+	}{
+		{err: &ValidationError{Msg: "invalid"}, isValidation: true},
+		{err: ValidationError{Msg: "invalid"}, isValidation: true},
+		{err: &ConflictError{Msg: "conflict"}, isConflict: true},
+		{err: ConflictError{Msg: "conflict"}, isConflict: true},
+		{err: &NotFoundError{Msg: "not found"}, isNotFound: true},
+		{err: NotFoundError{Msg: "not found"}, isNotFound: true},
+		{err: fmt.Errorf("wrapped: %w", &NotFoundError{Msg: "not found"}), isNotFound: true},
+		{err: fmt.Errorf("wrapped: %w", ValidationError{Msg: "invalid"}), isValidation: true},
+		{err: errors.New("some error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%#v", tt.err), func(t *testing.T) {
+			assert.Equal(t, tt.isValidation, IsValidationError(tt.err))
+			assert.Equal(t, tt.isConflict, IsConflictError(tt.err))
+			assert.Equal(t, tt.isNotFound, IsNotFoundError(tt.err))
+		})
+	}
+}
+
+func TestIsErrorHelpers_WrappedErrors(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", err))
+	}
+	resource := schema.GroupResource{Group: "extensions.tsuru.io", Resource: "rpaasinstances"}
+
+	assert.True(t, IsValidationError(wrap(&ValidationError{Msg: "invalid"})))
+	assert.True(t, IsConflictError(wrap(&ConflictError{Msg: "conflict"})))
+	assert.True(t, IsNotFoundError(wrap(&NotFoundError{Msg: "not found"})))
+	assert.True(t, IsValidationError(wrap(k8sErrors.NewBadRequest("invalid"))))
+	assert.True(t, IsConflictError(wrap(k8sErrors.NewConflict(resource, "my-instance", errors.New("conflict")))))
+	assert.True(t, IsNotFoundError(wrap(k8sErrors.NewNotFound(resource, "my-instance"))))
+	assert.False(t, IsNotFoundError(wrap(k8sErrors.NewBadRequest("invalid"))))
+	assert.False(t, IsNotFoundError(nil))
+	assert.True(t, IsTimeoutError(wrap(&TimeoutError{Msg: "timed out"})))
+	assert.True(t, IsTimeoutError(wrap(k8sErrors.NewTimeoutError("timed out", 1))))
+	assert.False(t, IsTimeoutError(wrap(&NotFoundError{Msg: "not found"})))
+
+	var nfErr *NotFoundError
+	if assert.True(t, errors.As(wrap(&NotFoundError{Msg: "not found"}), &nfErr)) {
+		assert.Equal(t, "not found", nfErr.Msg)
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	assert.True(t, errors.Is(&NotFoundError{Msg: "not found"}, NotFoundError{Msg: "not found"}))
+	assert.True(t, errors.Is(NotFoundError{Msg: "not found"}, &NotFoundError{Msg: "not found"}))
+	assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", &ValidationError{Msg: "invalid"}), &ValidationError{Msg: "invalid"}))
+	assert.True(t, errors.Is(ConflictError{Msg: "conflict"}, &ConflictError{Msg: "conflict"}))
+	assert.False(t, errors.Is(&NotFoundError{Msg: "not found"}, &NotFoundError{Msg: "other"}))
+	assert.False(t, errors.Is(&NotFoundError{Msg: "not found"}, &ValidationError{Msg: "not found"}))
+}
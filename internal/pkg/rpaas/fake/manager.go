@@ -7,50 +7,185 @@ package fake
 import (
 	"context"
 	"crypto/tls"
+	"time"
 
+	nginxv1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var _ rpaas.RpaasManager = &RpaasManager{}
 
 type RpaasManager struct {
-	FakeUpdateCertificate func(instance, name string, cert tls.Certificate) error
-	FakeCreateInstance    func(args rpaas.CreateArgs) error
-	FakeDeleteInstance    func(instanceName string) error
-	FakeUpdateInstance    func(instanceName string, args rpaas.UpdateInstanceArgs) error
-	FakeGetInstance       func(instanceName string) (*v1alpha1.RpaasInstance, error)
-	FakeDeleteBlock       func(instanceName, blockName string) error
-	FakeListBlocks        func(instanceName string) ([]rpaas.ConfigurationBlock, error)
-	FakeUpdateBlock       func(instanceName string, block rpaas.ConfigurationBlock) error
-	FakeInstanceAddress   func(name string) (string, error)
-	FakeInstanceStatus    func(name string) (rpaas.PodStatusMap, error)
-	FakeScale             func(instanceName string, replicas int32) error
-	FakeGetPlans          func() ([]v1alpha1.RpaasPlan, error)
-	FakeCreateExtraFiles  func(instanceName string, files ...rpaas.File) error
-	FakeDeleteExtraFiles  func(instanceName string, filenames ...string) error
-	FakeGetExtraFiles     func(instanceName string) ([]rpaas.File, error)
-	FakeUpdateExtraFiles  func(instanceName string, files ...rpaas.File) error
-	FakeBindApp           func(instanceName string, args rpaas.BindAppArgs) error
-	FakeUnbindApp         func(instanceName string) error
-	FakePurgeCache        func(instanceName string, args rpaas.PurgeCacheArgs) (int, error)
-	FakeDeleteRoute       func(instanceName, path string) error
-	FakeGetRoutes         func(instanceName string) ([]rpaas.Route, error)
-	FakeUpdateRoute       func(instanceName string, route rpaas.Route) error
-}
-
-func (m *RpaasManager) UpdateCertificate(ctx context.Context, instance, name string, c tls.Certificate) error {
+	FakeUpdateCertificate          func(instance, name string, cert tls.Certificate, strict bool) ([]string, error)
+	FakeDeriveCertificateName      func(instance string, c tls.Certificate) (string, error)
+	FakeUpdateCertificates         func(instance string, certs []rpaas.CertificateData, strict bool) ([]rpaas.CertificateResult, error)
+	FakeListCertificateNames       func(instance string) ([]string, error)
+	FakeGetCertificates            func(instance string) ([]rpaas.CertificateInfo, error)
+	FakeUpdateDefaultCertificate   func(instance, name string) error
+	FakeUpdateClientAuth           func(instance string, clientAuth rpaas.ClientAuth) error
+	FakeDeleteClientAuth           func(instance string) error
+	FakeGetTLSConfig               func(instance string) (*rpaas.TLSConfig, error)
+	FakeUpdateTLSConfig            func(instance string, tlsConfig rpaas.TLSConfig) error
+	FakeDeleteTLSConfig            func(instance string) error
+	FakeCreateInstance             func(args rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error)
+	FakePreviewService             func(args rpaas.CreateArgs) (*nginxv1alpha1.NginxService, error)
+	FakeDeleteInstance             func(instanceName string) error
+	FakeWaitInstanceTeardown       func(instanceName string, timeout time.Duration) error
+	FakeGarbageCollect             func(instanceName string, dryRun bool) ([]rpaas.GarbageCollectedObject, error)
+	FakeUpdateInstance             func(instanceName string, args rpaas.UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error)
+	FakeUpdateInstanceMetadata     func(instanceName string, args rpaas.UpdateInstanceMetadataArgs) error
+	FakeGetInstance                func(instanceName string) (*v1alpha1.RpaasInstance, error)
+	FakeGetInstanceMetadata        func(instanceName string) (*rpaas.InstanceMetadata, error)
+	FakeGetInstanceTags            func(instanceName string) (*rpaas.InstanceTags, error)
+	FakeDeleteBlock                func(instanceName, blockName string) error
+	FakeListBlocks                 func(instanceName string) ([]rpaas.ConfigurationBlock, error)
+	FakeGetAllowedBlocks           func() ([]string, error)
+	FakeUpdateBlock                func(instanceName string, block rpaas.ConfigurationBlock) error
+	FakeUpdateBlockAcrossInstances func(selector string, block rpaas.ConfigurationBlock) ([]rpaas.InstanceBlockResult, error)
+	FakeInstanceAddress            func(name string) (string, error)
+	FakeInstanceStatus             func(name string) (rpaas.PodStatusMap, error)
+	FakeInstancesStatus            func(names []string) (map[string]rpaas.PodStatusMap, error)
+	FakeGetNginxStatus             func(name string) (*rpaas.NginxStatus, error)
+	FakeGetReloadStatus            func(name string) (*rpaas.ReloadStatus, error)
+	FakeGetInstanceNginxInfo       func(name string) (*rpaas.NginxInfo, error)
+	FakeGetInstanceResources       func(name string) (*rpaas.InstanceResources, error)
+	FakeGetInstancePlanResources   func(name string) (*corev1.ResourceRequirements, error)
+	FakeExportInstance             func(name string) (*rpaas.InstanceExport, error)
+	FakeCloneInstance              func(source string, args rpaas.CloneArgs) error
+	FakeImportInstance             func(name string, export rpaas.InstanceExport) error
+	FakeScale                      func(instanceName string, replicas int32) error
+	FakeRestartInstance            func(instanceName string) error
+	FakeSyncInstance               func(instanceName string) error
+	FakeSetTeam                    func(instanceName, team string) error
+	FakeRepairLabels               func(instanceName string) error
+	FakeUpdateServerTokens         func(instanceName string, hide bool) error
+	FakeUpdateProxyProtocol        func(instanceName string, enabled bool) error
+	FakeUpdateExternalHostname     func(instanceName, hostname string) error
+	FakeUpdateRejectUnknownHosts   func(instanceName string, enabled bool) error
+	FakeUpdateRequestID            func(instanceName string, enabled bool) error
+	FakeUpdateHTTP3                func(instanceName string, enabled bool) error
+	FakeUpdateHealthCheck          func(instanceName, path string, status int) error
+	FakeUpdateStatusCallback       func(instanceName, url string) error
+	FakeUpdateCompression          func(instanceName string, compression rpaas.Compression) error
+	FakeUpdateAccessLog            func(instanceName string, accessLog rpaas.AccessLog) error
+	FakeUpdateRateLimit            func(instanceName string, rateLimit rpaas.RateLimit) error
+	FakeUpdateScaleToZero          func(instanceName string, scaleToZero rpaas.ScaleToZero) error
+	FakeUpdateAutoscale            func(instanceName string, autoscale rpaas.Autoscale) error
+	FakeGetAutoscaleStatus         func(instanceName string) (*rpaas.AutoscaleStatus, error)
+	FakeUpdatePodDisruptionBudget  func(instanceName string, pdb rpaas.PodDisruptionBudget) error
+	FakeDeletePodDisruptionBudget  func(instanceName string) error
+	FakeGetPlans                   func() ([]v1alpha1.RpaasPlan, error)
+	FakeGetPlan                    func(name, flavor string) (*v1alpha1.RpaasPlan, error)
+	FakeCreateExtraFiles           func(instanceName string, files ...rpaas.File) error
+	FakeDeleteExtraFiles           func(instanceName string, filenames ...string) error
+	FakeGetExtraFiles              func(instanceName string) ([]rpaas.File, error)
+	FakeUpdateExtraFiles           func(instanceName string, files ...rpaas.File) error
+	FakeBindApp                    func(instanceName string, args rpaas.BindAppArgs) error
+	FakeUnbindApp                  func(instanceName string) error
+	FakePurgeCache                 func(instanceName string, args rpaas.PurgeCacheArgs) (int, error)
+	FakeValidateInstanceConfig     func(instanceName string, desired rpaas.InstanceConfig) ([]rpaas.ValidationError, error)
+	FakeDeleteRoute                func(instanceName, path string) error
+	FakeDeleteRoutes               func(instanceName string, paths ...string) error
+	FakeGetRoutes                  func(instanceName string) ([]rpaas.Route, error)
+	FakeFindRoutesByDestination    func(instanceName, destination string) ([]rpaas.Route, error)
+	FakeUpdateRoute                func(instanceName string, route rpaas.Route) error
+	FakeTestDestination            func(instanceName, destination string) (*rpaas.DestinationCheck, error)
+	FakeUpdateMap                  func(instanceName string, args rpaas.MapArgs) error
+	FakeGetMaps                    func(instanceName string) ([]rpaas.MapArgs, error)
+	FakeDeleteMap                  func(instanceName, variable string) error
+}
+
+func (m *RpaasManager) UpdateCertificate(ctx context.Context, instance, name string, c tls.Certificate, strict bool) ([]string, error) {
 	if m.FakeUpdateCertificate != nil {
-		return m.FakeUpdateCertificate(instance, name, c)
+		return m.FakeUpdateCertificate(instance, name, c, strict)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) DeriveCertificateName(ctx context.Context, instance string, c tls.Certificate) (string, error) {
+	if m.FakeDeriveCertificateName != nil {
+		return m.FakeDeriveCertificateName(instance, c)
+	}
+	return "", nil
+}
+
+func (m *RpaasManager) UpdateCertificates(ctx context.Context, instance string, certs []rpaas.CertificateData, strict bool) ([]rpaas.CertificateResult, error) {
+	if m.FakeUpdateCertificates != nil {
+		return m.FakeUpdateCertificates(instance, certs, strict)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) UpdateClientAuth(ctx context.Context, instance string, clientAuth rpaas.ClientAuth) error {
+	if m.FakeUpdateClientAuth != nil {
+		return m.FakeUpdateClientAuth(instance, clientAuth)
+	}
+	return nil
+}
+
+func (m *RpaasManager) DeleteClientAuth(ctx context.Context, instance string) error {
+	if m.FakeDeleteClientAuth != nil {
+		return m.FakeDeleteClientAuth(instance)
+	}
+	return nil
+}
+
+func (m *RpaasManager) GetTLSConfig(ctx context.Context, instance string) (*rpaas.TLSConfig, error) {
+	if m.FakeGetTLSConfig != nil {
+		return m.FakeGetTLSConfig(instance)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) UpdateTLSConfig(ctx context.Context, instance string, tlsConfig rpaas.TLSConfig) error {
+	if m.FakeUpdateTLSConfig != nil {
+		return m.FakeUpdateTLSConfig(instance, tlsConfig)
+	}
+	return nil
+}
+
+func (m *RpaasManager) DeleteTLSConfig(ctx context.Context, instance string) error {
+	if m.FakeDeleteTLSConfig != nil {
+		return m.FakeDeleteTLSConfig(instance)
+	}
+	return nil
+}
+
+func (m *RpaasManager) ListCertificateNames(ctx context.Context, instance string) ([]string, error) {
+	if m.FakeListCertificateNames != nil {
+		return m.FakeListCertificateNames(instance)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetCertificates(ctx context.Context, instance string) ([]rpaas.CertificateInfo, error) {
+	if m.FakeGetCertificates != nil {
+		return m.FakeGetCertificates(instance)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) UpdateDefaultCertificate(ctx context.Context, instance, name string) error {
+	if m.FakeUpdateDefaultCertificate != nil {
+		return m.FakeUpdateDefaultCertificate(instance, name)
 	}
 	return nil
 }
 
-func (m *RpaasManager) CreateInstance(ctx context.Context, args rpaas.CreateArgs) error {
+func (m *RpaasManager) CreateInstance(ctx context.Context, args rpaas.CreateArgs) (*v1alpha1.RpaasInstance, error) {
 	if m.FakeCreateInstance != nil {
 		return m.FakeCreateInstance(args)
 	}
-	return nil
+	return nil, nil
+}
+
+func (m *RpaasManager) PreviewService(ctx context.Context, args rpaas.CreateArgs) (*nginxv1alpha1.NginxService, error) {
+	if m.FakePreviewService != nil {
+		return m.FakePreviewService(args)
+	}
+	return nil, nil
 }
 
 func (m *RpaasManager) DeleteInstance(ctx context.Context, name string) error {
@@ -60,10 +195,31 @@ func (m *RpaasManager) DeleteInstance(ctx context.Context, name string) error {
 	return nil
 }
 
-func (m *RpaasManager) UpdateInstance(ctx context.Context, name string, args rpaas.UpdateInstanceArgs) error {
+func (m *RpaasManager) WaitInstanceTeardown(ctx context.Context, name string, timeout time.Duration) error {
+	if m.FakeWaitInstanceTeardown != nil {
+		return m.FakeWaitInstanceTeardown(name, timeout)
+	}
+	return nil
+}
+
+func (m *RpaasManager) GarbageCollect(ctx context.Context, instanceName string, dryRun bool) ([]rpaas.GarbageCollectedObject, error) {
+	if m.FakeGarbageCollect != nil {
+		return m.FakeGarbageCollect(instanceName, dryRun)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) UpdateInstance(ctx context.Context, name string, args rpaas.UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error) {
 	if m.FakeUpdateInstance != nil {
 		return m.FakeUpdateInstance(name, args)
 	}
+	return nil, nil
+}
+
+func (m *RpaasManager) UpdateInstanceMetadata(ctx context.Context, name string, args rpaas.UpdateInstanceMetadataArgs) error {
+	if m.FakeUpdateInstanceMetadata != nil {
+		return m.FakeUpdateInstanceMetadata(name, args)
+	}
 	return nil
 }
 
@@ -74,6 +230,20 @@ func (m *RpaasManager) GetInstance(ctx context.Context, name string) (*v1alpha1.
 	return nil, nil
 }
 
+func (m *RpaasManager) GetInstanceMetadata(ctx context.Context, name string) (*rpaas.InstanceMetadata, error) {
+	if m.FakeGetInstanceMetadata != nil {
+		return m.FakeGetInstanceMetadata(name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetInstanceTags(ctx context.Context, name string) (*rpaas.InstanceTags, error) {
+	if m.FakeGetInstanceTags != nil {
+		return m.FakeGetInstanceTags(name)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) DeleteBlock(ctx context.Context, instanceName, blockName string) error {
 	if m.FakeDeleteBlock != nil {
 		return m.FakeDeleteBlock(instanceName, blockName)
@@ -88,6 +258,13 @@ func (m *RpaasManager) ListBlocks(ctx context.Context, instanceName string) ([]r
 	return nil, nil
 }
 
+func (m *RpaasManager) GetAllowedBlocks(ctx context.Context) ([]string, error) {
+	if m.FakeGetAllowedBlocks != nil {
+		return m.FakeGetAllowedBlocks()
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) UpdateBlock(ctx context.Context, instanceName string, block rpaas.ConfigurationBlock) error {
 	if m.FakeUpdateBlock != nil {
 		return m.FakeUpdateBlock(instanceName, block)
@@ -95,6 +272,13 @@ func (m *RpaasManager) UpdateBlock(ctx context.Context, instanceName string, blo
 	return nil
 }
 
+func (m *RpaasManager) UpdateBlockAcrossInstances(ctx context.Context, selector string, block rpaas.ConfigurationBlock) ([]rpaas.InstanceBlockResult, error) {
+	if m.FakeUpdateBlockAcrossInstances != nil {
+		return m.FakeUpdateBlockAcrossInstances(selector, block)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) GetInstanceAddress(ctx context.Context, name string) (string, error) {
 	if m.FakeInstanceAddress != nil {
 		return m.FakeInstanceAddress(name)
@@ -109,6 +293,69 @@ func (m *RpaasManager) GetInstanceStatus(ctx context.Context, name string) (rpaa
 	return nil, nil
 }
 
+func (m *RpaasManager) GetInstancesStatus(ctx context.Context, names []string) (map[string]rpaas.PodStatusMap, error) {
+	if m.FakeInstancesStatus != nil {
+		return m.FakeInstancesStatus(names)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetNginxStatus(ctx context.Context, name string) (*rpaas.NginxStatus, error) {
+	if m.FakeGetNginxStatus != nil {
+		return m.FakeGetNginxStatus(name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetReloadStatus(ctx context.Context, name string) (*rpaas.ReloadStatus, error) {
+	if m.FakeGetReloadStatus != nil {
+		return m.FakeGetReloadStatus(name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetInstanceNginxInfo(ctx context.Context, name string) (*rpaas.NginxInfo, error) {
+	if m.FakeGetInstanceNginxInfo != nil {
+		return m.FakeGetInstanceNginxInfo(name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetInstanceResources(ctx context.Context, name string) (*rpaas.InstanceResources, error) {
+	if m.FakeGetInstanceResources != nil {
+		return m.FakeGetInstanceResources(name)
+	}
+	return &rpaas.InstanceResources{}, nil
+}
+
+func (m *RpaasManager) GetInstancePlanResources(ctx context.Context, name string) (*corev1.ResourceRequirements, error) {
+	if m.FakeGetInstancePlanResources != nil {
+		return m.FakeGetInstancePlanResources(name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) ExportInstance(ctx context.Context, name string) (*rpaas.InstanceExport, error) {
+	if m.FakeExportInstance != nil {
+		return m.FakeExportInstance(name)
+	}
+	return &rpaas.InstanceExport{}, nil
+}
+
+func (m *RpaasManager) CloneInstance(ctx context.Context, source string, args rpaas.CloneArgs) error {
+	if m.FakeCloneInstance != nil {
+		return m.FakeCloneInstance(source, args)
+	}
+	return nil
+}
+
+func (m *RpaasManager) ImportInstance(ctx context.Context, name string, export rpaas.InstanceExport) error {
+	if m.FakeImportInstance != nil {
+		return m.FakeImportInstance(name, export)
+	}
+	return nil
+}
+
 func (m *RpaasManager) Scale(ctx context.Context, instanceName string, replicas int32) error {
 	if m.FakeScale != nil {
 		return m.FakeScale(instanceName, replicas)
@@ -123,6 +370,13 @@ func (m *RpaasManager) GetPlans(ctx context.Context) ([]v1alpha1.RpaasPlan, erro
 	return nil, nil
 }
 
+func (m *RpaasManager) GetPlan(ctx context.Context, name, flavor string) (*v1alpha1.RpaasPlan, error) {
+	if m.FakeGetPlan != nil {
+		return m.FakeGetPlan(name, flavor)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) CreateExtraFiles(ctx context.Context, instanceName string, files ...rpaas.File) error {
 	if m.FakeCreateExtraFiles != nil {
 		return m.FakeCreateExtraFiles(instanceName, files...)
@@ -179,6 +433,13 @@ func (m *RpaasManager) DeleteRoute(ctx context.Context, instanceName, path strin
 	return nil
 }
 
+func (m *RpaasManager) DeleteRoutes(ctx context.Context, instanceName string, paths ...string) error {
+	if m.FakeDeleteRoutes != nil {
+		return m.FakeDeleteRoutes(instanceName, paths...)
+	}
+	return nil
+}
+
 func (m *RpaasManager) GetRoutes(ctx context.Context, instanceName string) ([]rpaas.Route, error) {
 	if m.FakeGetRoutes != nil {
 		return m.FakeGetRoutes(instanceName)
@@ -186,9 +447,191 @@ func (m *RpaasManager) GetRoutes(ctx context.Context, instanceName string) ([]rp
 	return nil, nil
 }
 
+func (m *RpaasManager) FindRoutesByDestination(ctx context.Context, instanceName, destination string) ([]rpaas.Route, error) {
+	if m.FakeFindRoutesByDestination != nil {
+		return m.FakeFindRoutesByDestination(instanceName, destination)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) TestDestination(ctx context.Context, instanceName, destination string) (*rpaas.DestinationCheck, error) {
+	if m.FakeTestDestination != nil {
+		return m.FakeTestDestination(instanceName, destination)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) UpdateRoute(ctx context.Context, instanceName string, route rpaas.Route) error {
 	if m.FakeUpdateRoute != nil {
 		return m.FakeUpdateRoute(instanceName, route)
 	}
 	return nil
 }
+
+func (m *RpaasManager) UpdateMap(ctx context.Context, instanceName string, args rpaas.MapArgs) error {
+	if m.FakeUpdateMap != nil {
+		return m.FakeUpdateMap(instanceName, args)
+	}
+	return nil
+}
+
+func (m *RpaasManager) GetMaps(ctx context.Context, instanceName string) ([]rpaas.MapArgs, error) {
+	if m.FakeGetMaps != nil {
+		return m.FakeGetMaps(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) DeleteMap(ctx context.Context, instanceName, variable string) error {
+	if m.FakeDeleteMap != nil {
+		return m.FakeDeleteMap(instanceName, variable)
+	}
+	return nil
+}
+
+func (m *RpaasManager) RestartInstance(ctx context.Context, instanceName string) error {
+	if m.FakeRestartInstance != nil {
+		return m.FakeRestartInstance(instanceName)
+	}
+	return nil
+}
+
+func (m *RpaasManager) SyncInstance(ctx context.Context, instanceName string) error {
+	if m.FakeSyncInstance != nil {
+		return m.FakeSyncInstance(instanceName)
+	}
+	return nil
+}
+
+func (m *RpaasManager) SetTeam(ctx context.Context, instanceName, team string) error {
+	if m.FakeSetTeam != nil {
+		return m.FakeSetTeam(instanceName, team)
+	}
+	return nil
+}
+
+func (m *RpaasManager) RepairLabels(ctx context.Context, instanceName string) error {
+	if m.FakeRepairLabels != nil {
+		return m.FakeRepairLabels(instanceName)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateServerTokens(ctx context.Context, instanceName string, hide bool) error {
+	if m.FakeUpdateServerTokens != nil {
+		return m.FakeUpdateServerTokens(instanceName, hide)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateHealthCheck(ctx context.Context, instanceName, path string, status int) error {
+	if m.FakeUpdateHealthCheck != nil {
+		return m.FakeUpdateHealthCheck(instanceName, path, status)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateCompression(ctx context.Context, instanceName string, compression rpaas.Compression) error {
+	if m.FakeUpdateCompression != nil {
+		return m.FakeUpdateCompression(instanceName, compression)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateAccessLog(ctx context.Context, instanceName string, accessLog rpaas.AccessLog) error {
+	if m.FakeUpdateAccessLog != nil {
+		return m.FakeUpdateAccessLog(instanceName, accessLog)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateRateLimit(ctx context.Context, instanceName string, rateLimit rpaas.RateLimit) error {
+	if m.FakeUpdateRateLimit != nil {
+		return m.FakeUpdateRateLimit(instanceName, rateLimit)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateScaleToZero(ctx context.Context, instanceName string, scaleToZero rpaas.ScaleToZero) error {
+	if m.FakeUpdateScaleToZero != nil {
+		return m.FakeUpdateScaleToZero(instanceName, scaleToZero)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateStatusCallback(ctx context.Context, instanceName, url string) error {
+	if m.FakeUpdateStatusCallback != nil {
+		return m.FakeUpdateStatusCallback(instanceName, url)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateProxyProtocol(ctx context.Context, instanceName string, enabled bool) error {
+	if m.FakeUpdateProxyProtocol != nil {
+		return m.FakeUpdateProxyProtocol(instanceName, enabled)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateExternalHostname(ctx context.Context, instanceName, hostname string) error {
+	if m.FakeUpdateExternalHostname != nil {
+		return m.FakeUpdateExternalHostname(instanceName, hostname)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateRejectUnknownHosts(ctx context.Context, instanceName string, enabled bool) error {
+	if m.FakeUpdateRejectUnknownHosts != nil {
+		return m.FakeUpdateRejectUnknownHosts(instanceName, enabled)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateRequestID(ctx context.Context, instanceName string, enabled bool) error {
+	if m.FakeUpdateRequestID != nil {
+		return m.FakeUpdateRequestID(instanceName, enabled)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateHTTP3(ctx context.Context, instanceName string, enabled bool) error {
+	if m.FakeUpdateHTTP3 != nil {
+		return m.FakeUpdateHTTP3(instanceName, enabled)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdateAutoscale(ctx context.Context, instanceName string, autoscale rpaas.Autoscale) error {
+	if m.FakeUpdateAutoscale != nil {
+		return m.FakeUpdateAutoscale(instanceName, autoscale)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UpdatePodDisruptionBudget(ctx context.Context, instanceName string, pdb rpaas.PodDisruptionBudget) error {
+	if m.FakeUpdatePodDisruptionBudget != nil {
+		return m.FakeUpdatePodDisruptionBudget(instanceName, pdb)
+	}
+	return nil
+}
+
+func (m *RpaasManager) DeletePodDisruptionBudget(ctx context.Context, instanceName string) error {
+	if m.FakeDeletePodDisruptionBudget != nil {
+		return m.FakeDeletePodDisruptionBudget(instanceName)
+	}
+	return nil
+}
+
+func (m *RpaasManager) GetAutoscaleStatus(ctx context.Context, instanceName string) (*rpaas.AutoscaleStatus, error) {
+	if m.FakeGetAutoscaleStatus != nil {
+		return m.FakeGetAutoscaleStatus(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) ValidateInstanceConfig(ctx context.Context, instanceName string, desired rpaas.InstanceConfig) ([]rpaas.ValidationError, error) {
+	if m.FakeValidateInstanceConfig != nil {
+		return m.FakeValidateInstanceConfig(instanceName, desired)
+	}
+	return nil, nil
+}
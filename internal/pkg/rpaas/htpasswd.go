@@ -0,0 +1,129 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	apr1Magic    = "$apr1$"
+	apr1Alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// parseHtpasswd returns the password hashes, by user name, from an
+// htpasswd-formatted content.
+func parseHtpasswd(content []byte) map[string]string {
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		hashes[parts[0]] = parts[1]
+	}
+	return hashes
+}
+
+// formatHtpasswd writes the password hashes in the htpasswd format, sorted by
+// user name.
+func formatHtpasswd(hashes map[string]string) []byte {
+	var users []string
+	for user := range hashes {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	var buffer bytes.Buffer
+	for _, user := range users {
+		fmt.Fprintf(&buffer, "%s:%s\n", user, hashes[user])
+	}
+	return buffer.Bytes()
+}
+
+// hashPassword hashes the password using the Apache's MD5-based algorithm
+// (apr1), which NGINX supports regardless of the system's crypt(3).
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	for i := range salt {
+		salt[i] = apr1Alphabet[int(salt[i])%len(apr1Alphabet)]
+	}
+	return apr1(password, string(salt)), nil
+}
+
+func apr1(password, salt string) string {
+	pw := []byte(password)
+
+	alternate := md5.New()
+	alternate.Write(pw)
+	alternate.Write([]byte(salt))
+	alternate.Write(pw)
+	alternateSum := alternate.Sum(nil)
+
+	ctx := md5.New()
+	ctx.Write(pw)
+	ctx.Write([]byte(apr1Magic))
+	ctx.Write([]byte(salt))
+	for i := len(pw); i > 0; i -= md5.Size {
+		if i > md5.Size {
+			ctx.Write(alternateSum)
+		} else {
+			ctx.Write(alternateSum[:i])
+		}
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write(pw[:1])
+		}
+	}
+	sum := ctx.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		round := md5.New()
+		if i&1 == 1 {
+			round.Write(pw)
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			round.Write(pw)
+		}
+		if i&1 == 1 {
+			round.Write(sum)
+		} else {
+			round.Write(pw)
+		}
+		sum = round.Sum(nil)
+	}
+
+	var encoded strings.Builder
+	encode := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			encoded.WriteByte(apr1Alphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, idx := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint32(sum[idx[0]])<<16|uint32(sum[idx[1]])<<8|uint32(sum[idx[2]]), 4)
+	}
+	encode(uint32(sum[11]), 2)
+
+	return apr1Magic + salt + "$" + encoded.String()
+}
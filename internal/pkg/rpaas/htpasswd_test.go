@@ -0,0 +1,36 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_apr1(t *testing.T) {
+	assert.Equal(t, "$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/", apr1("password", "saltsalt"))
+	assert.Equal(t, "$apr1$ab12$mL2nZeC6lh5VvEgaAQEqJ1", apr1("süper secret", "ab12"))
+}
+
+func Test_hashPassword(t *testing.T) {
+	hash, err := hashPassword("password")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$apr1$"))
+	salt := strings.Split(hash, "$")[2]
+	assert.Len(t, salt, 8)
+	assert.Equal(t, apr1("password", salt), hash)
+}
+
+func Test_parseAndFormatHtpasswd(t *testing.T) {
+	hashes := parseHtpasswd([]byte("joe:$apr1$ab12$mL2nZeC6lh5VvEgaAQEqJ1\n\ninvalid line\nadmin:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/\n"))
+	assert.Equal(t, map[string]string{
+		"admin": "$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/",
+		"joe":   "$apr1$ab12$mL2nZeC6lh5VvEgaAQEqJ1",
+	}, hashes)
+	assert.Equal(t, "admin:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/\njoe:$apr1$ab12$mL2nZeC6lh5VvEgaAQEqJ1\n", string(formatHtpasswd(hashes)))
+}
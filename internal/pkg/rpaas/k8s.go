@@ -12,13 +12,20 @@ import (
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
+	"net/url"
 	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
@@ -26,11 +33,21 @@ import (
 	nginxManager "github.com/tsuru/rpaas-operator/internal/pkg/rpaas/nginx"
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
 	"github.com/tsuru/rpaas-operator/pkg/util"
+	"go.opencensus.io/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -38,14 +55,21 @@ import (
 const (
 	defaultNamespace      = "rpaasv2"
 	defaultKeyLabelPrefix = "rpaas.extensions.tsuru.io"
+
+	nginxResourceNameLabel = "nginx.tsuru.io/resource-name"
+
+	defaultRouteContentMaxInlineSize = 512 * 1024
 )
 
 var _ RpaasManager = &k8sRpaasManager{}
 
 type k8sRpaasManager struct {
-	nonCachedCli client.Client
-	cli          client.Client
-	cacheManager CacheManager
+	nonCachedCli       client.Client
+	cli                client.Client
+	cacheManager       CacheManager
+	reloadChecker      ReloadChecker
+	versionChecker     VersionChecker
+	destinationChecker DestinationChecker
 }
 
 func NewK8S(mgr manager.Manager) (RpaasManager, error) {
@@ -57,43 +81,269 @@ func NewK8S(mgr manager.Manager) (RpaasManager, error) {
 		return nil, err
 	}
 	return &k8sRpaasManager{
-		nonCachedCli: nonCachedCli,
-		cli:          mgr.GetClient(),
-		cacheManager: nginxManager.NewNginxManager(),
+		nonCachedCli:       nonCachedCli,
+		cli:                mgr.GetClient(),
+		cacheManager:       nginxManager.NewNginxManager(),
+		reloadChecker:      nginxManager.NewNginxManager(),
+		versionChecker:     nginxManager.NewNginxManager(),
+		destinationChecker: nginxManager.NewNginxManager(),
 	}, nil
 }
 
 func (m *k8sRpaasManager) DeleteInstance(ctx context.Context, name string) error {
+	ctx, span := trace.StartSpan(ctx, "rpaas.DeleteInstance")
+	defer span.End()
+
 	instance, err := m.GetInstance(ctx, name)
 	if err != nil {
 		return err
 	}
+	if err = m.deleteOwnedObjects(ctx, *instance); err != nil {
+		return err
+	}
 	return m.cli.Delete(ctx, instance)
 }
 
-func (m *k8sRpaasManager) CreateInstance(ctx context.Context, args CreateArgs) error {
-	if err := m.validateCreate(ctx, args); err != nil {
+// instanceTeardownPollInterval is how often WaitInstanceTeardown looks for
+// the remaining objects of an instance.
+var instanceTeardownPollInterval = 2 * time.Second
+
+func (m *k8sRpaasManager) WaitInstanceTeardown(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(instanceTeardownPollInterval)
+	defer ticker.Stop()
+
+	var remaining []string
+	for {
+		// Errors caused by the deadline itself are reported as a timeout,
+		// along with the objects found by the last successful check.
+		current, err := m.remainingInstanceObjects(ctx, name)
+		switch {
+		case err == nil && len(current) == 0:
+			return nil
+		case err == nil:
+			remaining = current
+		case ctx.Err() == nil:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return &TimeoutError{Msg: fmt.Sprintf("timed out waiting for the teardown of instance %q, still present: %s", name, strings.Join(remaining, ", "))}
+		case <-ticker.C:
+		}
+	}
+}
+
+// remainingInstanceObjects returns the pods and Services created by
+// nginx-operator for the instance which still exist.
+func (m *k8sRpaasManager) remainingInstanceObjects(ctx context.Context, name string) ([]string, error) {
+	listOpts := client.InNamespace(namespaceName()).
+		MatchingLabels(map[string]string{nginxResourceNameLabel: name})
+
+	var pods corev1.PodList
+	if err := m.cli.List(ctx, listOpts, &pods); err != nil {
+		return nil, err
+	}
+
+	var services corev1.ServiceList
+	if err := m.cli.List(ctx, listOpts, &services); err != nil {
+		return nil, err
+	}
+
+	// The label selector is filtered again, just like GetInstance does.
+	var remaining []string
+	for _, pod := range pods.Items {
+		if pod.Labels[nginxResourceNameLabel] == name {
+			remaining = append(remaining, fmt.Sprintf("pod %q", pod.Name))
+		}
+	}
+	for _, svc := range services.Items {
+		if svc.Labels[nginxResourceNameLabel] == name {
+			remaining = append(remaining, fmt.Sprintf("service %q", svc.Name))
+		}
+	}
+	return remaining, nil
+}
+
+// deleteOwnedObjects removes the ConfigMaps and Secrets (e.g. extra files and
+// certificates) controlled by instance. Those objects already carry owner
+// references, but they're explicitly removed here so nothing is leaked when
+// the garbage collector cannot clean them up.
+func (m *k8sRpaasManager) deleteOwnedObjects(ctx context.Context, instance v1alpha1.RpaasInstance) error {
+	var configMaps corev1.ConfigMapList
+	if err := m.cli.List(ctx, client.InNamespace(instance.Namespace), &configMaps); err != nil {
+		return err
+	}
+	for i := range configMaps.Items {
+		if !isOwnedBy(configMaps.Items[i].ObjectMeta, instance) {
+			continue
+		}
+		if err := m.cli.Delete(ctx, &configMaps.Items[i]); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	var secrets corev1.SecretList
+	if err := m.cli.List(ctx, client.InNamespace(instance.Namespace), &secrets); err != nil {
 		return err
 	}
+	for i := range secrets.Items {
+		if !isOwnedBy(secrets.Items[i].ObjectMeta, instance) {
+			continue
+		}
+		if err := m.cli.Delete(ctx, &secrets.Items[i]); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
 
-	nsName, err := m.ensureNamespaceExists(ctx)
+	return nil
+}
+
+// GarbageCollect removes the ConfigMaps and Secrets labeled as belonging to
+// instance which are no longer referenced by its spec, e.g. the ones left
+// behind by migrations. With dryRun, the stale objects are only listed.
+func (m *k8sRpaasManager) GarbageCollect(ctx context.Context, instanceName string, dryRun bool) ([]GarbageCollectedObject, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	referencedConfigMaps := referencedConfigMaps(*instance)
+	listOpts := client.InNamespace(instance.Namespace).
+		MatchingLabels(labelsForRpaasInstance(instance.Name))
+
+	var collected []GarbageCollectedObject
+
+	var configMaps corev1.ConfigMapList
+	if err = m.cli.List(ctx, listOpts, &configMaps); err != nil {
+		return nil, err
+	}
+	for i := range configMaps.Items {
+		if !hasInstanceLabels(configMaps.Items[i].ObjectMeta, *instance) || referencedConfigMaps[configMaps.Items[i].Name] {
+			continue
+		}
+		if !dryRun {
+			if err = m.cli.Delete(ctx, &configMaps.Items[i]); err != nil && !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+		collected = append(collected, GarbageCollectedObject{Kind: "ConfigMap", Name: configMaps.Items[i].Name})
+	}
+
+	var secrets corev1.SecretList
+	if err = m.cli.List(ctx, listOpts, &secrets); err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		if !hasInstanceLabels(secrets.Items[i].ObjectMeta, *instance) {
+			continue
+		}
+		if instance.Spec.Certificates != nil && instance.Spec.Certificates.SecretName == secrets.Items[i].Name {
+			continue
+		}
+		if !dryRun {
+			if err = m.cli.Delete(ctx, &secrets.Items[i]); err != nil && !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+		collected = append(collected, GarbageCollectedObject{Kind: "Secret", Name: secrets.Items[i].Name})
+	}
+
+	return collected, nil
+}
+
+// hasInstanceLabels double checks the label selector, since only objects
+// labeled for the instance may ever be garbage collected.
+func hasInstanceLabels(object metav1.ObjectMeta, instance v1alpha1.RpaasInstance) bool {
+	for key, value := range labelsForRpaasInstance(instance.Name) {
+		if object.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// referencedConfigMaps returns the names of the ConfigMaps used by the
+// instance's extra files, blocks and locations.
+func referencedConfigMaps(instance v1alpha1.RpaasInstance) map[string]bool {
+	names := make(map[string]bool)
+	if instance.Spec.ExtraFiles != nil {
+		names[instance.Spec.ExtraFiles.Name] = true
+	}
+	addValueRef := func(value *v1alpha1.Value) {
+		if value != nil && value.ValueFrom != nil && value.ValueFrom.ConfigMapKeyRef != nil {
+			names[value.ValueFrom.ConfigMapKeyRef.Name] = true
+		}
+	}
+	for _, block := range instance.Spec.Blocks {
+		block := block
+		addValueRef(&block)
+	}
+	for _, location := range instance.Spec.Locations {
+		addValueRef(location.Content)
+	}
+	return names
+}
+
+// newOwnerReference returns a controller reference to instance, which should
+// be set on every object created on its behalf so that the garbage collector
+// can reclaim them once the instance is gone.
+func newOwnerReference(instance v1alpha1.RpaasInstance) *metav1.OwnerReference {
+	return metav1.NewControllerRef(&instance, schema.GroupVersionKind{
+		Group:   v1alpha1.SchemeGroupVersion.Group,
+		Version: v1alpha1.SchemeGroupVersion.Version,
+		Kind:    "RpaasInstance",
+	})
+}
+
+func isOwnedBy(object metav1.ObjectMeta, instance v1alpha1.RpaasInstance) bool {
+	owner := metav1.GetControllerOf(&object)
+	if owner == nil {
+		return false
+	}
+	return owner.Kind == "RpaasInstance" && owner.Name == instance.Name && owner.UID == instance.UID
+}
+
+func (m *k8sRpaasManager) CreateInstance(ctx context.Context, args CreateArgs) (*v1alpha1.RpaasInstance, error) {
+	ctx, span := trace.StartSpan(ctx, "rpaas.CreateInstance")
+	defer span.End()
+
+	if err := m.validateCreate(ctx, args); err != nil {
+		return nil, err
+	}
+
+	// In dry-run mode, even the namespace must not be created.
+	nsName := getServiceName()
+	if !args.DryRun {
+		if _, err := m.ensureNamespaceExists(ctx); err != nil {
+			return nil, err
+		}
 	}
 
 	plan, err := m.getPlan(ctx, args.Plan)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var ingress *v1alpha1.RpaasInstanceIngressSpec
+	if args.IngressHost != "" {
+		ingress = &v1alpha1.RpaasInstanceIngressSpec{
+			ClassName:     args.IngressClass,
+			Host:          args.IngressHost,
+			TLSSecretName: args.IngressTLSSecret,
+		}
 	}
 
 	instance := newRpaasInstance(args.Name)
 	instance.Namespace = nsName
 	instance.Spec.PlanName = plan.Name
 	instance.Spec.Replicas = func(n int32) *int32 { return &n }(int32(1)) // one replica
-	instance.Spec.Service = &nginxv1alpha1.NginxService{
-		Type:        corev1.ServiceTypeLoadBalancer,
-		Annotations: config.Get().ServiceAnnotations,
-		Labels:      instance.Labels,
+	instance.Spec.Service, err = newInstanceService(args, instance.Labels)
+	if err != nil {
+		return nil, err
 	}
 	instance.Spec.PodTemplate = nginxv1alpha1.NginxPodTemplateSpec{
 		Affinity:    getAffinity(args.Team),
@@ -101,38 +351,219 @@ func (m *k8sRpaasManager) CreateInstance(ctx context.Context, args CreateArgs) e
 		Labels:      instance.Labels,
 	}
 
+	instance.Spec.HideServerTokens = v1alpha1.Bool(config.Get().HideServerTokens)
+	instance.Spec.Ingress = ingress
+	instance.Spec.NodeSelector = args.NodeSelector
+	instance.Spec.TopologySpreadConstraints = args.TopologySpreadConstraints
+	instance.Spec.ExtraPorts = args.ExtraPorts
+	instance.Spec.Env = args.Env
+
 	setDescription(instance, args.Description)
 	setTeamOwner(instance, args.Team)
+	setOwner(instance, args.User)
+
+	if err := setPlanOverride(instance, args.Tags, args.PlanOverride); err != nil {
+		return nil, err
+	}
 
 	if err := setTags(instance, args.Tags); err != nil {
-		return err
+		return nil, err
+	}
+
+	if args.DryRun {
+		return instance, nil
+	}
+
+	if err := m.cli.Create(ctx, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+func (m *k8sRpaasManager) PreviewService(ctx context.Context, args CreateArgs) (*nginxv1alpha1.NginxService, error) {
+	if err := m.validateCreate(ctx, args); err != nil {
+		return nil, err
+	}
+	// The Service shares the labels of the instance, including the ones
+	// set after it is built, e.g. the team owner.
+	instance := newRpaasInstance(args.Name)
+	setTeamOwner(instance, args.Team)
+	return newInstanceService(args, instance.Labels)
+}
+
+// newInstanceService returns the Service spec of an instance being created
+// with the given arguments.
+func newInstanceService(args CreateArgs, labels map[string]string) (*nginxv1alpha1.NginxService, error) {
+	serviceType, err := getServiceType(args.ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Ingress controller is the entry point, so the Service only needs
+	// to be reachable inside the cluster.
+	if args.IngressHost != "" && args.ServiceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
 	}
 
-	return m.cli.Create(ctx, instance)
+	return &nginxv1alpha1.NginxService{
+		Type:        serviceType,
+		Annotations: mergeServiceAnnotations(config.Get().ServiceAnnotations, args.ServiceAnnotations),
+		Labels:      labels,
+	}, nil
 }
 
-func (m *k8sRpaasManager) UpdateInstance(ctx context.Context, instanceName string, args UpdateInstanceArgs) error {
+func (m *k8sRpaasManager) UpdateInstance(ctx context.Context, instanceName string, args UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error) {
+	ctx, span := trace.StartSpan(ctx, "rpaas.UpdateInstance")
+	defer span.End()
+
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	plan, err := m.getPlan(ctx, args.Plan)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if args.NodeSelector != nil {
+		if err = validateNodeSelector(args.NodeSelector); err != nil {
+			return nil, err
+		}
+		instance.Spec.NodeSelector = args.NodeSelector
+	}
+
+	if args.TopologySpreadConstraints != nil {
+		if err = validateTopologySpreadConstraints(args.TopologySpreadConstraints); err != nil {
+			return nil, err
+		}
+		instance.Spec.TopologySpreadConstraints = args.TopologySpreadConstraints
+	}
+
+	if args.ServiceAnnotations != nil {
+		if err = validateServiceAnnotations(args.ServiceAnnotations); err != nil {
+			return nil, err
+		}
+		if instance.Spec.Service == nil {
+			instance.Spec.Service = &nginxv1alpha1.NginxService{}
+		}
+		instance.Spec.Service.Annotations = mergeServiceAnnotations(instance.Spec.Service.Annotations, args.ServiceAnnotations)
+	}
+
+	if args.ExtraPorts != nil {
+		if err = validateExtraPorts(args.ExtraPorts); err != nil {
+			return nil, err
+		}
+		instance.Spec.ExtraPorts = args.ExtraPorts
+	}
+
+	if args.Env != nil {
+		if err = validateEnv(args.Env); err != nil {
+			return nil, err
+		}
+		instance.Spec.Env = args.Env
 	}
 
 	instance.Spec.PlanName = plan.Name
 	setDescription(instance, args.Description)
 	setTeamOwner(instance, args.Team)
+	repairLabels(instance)
+
+	if err = setPlanOverride(instance, args.Tags, args.PlanOverride); err != nil {
+		return nil, err
+	}
 
 	if err = setTags(instance, args.Tags); err != nil {
+		return nil, err
+	}
+
+	if args.DryRun {
+		return instance, nil
+	}
+
+	if err = m.cli.Update(ctx, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+func (m *k8sRpaasManager) UpdateInstanceMetadata(ctx context.Context, instanceName string, args UpdateInstanceMetadataArgs) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
 		return err
 	}
 
+	if args.Description != nil {
+		setDescription(instance, *args.Description)
+	}
+
+	if args.Tags != nil {
+		tags := append([]string{}, args.Tags...)
+		var planOverride string
+		parseTagArg(tags, "plan-override", &planOverride)
+		if current := currentPlanOverrideTag(instance); planOverride == "" && current != "" {
+			tags = append(tags, current)
+		}
+
+		if err = setTags(instance, tags); err != nil {
+			return err
+		}
+	}
+
 	return m.cli.Update(ctx, instance)
 }
 
+func (m *k8sRpaasManager) GetInstanceMetadata(ctx context.Context, instanceName string) (*InstanceMetadata, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InstanceMetadata{
+		Description: instance.Annotations[labelKey("description")],
+		Tags:        instanceTags(instance),
+		Team:        instance.Annotations[labelKey("team-owner")],
+		Owner:       instance.Annotations[labelKey("owner")],
+	}, nil
+}
+
+func (m *k8sRpaasManager) GetInstanceTags(ctx context.Context, instanceName string) (*InstanceTags, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := parseTags(instanceTags(instance))
+	return &tags, nil
+}
+
+// currentPlanOverrideTag returns the plan-override tag stored in the
+// instance annotations. As its JSON value may contain commas, the
+// annotation can't be simply split on them.
+func currentPlanOverrideTag(instance *v1alpha1.RpaasInstance) string {
+	const prefix = "plan-override="
+	tags := instance.Annotations[labelKey("tags")]
+	start := -1
+	for i := 0; start < 0; {
+		idx := strings.Index(tags[i:], prefix)
+		if idx < 0 {
+			return ""
+		}
+		if idx += i; idx == 0 || tags[idx-1] == ',' {
+			start = idx
+		}
+		i = idx + 1
+	}
+
+	rest := tags[start:]
+	for i, c := range rest {
+		if c == ',' && json.Valid([]byte(rest[len(prefix):i])) {
+			return rest[:i]
+		}
+	}
+	return rest
+}
+
 func (m *k8sRpaasManager) ensureNamespaceExists(ctx context.Context) (string, error) {
 	nsName := getServiceName()
 	ns := newNamespace(nsName)
@@ -150,12 +581,12 @@ func (m *k8sRpaasManager) DeleteBlock(ctx context.Context, instanceName, blockNa
 	}
 
 	if instance.Spec.Blocks == nil {
-		return NotFoundError{Msg: fmt.Sprintf("block %q not found", blockName)}
+		return &NotFoundError{Msg: fmt.Sprintf("block %q not found", blockName)}
 	}
 
 	blockType := v1alpha1.BlockType(blockName)
 	if _, ok := instance.Spec.Blocks[blockType]; !ok {
-		return NotFoundError{Msg: fmt.Sprintf("block %q not found", blockName)}
+		return &NotFoundError{Msg: fmt.Sprintf("block %q not found", blockName)}
 	}
 
 	delete(instance.Spec.Blocks, blockType)
@@ -185,481 +616,3285 @@ func (m *k8sRpaasManager) ListBlocks(ctx context.Context, instanceName string) (
 	return blocks, nil
 }
 
+func (m *k8sRpaasManager) GetAllowedBlocks(ctx context.Context) ([]string, error) {
+	var names []string
+	for _, bt := range allowedBlockTypes {
+		names = append(names, string(bt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func (m *k8sRpaasManager) UpdateBlock(ctx context.Context, instanceName string, block ConfigurationBlock) error {
-	instance, err := m.GetInstance(ctx, instanceName)
+	block, err := resolveBlock(block)
 	if err != nil {
 		return err
 	}
 
 	blockType := v1alpha1.BlockType(block.Name)
-	if !isBlockTypeAllowed(blockType) {
-		return ValidationError{Msg: fmt.Sprintf("block %q is not allowed", block.Name)}
-	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := m.GetInstance(ctx, instanceName)
+		if err != nil {
+			return err
+		}
 
-	if instance.Spec.Blocks == nil {
-		instance.Spec.Blocks = make(map[v1alpha1.BlockType]v1alpha1.Value)
-	}
+		if instance.Spec.Blocks == nil {
+			instance.Spec.Blocks = make(map[v1alpha1.BlockType]v1alpha1.Value)
+		}
 
-	instance.Spec.Blocks[blockType] = v1alpha1.Value{Value: block.Content}
+		instance.Spec.Blocks[blockType] = v1alpha1.Value{Value: block.Content}
 
-	return m.cli.Update(ctx, instance)
+		return m.cli.Update(ctx, instance)
+	})
 }
 
-func (m *k8sRpaasManager) Scale(ctx context.Context, instanceName string, replicas int32) error {
-	instance, err := m.GetInstance(ctx, instanceName)
-	if err != nil {
-		return err
+// UpdateBlockAcrossInstances sets the block on every instance in the
+// namespace matching the label selector, one at a time, collecting the
+// failures in the results instead of stopping at the first one.
+func (m *k8sRpaasManager) UpdateBlockAcrossInstances(ctx context.Context, selector string, block ConfigurationBlock) ([]InstanceBlockResult, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, &ValidationError{Msg: "label selector is required"}
 	}
-	if replicas < 0 {
-		return ValidationError{Msg: fmt.Sprintf("invalid replicas number: %d", replicas)}
+
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		return nil, &ValidationError{Msg: fmt.Sprintf("invalid label selector %q: %v", selector, err)}
 	}
-	instance.Spec.Replicas = &replicas
-	return m.cli.Update(ctx, instance)
-}
 
-func (m *k8sRpaasManager) UpdateCertificate(ctx context.Context, instanceName, name string, c tls.Certificate) error {
-	instance, err := m.GetInstance(ctx, instanceName)
+	// Rendering and validating the block once, an invalid block is
+	// reported as such rather than as a failure on every instance.
+	block, err = resolveBlock(block)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if name == "" {
-		name = v1alpha1.CertificateNameDefault
+	var list v1alpha1.RpaasInstanceList
+	if err = m.cli.List(ctx, &client.ListOptions{Namespace: namespaceName(), LabelSelector: labelSelector}, &list); err != nil {
+		return nil, err
 	}
 
-	var oldSecret corev1.Secret
-	if instance.Spec.Certificates != nil && instance.Spec.Certificates.SecretName != "" {
-		err = m.cli.Get(ctx, types.NamespacedName{
-			Name:      instance.Spec.Certificates.SecretName,
-			Namespace: instance.Namespace,
-		}, &oldSecret)
+	var names []string
+	for _, instance := range list.Items {
+		names = append(names, instance.Name)
+	}
+	sort.Strings(names)
+
+	results := make([]InstanceBlockResult, 0, len(names))
+	for _, name := range names {
+		result := InstanceBlockResult{Instance: name}
+		if err = m.UpdateBlock(ctx, name, block); err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
 
+// resolveBlock fills the block content from its snippet, if any, and checks
+// whether the block can be set on instances.
+func resolveBlock(block ConfigurationBlock) (ConfigurationBlock, error) {
+	if block.TemplateRef != nil {
+		if block.Content != "" {
+			return block, &ValidationError{Msg: "cannot set both content and template_ref"}
+		}
+		content, err := renderSnippet(*block.TemplateRef)
 		if err != nil {
-			return err
+			return block, err
 		}
+		block.Content = content
+		block.TemplateRef = nil
 	}
 
-	newSecretData := map[string][]byte{}
-	// copying the whole old secret's data to newSecretData to safely compare them after
-	for key, value := range oldSecret.Data {
-		newSecretData[key] = value
+	blockType := v1alpha1.BlockType(block.Name)
+	if !isBlockTypeAllowed(blockType) {
+		return block, &ValidationError{Msg: fmt.Sprintf("block %q is not allowed", block.Name)}
 	}
 
-	rawCertificate, rawKey, err := getRawCertificateAndKey(c)
-	if err != nil {
-		return err
+	if err := validateBlockDirectives(blockType, block.Content); err != nil {
+		return block, err
 	}
 
-	newCertificateField := fmt.Sprintf("%s.crt", name)
-	newKeyField := fmt.Sprintf("%s.key", name)
+	return block, nil
+}
 
-	newSecretData[newCertificateField] = rawCertificate
-	newSecretData[newKeyField] = rawKey
+// validateBlockDirectives applies the directive policy to the blocks holding
+// NGINX configuration. As the Lua code can't be checked against it, the Lua
+// blocks are only accepted by a policy permitting them explicitly.
+func validateBlockDirectives(blockType v1alpha1.BlockType, content string) error {
+	if blockType != v1alpha1.BlockTypeLuaServer && blockType != v1alpha1.BlockTypeLuaWorker {
+		return validateDirectives(content)
+	}
 
-	if reflect.DeepEqual(newSecretData, oldSecret.Data) {
-		return &ConflictError{Msg: fmt.Sprintf("certificate %q already is deployed", name)}
+	if err := nginxManager.ValidateLuaBlock(content); err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("could not parse the Lua code: %v", err)}
 	}
 
-	newSecret := newSecretForCertificates(*instance, newSecretData)
-	if err = m.cli.Create(ctx, newSecret); err != nil {
-		return err
-	}
-
-	if instance.Spec.Certificates == nil {
-		instance.Spec.Certificates = &nginxv1alpha1.TLSSecret{}
+	policy := config.Get().DirectivePolicy
+	if (len(policy.Allow) > 0 || len(policy.Deny) > 0) && !policy.LuaBlocks {
+		return &ValidationError{Msg: fmt.Sprintf("block %q is not allowed by the directive policy", blockType)}
 	}
 
-	instance.Spec.Certificates.SecretName = newSecret.Name
+	return nil
+}
 
-	isNewCertificate := true
-	for _, item := range instance.Spec.Certificates.Items {
-		if item.CertificateField == newCertificateField && item.KeyField == newKeyField {
-			isNewCertificate = false
+func renderSnippet(ref TemplateRef) (string, error) {
+	var snippet *config.SnippetConfig
+	snippets := config.Get().Snippets
+	for i := range snippets {
+		if snippets[i].Name == ref.Name {
+			snippet = &snippets[i]
 			break
 		}
 	}
-
-	if isNewCertificate {
-		instance.Spec.Certificates.Items = append(instance.Spec.Certificates.Items, nginxv1alpha1.TLSSecretItem{
-			CertificateField: newCertificateField,
-			KeyField:         newKeyField,
-		})
+	if snippet == nil {
+		return "", &ValidationError{Msg: fmt.Sprintf("snippet %q not found", ref.Name)}
 	}
 
-	return m.cli.Update(ctx, instance)
-}
-
-func (m *k8sRpaasManager) GetInstanceAddress(ctx context.Context, name string) (string, error) {
-	instance, err := m.GetInstance(ctx, name)
-	if err != nil {
-		return "", err
+	var missing []string
+	for _, param := range snippet.Parameters {
+		if _, ok := ref.Params[param]; !ok {
+			missing = append(missing, param)
+		}
 	}
-
-	var nginx nginxv1alpha1.Nginx
-	err = m.cli.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &nginx)
-	if err != nil && IsNotFoundError(err) {
-		return "", nil
+	if len(missing) > 0 {
+		return "", &ValidationError{Msg: fmt.Sprintf("missing parameters for snippet %q: %s", ref.Name, strings.Join(missing, ", "))}
 	}
 
+	tmpl, err := template.New(snippet.Name).Option("missingkey=error").Parse(snippet.Template)
 	if err != nil {
-		return "", err
+		return "", errors.Wrapf(err, "could not parse snippet %q", snippet.Name)
 	}
-
-	if len(nginx.Status.Services) == 0 {
-		return "", nil
+	var buffer bytes.Buffer
+	if err = tmpl.Execute(&buffer, ref.Params); err != nil {
+		return "", &ValidationError{Msg: fmt.Sprintf("could not render snippet %q: %s", ref.Name, err)}
 	}
+	return buffer.String(), nil
+}
 
-	svcName := nginx.Status.Services[0].Name
-	var svc corev1.Service
-	err = m.cli.Get(ctx, types.NamespacedName{Name: svcName, Namespace: instance.Namespace}, &svc)
+func (m *k8sRpaasManager) Scale(ctx context.Context, instanceName string, replicas int32) error {
+	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if replicas < 0 {
+		return &ValidationError{Msg: fmt.Sprintf("invalid replicas number: %d", replicas)}
 	}
+	instance.Spec.Replicas = &replicas
+	return m.cli.Update(ctx, instance)
+}
 
-	switch svc.Spec.Type {
-	case corev1.ServiceTypeLoadBalancer:
-		if len(svc.Status.LoadBalancer.Ingress) == 0 {
-			return "", nil
-		}
+var autoscaleMetricTypes = map[string]v1alpha1.AutoscaleMetricType{
+	"resource": v1alpha1.AutoscaleMetricTypeResource,
+	"pods":     v1alpha1.AutoscaleMetricTypePods,
+	"external": v1alpha1.AutoscaleMetricTypeExternal,
+}
 
-		return svc.Status.LoadBalancer.Ingress[0].IP, nil
-	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort:
-		return svc.Spec.ClusterIP, nil
+func (m *k8sRpaasManager) UpdateAutoscale(ctx context.Context, instanceName string, autoscale Autoscale) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
 	}
-
-	return "", nil
+	spec, err := newAutoscaleSpec(autoscale)
+	if err != nil {
+		return err
+	}
+	if instance.Spec.ScaleToZero != nil && keepsReplicas(spec) {
+		return &ValidationError{Msg: "autoscale min replicas must be zero while scale to zero is enabled"}
+	}
+	instance.Spec.Autoscale = spec
+	return m.cli.Update(ctx, instance)
 }
 
-func (m *k8sRpaasManager) GetInstance(ctx context.Context, name string) (*v1alpha1.RpaasInstance, error) {
-	list := &v1alpha1.RpaasInstanceList{}
-	listOpts := client.InNamespace(namespaceName()).
-		MatchingLabels(labelsForRpaasInstance(name))
-	err := m.cli.List(ctx, listOpts, list)
+const (
+	defaultScaleToZeroIdleTimeout = 900
+	minScaleToZeroIdleTimeout     = 60
+)
+
+func (m *k8sRpaasManager) UpdateScaleToZero(ctx context.Context, instanceName string, scaleToZero ScaleToZero) error {
+	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	// Let's filter the list again, field selector implementation is not always
-	// trustyworthy (mainly on tests). If it works correctly we're only
-	// iterating on 1 item at most, so no problem playing safe here.
-	for i := 0; i < len(list.Items); i++ {
-		if list.Items[i].Name != name {
-			list.Items[i] = list.Items[len(list.Items)-1]
-			list.Items = list.Items[:len(list.Items)-1]
-			i--
-		}
+	if !scaleToZero.Enabled {
+		instance.Spec.ScaleToZero = nil
+		return m.cli.Update(ctx, instance)
 	}
-	if len(list.Items) == 0 {
-		return nil, NotFoundError{Msg: fmt.Sprintf("rpaas instance %q not found", name)}
+	if scaleToZero.IdleTimeout != 0 && scaleToZero.IdleTimeout < minScaleToZeroIdleTimeout {
+		return &ValidationError{Msg: fmt.Sprintf("idle timeout must be at least %d seconds", minScaleToZeroIdleTimeout)}
 	}
-	if len(list.Items) > 1 {
-		return nil, ConflictError{Msg: fmt.Sprintf("multiple instances found for name %q: %#v", name, list.Items)}
+	if keepsReplicas(instance.Spec.Autoscale) {
+		return &ValidationError{Msg: "scale to zero is incompatible with autoscale min replicas greater than zero"}
 	}
-	return &list.Items[0], nil
+	idleTimeout := scaleToZero.IdleTimeout
+	if idleTimeout == 0 {
+		idleTimeout = defaultScaleToZeroIdleTimeout
+	}
+	instance.Spec.ScaleToZero = &v1alpha1.RpaasInstanceScaleToZeroSpec{IdleTimeoutSeconds: idleTimeout}
+	return m.cli.Update(ctx, instance)
 }
 
-func (m *k8sRpaasManager) GetPlans(ctx context.Context) ([]v1alpha1.RpaasPlan, error) {
-	var planList v1alpha1.RpaasPlanList
-	if err := m.cli.List(ctx, client.InNamespace(namespaceName()), &planList); err != nil {
-		return nil, err
+// keepsReplicas returns whether the autoscaler would keep at least one
+// replica running. The HPA defaults min replicas to 1 when it's unset.
+func keepsReplicas(autoscale *v1alpha1.RpaasInstanceAutoscaleSpec) bool {
+	if autoscale == nil {
+		return false
 	}
-
-	return planList.Items, nil
+	return autoscale.MinReplicas == nil || *autoscale.MinReplicas > 0
 }
 
-func (m *k8sRpaasManager) CreateExtraFiles(ctx context.Context, instanceName string, files ...File) error {
+func (m *k8sRpaasManager) UpdatePodDisruptionBudget(ctx context.Context, instanceName string, pdb PodDisruptionBudget) error {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
 	}
-	newData := map[string][]byte{}
-	oldExtraFiles, err := m.getExtraFiles(ctx, *instance)
-	if err != nil && !IsNotFoundError(err) {
+	spec, err := newPodDisruptionBudgetSpec(pdb, minimumReplicas(instance))
+	if err != nil {
 		return err
 	}
-	if oldExtraFiles != nil && oldExtraFiles.BinaryData != nil {
-		newData = oldExtraFiles.BinaryData
+	instance.Spec.PodDisruptionBudget = spec
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) DeletePodDisruptionBudget(ctx context.Context, instanceName string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
 	}
-	for _, file := range files {
-		if !isPathValid(file.Name) {
-			return &ValidationError{Msg: fmt.Sprintf("filename %q is not valid", file.Name)}
+	if instance.Spec.PodDisruptionBudget == nil {
+		return &NotFoundError{Msg: "pod disruption budget not found"}
+	}
+	instance.Spec.PodDisruptionBudget = nil
+	return m.cli.Update(ctx, instance)
+}
+
+// minimumReplicas returns the lowest number of replicas the instance may
+// run with, taking the autoscaler into account.
+func minimumReplicas(instance *v1alpha1.RpaasInstance) int32 {
+	replicas := int32(1)
+	if instance.Spec.Replicas != nil {
+		replicas = *instance.Spec.Replicas
+	}
+	if instance.Spec.Autoscale != nil && instance.Spec.Autoscale.MinReplicas != nil {
+		replicas = *instance.Spec.Autoscale.MinReplicas
+	}
+	return replicas
+}
+
+func newPodDisruptionBudgetSpec(pdb PodDisruptionBudget, replicas int32) (*v1alpha1.RpaasInstancePodDisruptionBudgetSpec, error) {
+	if (pdb.MinAvailable == "") == (pdb.MaxUnavailable == "") {
+		return nil, &ValidationError{Msg: "exactly one of min available or max unavailable is required"}
+	}
+	if replicas < 2 {
+		return nil, &ValidationError{Msg: "pod disruption budget requires at least 2 replicas"}
+	}
+	field, raw := "min available", pdb.MinAvailable
+	if pdb.MaxUnavailable != "" {
+		field, raw = "max unavailable", pdb.MaxUnavailable
+	}
+	value := intstr.Parse(raw)
+	if value.Type == intstr.String {
+		percent, err := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+		if err != nil || !strings.HasSuffix(value.StrVal, "%") {
+			return nil, &ValidationError{Msg: fmt.Sprintf("invalid %s %q: must be an integer or a percentage", field, raw)}
 		}
-		key := convertPathToConfigMapKey(file.Name)
-		if _, ok := newData[key]; ok {
-			return &ConflictError{Msg: fmt.Sprintf("file %q already exists", file.Name)}
+		if percent <= 0 || percent >= 100 {
+			return nil, &ValidationError{Msg: fmt.Sprintf("%s must be between 1%% and 99%%", field)}
 		}
-		newData[key] = file.Content
+	} else if value.IntVal <= 0 || value.IntVal >= replicas {
+		return nil, &ValidationError{Msg: fmt.Sprintf("%s must be between 1 and %d (replicas - 1)", field, replicas-1)}
 	}
-	newExtraFiles, err := m.createExtraFiles(ctx, *instance, newData)
-	if err != nil {
-		return err
+	if pdb.MinAvailable != "" {
+		return &v1alpha1.RpaasInstancePodDisruptionBudgetSpec{MinAvailable: &value}, nil
 	}
-	if instance.Spec.ExtraFiles == nil {
-		instance.Spec.ExtraFiles = &nginxv1alpha1.FilesRef{
-			Files: map[string]string{},
-		}
+	return &v1alpha1.RpaasInstancePodDisruptionBudgetSpec{MaxUnavailable: &value}, nil
+}
+
+func newAutoscaleSpec(autoscale Autoscale) (*v1alpha1.RpaasInstanceAutoscaleSpec, error) {
+	if autoscale.MaxReplicas <= 0 {
+		return nil, &ValidationError{Msg: "max replicas must be greater than zero"}
 	}
-	for _, file := range files {
-		key := convertPathToConfigMapKey(file.Name)
-		instance.Spec.ExtraFiles.Files[key] = file.Name
+	if autoscale.MinReplicas != nil && (*autoscale.MinReplicas < 0 || *autoscale.MinReplicas > autoscale.MaxReplicas) {
+		return nil, &ValidationError{Msg: "min replicas must be between zero and max replicas"}
 	}
-	instance.Spec.ExtraFiles.Name = newExtraFiles.Name
-	return m.cli.Update(ctx, instance)
+	if autoscale.CPU != nil && *autoscale.CPU <= 0 {
+		return nil, &ValidationError{Msg: "cpu target must be greater than zero"}
+	}
+	if autoscale.Memory != nil && *autoscale.Memory <= 0 {
+		return nil, &ValidationError{Msg: "memory target must be greater than zero"}
+	}
+	spec := &v1alpha1.RpaasInstanceAutoscaleSpec{
+		MaxReplicas:                       autoscale.MaxReplicas,
+		MinReplicas:                       autoscale.MinReplicas,
+		TargetCPUUtilizationPercentage:    autoscale.CPU,
+		TargetMemoryUtilizationPercentage: autoscale.Memory,
+	}
+	for _, metric := range autoscale.Metrics {
+		metricType, ok := autoscaleMetricTypes[strings.ToLower(metric.Type)]
+		if !ok {
+			return nil, &ValidationError{Msg: fmt.Sprintf("unknown metric type %q", metric.Type)}
+		}
+		if metric.Name == "" {
+			return nil, &ValidationError{Msg: "metric name is required"}
+		}
+		if metricType == v1alpha1.AutoscaleMetricTypeResource && metric.Name != string(corev1.ResourceCPU) && metric.Name != string(corev1.ResourceMemory) {
+			return nil, &ValidationError{Msg: fmt.Sprintf("unknown resource metric %q", metric.Name)}
+		}
+		if metricType == v1alpha1.AutoscaleMetricTypeResource && ((metric.Name == string(corev1.ResourceCPU) && autoscale.CPU != nil) || (metric.Name == string(corev1.ResourceMemory) && autoscale.Memory != nil)) {
+			return nil, &ValidationError{Msg: fmt.Sprintf("cannot set both the %s target and a %s resource metric", metric.Name, metric.Name)}
+		}
+		if metric.Target <= 0 {
+			return nil, &ValidationError{Msg: fmt.Sprintf("target of metric %q must be greater than zero", metric.Name)}
+		}
+		spec.Metrics = append(spec.Metrics, v1alpha1.RpaasInstanceAutoscaleMetric{
+			Type:   metricType,
+			Name:   metric.Name,
+			Target: metric.Target,
+		})
+	}
+	return spec, nil
 }
 
-func (m *k8sRpaasManager) DeleteExtraFiles(ctx context.Context, instanceName string, filenames ...string) error {
+func (m *k8sRpaasManager) GetAutoscaleStatus(ctx context.Context, instanceName string) (*AutoscaleStatus, error) {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	if instance.Spec.Autoscale == nil {
+		return nil, &NotFoundError{Msg: fmt.Sprintf("autoscale is not enabled on instance %q", instanceName)}
+	}
+	var hpa autoscalingv2beta2.HorizontalPodAutoscaler
+	err = m.cli.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &hpa)
 	if err != nil {
-		return err
+		if k8sErrors.IsNotFound(err) {
+			return nil, &NotFoundError{Msg: fmt.Sprintf("autoscale of instance %q not found", instanceName)}
+		}
+		return nil, err
 	}
-	newData := map[string][]byte{}
-	if extraFiles.BinaryData != nil {
-		newData = extraFiles.BinaryData
+	status := &AutoscaleStatus{
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
 	}
-	for _, filename := range filenames {
-		key := convertPathToConfigMapKey(filename)
-		if _, ok := newData[key]; !ok {
-			return &NotFoundError{Msg: fmt.Sprintf("file %q does not exist", filename)}
+	if hpa.Spec.MinReplicas != nil {
+		status.MinReplicas = *hpa.Spec.MinReplicas
+	}
+	currentByName := make(map[string]string)
+	for _, metric := range hpa.Status.CurrentMetrics {
+		if name, current, ok := metricStatusValue(metric); ok {
+			currentByName[name] = formatMetricValueStatus(current)
 		}
-		delete(newData, key)
 	}
-	if len(newData) == 0 {
-		instance.Spec.ExtraFiles = nil
-		return m.cli.Update(ctx, instance)
+	for _, metric := range hpa.Spec.Metrics {
+		name, target, ok := metricSpecTarget(metric)
+		if !ok {
+			continue
+		}
+		status.Metrics = append(status.Metrics, AutoscaleMetricStatus{
+			Name:    name,
+			Current: currentByName[name],
+			Target:  formatMetricTarget(target),
+		})
 	}
-	extraFiles, err = m.createExtraFiles(ctx, *instance, newData)
-	if err != nil && k8sErrors.IsAlreadyExists(err) {
-		return ConflictError{Msg: "extra files already is defined"}
+	return status, nil
+}
+
+func metricSpecTarget(metric autoscalingv2beta2.MetricSpec) (string, autoscalingv2beta2.MetricTarget, bool) {
+	switch {
+	case metric.Type == autoscalingv2beta2.ResourceMetricSourceType && metric.Resource != nil:
+		return string(metric.Resource.Name), metric.Resource.Target, true
+	case metric.Type == autoscalingv2beta2.PodsMetricSourceType && metric.Pods != nil:
+		return metric.Pods.Metric.Name, metric.Pods.Target, true
+	case metric.Type == autoscalingv2beta2.ExternalMetricSourceType && metric.External != nil:
+		return metric.External.Metric.Name, metric.External.Target, true
+	}
+	return "", autoscalingv2beta2.MetricTarget{}, false
+}
+
+func metricStatusValue(metric autoscalingv2beta2.MetricStatus) (string, autoscalingv2beta2.MetricValueStatus, bool) {
+	switch {
+	case metric.Type == autoscalingv2beta2.ResourceMetricSourceType && metric.Resource != nil:
+		return string(metric.Resource.Name), metric.Resource.Current, true
+	case metric.Type == autoscalingv2beta2.PodsMetricSourceType && metric.Pods != nil:
+		return metric.Pods.Metric.Name, metric.Pods.Current, true
+	case metric.Type == autoscalingv2beta2.ExternalMetricSourceType && metric.External != nil:
+		return metric.External.Metric.Name, metric.External.Current, true
+	}
+	return "", autoscalingv2beta2.MetricValueStatus{}, false
+}
+
+func formatMetricTarget(target autoscalingv2beta2.MetricTarget) string {
+	return formatMetricValueStatus(autoscalingv2beta2.MetricValueStatus{
+		Value:              target.Value,
+		AverageValue:       target.AverageValue,
+		AverageUtilization: target.AverageUtilization,
+	})
+}
+
+func formatMetricValueStatus(value autoscalingv2beta2.MetricValueStatus) string {
+	switch {
+	case value.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *value.AverageUtilization)
+	case value.AverageValue != nil:
+		return value.AverageValue.String()
+	case value.Value != nil:
+		return value.Value.String()
 	}
+	return ""
+}
+
+func (m *k8sRpaasManager) UpdateServerTokens(ctx context.Context, instanceName string, hide bool) error {
+	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
 	}
-	for _, filename := range filenames {
-		key := convertPathToConfigMapKey(filename)
-		delete(instance.Spec.ExtraFiles.Files, key)
-	}
-	instance.Spec.ExtraFiles.Name = extraFiles.Name
+	instance.Spec.HideServerTokens = v1alpha1.Bool(hide)
 	return m.cli.Update(ctx, instance)
 }
 
-func (m *k8sRpaasManager) GetExtraFiles(ctx context.Context, instanceName string) ([]File, error) {
+func (m *k8sRpaasManager) UpdateProxyProtocol(ctx context.Context, instanceName string, enabled bool) error {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	extraFiles, err := m.getExtraFiles(ctx, *instance)
-	if err != nil && IsNotFoundError(err) {
-		return []File{}, nil
+	if enabled && (instance.Spec.Service == nil || instance.Spec.Service.Type != corev1.ServiceTypeLoadBalancer) {
+		return &ValidationError{Msg: "proxy protocol requires a service of type LoadBalancer"}
 	}
-	if err != nil {
-		return nil, err
+	instance.Spec.ProxyProtocol = enabled
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) UpdateExternalHostname(ctx context.Context, instanceName, hostname string) error {
+	if hostname != "" {
+		if errs := validation.IsDNS1123Subdomain(hostname); len(errs) > 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid external hostname %q: %s", hostname, strings.Join(errs, "; "))}
+		}
 	}
-	files := []File{}
-	for key, path := range instance.Spec.ExtraFiles.Files {
-		files = append(files, File{
-			Name:    path,
-			Content: extraFiles.BinaryData[key],
-		})
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
 	}
-	return files, nil
+	instance.Spec.ExternalHostname = hostname
+	return m.cli.Update(ctx, instance)
 }
 
-func (m *k8sRpaasManager) UpdateExtraFiles(ctx context.Context, instanceName string, files ...File) error {
+func (m *k8sRpaasManager) UpdateRejectUnknownHosts(ctx context.Context, instanceName string, enabled bool) error {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
 	}
-	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	// Without a server name every host is unknown, so nothing would be served.
+	if enabled && (instance.Spec.Ingress == nil || instance.Spec.Ingress.Host == "") {
+		return &ValidationError{Msg: "rejecting unknown hosts requires an ingress host"}
+	}
+	instance.Spec.RejectUnknownHosts = enabled
+	return m.cli.Update(ctx, instance)
+}
+
+var requestIDHeaderRegexp = regexp.MustCompile(`(?i)proxy_set_header\s+["']?x-request-id\b`)
+
+func (m *k8sRpaasManager) UpdateRequestID(ctx context.Context, instanceName string, enabled bool) error {
+	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
 	}
-	newData := map[string][]byte{}
-	if extraFiles.BinaryData != nil {
-		newData = extraFiles.BinaryData
+	if enabled {
+		if err = validateRequestIDConflicts(instance); err != nil {
+			return err
+		}
 	}
-	for _, file := range files {
-		key := convertPathToConfigMapKey(file.Name)
-		if _, ok := newData[key]; !ok {
-			return &NotFoundError{Msg: fmt.Sprintf("file %q does not exist", file.Name)}
+	instance.Spec.RequestID = enabled
+	return m.cli.Update(ctx, instance)
+}
+
+// validateRequestIDConflicts rejects enabling the request ID on instances
+// whose blocks or routes already set the X-Request-ID header themselves.
+func validateRequestIDConflicts(instance *v1alpha1.RpaasInstance) error {
+	var blockTypes []string
+	for blockType := range instance.Spec.Blocks {
+		blockTypes = append(blockTypes, string(blockType))
+	}
+	sort.Strings(blockTypes)
+
+	for _, blockType := range blockTypes {
+		if requestIDHeaderRegexp.MatchString(instance.Spec.Blocks[v1alpha1.BlockType(blockType)].Value) {
+			return &ValidationError{Msg: fmt.Sprintf("request ID conflicts with the X-Request-ID header set by the %q block", blockType)}
 		}
-		newData[key] = file.Content
 	}
-	extraFiles, err = m.createExtraFiles(ctx, *instance, newData)
-	if err != nil && k8sErrors.IsAlreadyExists(err) {
-		return ConflictError{Msg: "extra files already is defined"}
+
+	for _, location := range instance.Spec.Locations {
+		if location.Content != nil && requestIDHeaderRegexp.MatchString(location.Content.Value) {
+			return &ValidationError{Msg: fmt.Sprintf("request ID conflicts with the X-Request-ID header set by the route %q", location.Path)}
+		}
 	}
+
+	return nil
+}
+
+func (m *k8sRpaasManager) UpdateHTTP3(ctx context.Context, instanceName string, enabled bool) error {
+	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
 	}
-	instance.Spec.ExtraFiles.Name = extraFiles.Name
+	if enabled {
+		if !config.Get().HTTP3Enabled {
+			return &ValidationError{Msg: "HTTP/3 is not supported by the NGINX image"}
+		}
+		if !hasCertificate(*instance, v1alpha1.CertificateNameDefault) {
+			return &ValidationError{Msg: "HTTP/3 requires the default certificate to be set"}
+		}
+	}
+	instance.Spec.HTTP3 = enabled
 	return m.cli.Update(ctx, instance)
 }
 
-func (m *k8sRpaasManager) BindApp(ctx context.Context, instanceName string, args BindAppArgs) error {
+func (m *k8sRpaasManager) UpdateHealthCheck(ctx context.Context, instanceName, path string, status int) error {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
 	}
+	if path != "" {
+		if !strings.HasPrefix(path, "/") || !isPathValid(strings.TrimPrefix(path, "/")) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid health check path %q", path)}
+		}
+		for _, location := range instance.Spec.Locations {
+			if location.Path == path {
+				return &ValidationError{Msg: fmt.Sprintf("health check path %q conflicts with an existing route", path)}
+			}
+		}
+	}
+	// Probes only succeed on 2xx and 3xx responses.
+	if status != 0 && (status < 200 || status > 399) {
+		return &ValidationError{Msg: "health check status must be between 200 and 399"}
+	}
+	instance.Spec.HealthCheckPath = path
+	instance.Spec.HealthCheckStatus = status
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) UpdateStatusCallback(ctx context.Context, instanceName, callbackURL string) error {
+	if callbackURL != "" {
+		u, err := url.Parse(callbackURL)
+		if err != nil || u.Host == "" {
+			return &ValidationError{Msg: fmt.Sprintf("invalid status callback URL %q", callbackURL)}
+		}
+		if u.Scheme != "https" {
+			return &ValidationError{Msg: "status callback URL must use https"}
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := m.GetInstance(ctx, instanceName)
+		if err != nil {
+			return err
+		}
+		instance.Spec.StatusCallbackURL = callbackURL
+		return m.cli.Update(ctx, instance)
+	})
+}
+
+var mimeTypeRegexp = regexp.MustCompile(`^[a-zA-Z0-9.+-]+/[a-zA-Z0-9.+*-]+$`)
+
+func (m *k8sRpaasManager) UpdateCompression(ctx context.Context, instanceName string, compression Compression) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	if err = validateCompression(compression); err != nil {
+		return err
+	}
+	instance.Spec.Compression = &v1alpha1.RpaasInstanceCompressionSpec{
+		Enabled:   compression.Enabled,
+		MinLength: compression.MinLength,
+		MimeTypes: compression.MimeTypes,
+		Brotli:    compression.Brotli,
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+func validateCompression(compression Compression) error {
+	if compression.MinLength < 0 {
+		return &ValidationError{Msg: "compression min length must be greater than or equal to zero"}
+	}
+	if !compression.Enabled {
+		if compression.Brotli {
+			return &ValidationError{Msg: "brotli requires compression to be enabled"}
+		}
+		return nil
+	}
+	if len(compression.MimeTypes) == 0 {
+		return &ValidationError{Msg: "at least one MIME type is required when compression is enabled"}
+	}
+	for _, mimeType := range compression.MimeTypes {
+		if !mimeTypeRegexp.MatchString(mimeType) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid MIME type %q", mimeType)}
+		}
+	}
+	return nil
+}
+
+// maxAccessLogSampleRate keeps the sampled percentage representable by
+// NGINX's split_clients, which supports two decimal places.
+const maxAccessLogSampleRate = 10000
+
+func (m *k8sRpaasManager) UpdateAccessLog(ctx context.Context, instanceName string, accessLog AccessLog) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	if accessLog.SampleRate < 0 {
+		return &ValidationError{Msg: "access log sample rate must be a positive integer"}
+	}
+	if accessLog.SampleRate > maxAccessLogSampleRate {
+		return &ValidationError{Msg: fmt.Sprintf("access log sample rate must not be greater than %d", maxAccessLogSampleRate)}
+	}
+	if accessLog.SampleRate <= 1 && !accessLog.ExcludeSuccessful {
+		instance.Spec.AccessLog = nil
+	} else {
+		instance.Spec.AccessLog = &v1alpha1.RpaasInstanceAccessLogSpec{
+			SampleRate:        int32(accessLog.SampleRate),
+			ExcludeSuccessful: accessLog.ExcludeSuccessful,
+		}
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+const defaultRateLimitZoneSize = "10m"
+
+var zoneSizeRegexp = regexp.MustCompile(`^[1-9][0-9]*[kKmM]?$`)
+
+func (m *k8sRpaasManager) UpdateRateLimit(ctx context.Context, instanceName string, rateLimit RateLimit) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	if rateLimit.RequestsPerSecond < 0 {
+		return &ValidationError{Msg: "requests per second must be a positive integer"}
+	}
+	if rateLimit.Burst < 0 {
+		return &ValidationError{Msg: "burst must be a positive integer"}
+	}
+	if rateLimit.Connections < 0 {
+		return &ValidationError{Msg: "connections must be a positive integer"}
+	}
+	if rateLimit.Burst > 0 && rateLimit.RequestsPerSecond == 0 {
+		return &ValidationError{Msg: "burst requires requests per second to be set"}
+	}
+	if rateLimit.ZoneSize != "" && !zoneSizeRegexp.MatchString(rateLimit.ZoneSize) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid zone size %q: must be a positive size (e.g. \"10m\")", rateLimit.ZoneSize)}
+	}
+	if rateLimit.RequestsPerSecond == 0 && rateLimit.Connections == 0 {
+		instance.Spec.RateLimit = nil
+		return m.cli.Update(ctx, instance)
+	}
+	zoneSize := rateLimit.ZoneSize
+	if zoneSize == "" {
+		zoneSize = defaultRateLimitZoneSize
+	}
+	instance.Spec.RateLimit = &v1alpha1.RpaasInstanceRateLimitSpec{
+		ZoneSize:          zoneSize,
+		RequestsPerSecond: int32(rateLimit.RequestsPerSecond),
+		Burst:             int32(rateLimit.Burst),
+		Connections:       int32(rateLimit.Connections),
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+// RestartInstance triggers a rolling restart of the instance's pods by
+// changing an annotation on the pod template.
+func (m *k8sRpaasManager) RestartInstance(ctx context.Context, instanceName string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	if instance.Spec.PodTemplate.Annotations == nil {
+		instance.Spec.PodTemplate.Annotations = make(map[string]string)
+	}
+	instance.Spec.PodTemplate.Annotations[labelKey("restarted-at")] = time.Now().UTC().Format(time.RFC3339)
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) SyncInstance(ctx context.Context, instanceName string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	// Any change on the instance triggers its reconciliation.
+	instance.Annotations = mergeMap(instance.Annotations, map[string]string{
+		labelKey("reconcile-requested"): time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) RepairLabels(ctx context.Context, instanceName string) error {
+	// GetInstance selects the instance by the very labels which may be
+	// missing, so it's fetched by name instead.
+	var instance v1alpha1.RpaasInstance
+	err := m.cli.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: namespaceName()}, &instance)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return &NotFoundError{Msg: fmt.Sprintf("rpaas instance %q not found", instanceName)}
+	}
+	if err != nil {
+		return err
+	}
+	repairLabels(&instance)
+	return m.cli.Update(ctx, &instance)
+}
+
+// repairLabels re-applies the labels set on creation to the instance, its
+// Service and pod template, keeping any other label.
+func repairLabels(instance *v1alpha1.RpaasInstance) {
+	labels := labelsForRpaasInstance(instance.Name)
+	if team := instance.Annotations[labelKey("team-owner")]; team != "" {
+		labels[labelKey("team-owner")] = team
+	}
+	instance.Labels = mergeMap(instance.Labels, labels)
+	instance.Spec.PodTemplate.Labels = mergeMap(instance.Spec.PodTemplate.Labels, labels)
+	if instance.Spec.Service != nil {
+		instance.Spec.Service.Labels = mergeMap(instance.Spec.Service.Labels, labels)
+	}
+}
+
+func (m *k8sRpaasManager) SetTeam(ctx context.Context, instanceName, team string) error {
+	if team == "" {
+		return &ValidationError{Msg: "team name is required"}
+	}
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	setTeamOwner(instance, team)
+	// On creation, the Service shares the instance labels, so they're kept
+	// in sync here too.
+	if instance.Spec.Service != nil {
+		instance.Spec.Service.Labels = mergeMap(instance.Spec.Service.Labels, map[string]string{
+			labelKey("team-owner"): team,
+		})
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) UpdateCertificate(ctx context.Context, instanceName, name string, c tls.Certificate, strict bool) ([]string, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = v1alpha1.CertificateNameDefault
+	}
+
+	warnings, err := validateCertificate(*instance, name, c, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	oldSecretData, err := m.getCertificatesSecretData(ctx, *instance)
+	if err != nil {
+		return nil, err
+	}
+
+	newSecretData := map[string][]byte{}
+	// copying the whole old secret's data to newSecretData to safely compare them after
+	for key, value := range oldSecretData {
+		newSecretData[key] = value
+	}
+
+	rawCertificate, rawKey, err := getRawCertificateAndKey(c)
+	if err != nil {
+		return nil, err
+	}
+
+	newCertificateField, newKeyField := certificateFields(name)
+	newSecretData[newCertificateField] = rawCertificate
+	newSecretData[newKeyField] = rawKey
+
+	if reflect.DeepEqual(newSecretData, oldSecretData) {
+		return nil, &ConflictError{Msg: fmt.Sprintf("certificate %q already is deployed", name)}
+	}
+
+	if err = m.storeCertificates(ctx, *instance, newSecretData, []string{name}); err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+}
+
+func (m *k8sRpaasManager) DeriveCertificateName(ctx context.Context, instanceName string, c tls.Certificate) (string, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return "", err
+	}
+
+	return deriveCertificateName(*instance, c)
+}
+
+// deriveCertificateName names the certificate after its primary DNS name,
+// e.g. "*.example.com" becomes "wildcard-example-com", appending a numeric
+// suffix when the instance already has a certificate with that name. It
+// falls back to the default name when the certificate has no DNS name.
+func deriveCertificateName(instance v1alpha1.RpaasInstance, c tls.Certificate) (string, error) {
+	dnsNames, err := certificateDNSNames(c)
+	if err != nil {
+		return "", err
+	}
+
+	var base string
+	if len(dnsNames) > 0 {
+		base = sanitizeCertificateName(dnsNames[0])
+	}
+
+	if base == "" {
+		return v1alpha1.CertificateNameDefault, nil
+	}
+
+	existing := make(map[string]bool)
+	if instance.Spec.Certificates != nil {
+		for _, item := range instance.Spec.Certificates.Items {
+			existing[strings.ToLower(strings.TrimSuffix(item.CertificateField, ".crt"))] = true
+		}
+	}
+
+	name := base
+	for i := 2; existing[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return name, nil
+}
+
+var invalidCertificateNameCharsRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+
+func sanitizeCertificateName(dnsName string) string {
+	name := strings.ToLower(strings.TrimSuffix(dnsName, "."))
+	name = strings.Replace(name, "*", "wildcard", -1)
+	name = invalidCertificateNameCharsRegexp.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
+
+func (m *k8sRpaasManager) UpdateCertificates(ctx context.Context, instanceName string, certs []CertificateData, strict bool) ([]CertificateResult, error) {
+	if len(certs) == 0 {
+		return nil, &ValidationError{Msg: "at least one certificate must be provided"}
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(certs))
+	seen := make(map[string]string)
+	for i, cert := range certs {
+		names[i] = cert.Name
+		if names[i] == "" {
+			names[i] = v1alpha1.CertificateNameDefault
+		}
+
+		// Certificate names are compared case-insensitively, as done by
+		// validateCertificateName against the existing ones.
+		if other, ok := seen[strings.ToLower(names[i])]; ok {
+			return nil, &ValidationError{Msg: fmt.Sprintf("certificate name %q collides with %q in the batch", names[i], other)}
+		}
+		seen[strings.ToLower(names[i])] = names[i]
+	}
+
+	oldSecretData, err := m.getCertificatesSecretData(ctx, *instance)
+	if err != nil {
+		return nil, err
+	}
+
+	newSecretData := map[string][]byte{}
+	for key, value := range oldSecretData {
+		newSecretData[key] = value
+	}
+
+	var stored []string
+	results := make([]CertificateResult, len(certs))
+	for i, cert := range certs {
+		results[i].Name = names[i]
+
+		rawCertificate, rawKey, warnings, err := encodeCertificate(*instance, names[i], cert, strict)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		results[i].Warnings = warnings
+		certificateField, keyField := certificateFields(names[i])
+		if bytes.Equal(oldSecretData[certificateField], rawCertificate) && bytes.Equal(oldSecretData[keyField], rawKey) {
+			results[i].Error = fmt.Sprintf("certificate %q already is deployed", names[i])
+			continue
+		}
+
+		newSecretData[certificateField] = rawCertificate
+		newSecretData[keyField] = rawKey
+		stored = append(stored, names[i])
+	}
+
+	if len(stored) == 0 {
+		return results, nil
+	}
+
+	if err = m.storeCertificates(ctx, *instance, newSecretData, stored); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// encodeCertificate parses and validates a certificate of a batch, returning
+// its certificate chain and key as stored in the Secret.
+func encodeCertificate(instance v1alpha1.RpaasInstance, name string, cert CertificateData, strict bool) ([]byte, []byte, []string, error) {
+	c, err := ParseCertificate([]byte(cert.Certificate), []byte(cert.Key))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	warnings, err := validateCertificate(instance, name, c, strict)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	rawCertificate, rawKey, err := getRawCertificateAndKey(c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return rawCertificate, rawKey, warnings, nil
+}
+
+// validateCertificate checks the certificate name and whether the
+// certificate covers the instance host, returning the mismatch as a warning
+// unless in strict mode.
+func validateCertificate(instance v1alpha1.RpaasInstance, name string, c tls.Certificate, strict bool) ([]string, error) {
+	if err := validateCertificateName(instance, name); err != nil {
+		return nil, err
+	}
+
+	if instance.Spec.Host == "" {
+		return nil, nil
+	}
+
+	covered, err := certificateCoversHost(c, instance.Spec.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if covered {
+		return nil, nil
+	}
+
+	msg := fmt.Sprintf("certificate %q does not cover the instance host %q", name, instance.Spec.Host)
+	if strict {
+		return nil, &ValidationError{Msg: msg}
+	}
+
+	return []string{msg}, nil
+}
+
+func certificateFields(name string) (string, string) {
+	return fmt.Sprintf("%s.crt", name), fmt.Sprintf("%s.key", name)
+}
+
+func (m *k8sRpaasManager) getCertificatesSecretData(ctx context.Context, instance v1alpha1.RpaasInstance) (map[string][]byte, error) {
+	if instance.Spec.Certificates == nil || instance.Spec.Certificates.SecretName == "" {
+		return nil, nil
+	}
+
+	var secret corev1.Secret
+	err := m.cli.Get(ctx, types.NamespacedName{
+		Name:      instance.Spec.Certificates.SecretName,
+		Namespace: instance.Namespace,
+	}, &secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.Data, nil
+}
+
+// storeCertificates creates a Secret with the certificates data and points
+// the instance to it, adding the named certificates not listed yet.
+func (m *k8sRpaasManager) storeCertificates(ctx context.Context, instance v1alpha1.RpaasInstance, data map[string][]byte, names []string) error {
+	newSecret := newSecretForCertificates(instance, data)
+	if err := m.cli.Create(ctx, newSecret); err != nil {
+		return err
+	}
+
+	// The secret is only created once, retries just re-read the instance to
+	// point it to the new secret.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := m.GetInstance(ctx, instance.Name)
+		if err != nil {
+			return err
+		}
+
+		if instance.Spec.Certificates == nil {
+			instance.Spec.Certificates = &nginxv1alpha1.TLSSecret{}
+		}
+
+		instance.Spec.Certificates.SecretName = newSecret.Name
+
+		for _, name := range names {
+			certificateField, keyField := certificateFields(name)
+
+			isNewCertificate := true
+			for _, item := range instance.Spec.Certificates.Items {
+				if item.CertificateField == certificateField && item.KeyField == keyField {
+					isNewCertificate = false
+					break
+				}
+			}
+
+			if isNewCertificate {
+				instance.Spec.Certificates.Items = append(instance.Spec.Certificates.Items, nginxv1alpha1.TLSSecretItem{
+					CertificateField: certificateField,
+					KeyField:         keyField,
+				})
+			}
+		}
+
+		return m.cli.Update(ctx, instance)
+	})
+}
+
+func (m *k8sRpaasManager) ListCertificateNames(ctx context.Context, instanceName string) ([]string, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	if instance.Spec.Certificates == nil {
+		return names, nil
+	}
+
+	for _, item := range instance.Spec.Certificates.Items {
+		names = append(names, strings.TrimSuffix(item.CertificateField, ".crt"))
+	}
+
+	return names, nil
+}
+
+func (m *k8sRpaasManager) GetCertificates(ctx context.Context, instanceName string) ([]CertificateInfo, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := []CertificateInfo{}
+	if instance.Spec.Certificates == nil {
+		return certificates, nil
+	}
+
+	defaultName := nginxManager.DefaultCertificateName(*instance)
+	for _, item := range instance.Spec.Certificates.Items {
+		name := strings.TrimSuffix(item.CertificateField, ".crt")
+		certificates = append(certificates, CertificateInfo{Name: name, Default: name == defaultName})
+	}
+	return certificates, nil
+}
+
+func (m *k8sRpaasManager) UpdateDefaultCertificate(ctx context.Context, instanceName, name string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if name != "" && !hasCertificate(*instance, name) {
+		return &NotFoundError{Msg: fmt.Sprintf("certificate %q not found", name)}
+	}
+
+	// A single name, rather than a flag per certificate, ensures only one
+	// of them is the default.
+	instance.Spec.DefaultCertificate = name
+	return m.cli.Update(ctx, instance)
+}
+
+// clientAuthCAFileName is the extra file holding the CA bundle trusted to
+// issue client certificates. CA certificates aren't sensitive, so they're
+// kept along with the other extra files instead of in a Secret.
+const clientAuthCAFileName = "client-auth/ca.crt"
+
+func (m *k8sRpaasManager) UpdateClientAuth(ctx context.Context, instanceName string, clientAuth ClientAuth) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	verify := v1alpha1.ClientVerifyMode(clientAuth.Verify)
+	switch verify {
+	case "":
+		verify = v1alpha1.ClientVerifyRequired
+	case v1alpha1.ClientVerifyRequired, v1alpha1.ClientVerifyOptional:
+	default:
+		return &ValidationError{Msg: fmt.Sprintf("invalid verify mode %q: must be either required or optional", clientAuth.Verify)}
+	}
+
+	if err = validateCABundle([]byte(clientAuth.CABundle)); err != nil {
+		return err
+	}
+
+	if !hasCertificate(*instance, v1alpha1.CertificateNameDefault) {
+		return &ValidationError{Msg: "client authentication requires the default certificate to be set"}
+	}
+
+	if err = m.setExtraFile(ctx, instance, clientAuthCAFileName, []byte(clientAuth.CABundle)); err != nil {
+		return err
+	}
+
+	instance.Spec.ClientAuth = &v1alpha1.RpaasInstanceClientAuthSpec{
+		CAFile: clientAuthCAFileName,
+		Verify: verify,
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) DeleteClientAuth(ctx context.Context, instanceName string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if instance.Spec.ClientAuth == nil {
+		return &NotFoundError{Msg: "client authentication not found"}
+	}
+
+	if err = m.setExtraFile(ctx, instance, instance.Spec.ClientAuth.CAFile, nil); err != nil {
+		return err
+	}
+
+	instance.Spec.ClientAuth = nil
+	return m.cli.Update(ctx, instance)
+}
+
+// validateCABundle ensures the bundle holds only PEM-encoded certificates,
+// at least one.
+func validateCABundle(bundle []byte) error {
+	var count int
+	for rest := bytes.TrimSpace(bundle); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return &ValidationError{Msg: "invalid CA bundle: could not decode PEM data"}
+		}
+		if block.Type != "CERTIFICATE" {
+			return &ValidationError{Msg: fmt.Sprintf("invalid CA bundle: unexpected PEM block %q", block.Type)}
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return &ValidationError{Msg: fmt.Sprintf("invalid CA bundle: %v", err)}
+		}
+		count++
+	}
+	if count == 0 {
+		return &ValidationError{Msg: "CA bundle is required"}
+	}
+	return nil
+}
+
+// tlsDHParamFileName is the extra file holding the DH parameters. Like the
+// client CA bundle, DH parameters are public and the Nginx resource only
+// mounts certificate and key pairs from Secrets, so they're kept along with
+// the other extra files.
+const tlsDHParamFileName = "tls/dhparam.pem"
+
+const minDHParamBits = 2048
+
+var knownECDHCurves = map[string]bool{
+	"X25519":     true,
+	"X448":       true,
+	"prime256v1": true,
+	"secp384r1":  true,
+	"secp521r1":  true,
+	"P-256":      true,
+	"P-384":      true,
+	"P-521":      true,
+}
+
+func (m *k8sRpaasManager) GetTLSConfig(ctx context.Context, instanceName string) (*TLSConfig, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &TLSConfig{}
+	if instance.Spec.TLS == nil {
+		return tlsConfig, nil
+	}
+
+	tlsConfig.ECDHCurves = instance.Spec.TLS.ECDHCurves
+	if instance.Spec.TLS.DHParamFile != "" {
+		extraFiles, err := m.getExtraFiles(ctx, *instance)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.DHParam = string(extraFiles.BinaryData[convertPathToConfigMapKey(instance.Spec.TLS.DHParamFile)])
+	}
+	return tlsConfig, nil
+}
+
+func (m *k8sRpaasManager) UpdateTLSConfig(ctx context.Context, instanceName string, tlsConfig TLSConfig) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if tlsConfig.DHParam == "" && len(tlsConfig.ECDHCurves) == 0 {
+		return &ValidationError{Msg: "either DH parameters or ECDH curves are required"}
+	}
+
+	if tlsConfig.DHParam != "" {
+		if err = validateDHParam([]byte(tlsConfig.DHParam)); err != nil {
+			return err
+		}
+	}
+
+	if err = validateECDHCurves(tlsConfig.ECDHCurves); err != nil {
+		return err
+	}
+
+	spec := &v1alpha1.RpaasInstanceTLSSpec{ECDHCurves: tlsConfig.ECDHCurves}
+	if tlsConfig.DHParam != "" {
+		spec.DHParamFile = tlsDHParamFileName
+		err = m.setExtraFile(ctx, instance, tlsDHParamFileName, []byte(tlsConfig.DHParam))
+	} else if instance.Spec.TLS != nil && instance.Spec.TLS.DHParamFile != "" {
+		err = m.setExtraFile(ctx, instance, instance.Spec.TLS.DHParamFile, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	instance.Spec.TLS = spec
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) DeleteTLSConfig(ctx context.Context, instanceName string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if instance.Spec.TLS == nil {
+		return &NotFoundError{Msg: "TLS config not found"}
+	}
+
+	if instance.Spec.TLS.DHParamFile != "" {
+		if err = m.setExtraFile(ctx, instance, instance.Spec.TLS.DHParamFile, nil); err != nil {
+			return err
+		}
+	}
+
+	instance.Spec.TLS = nil
+	return m.cli.Update(ctx, instance)
+}
+
+// validateDHParam ensures the content holds a single PEM-encoded DH
+// parameters block, with a prime of at least minDHParamBits bits.
+func validateDHParam(content []byte) error {
+	block, rest := pem.Decode(bytes.TrimSpace(content))
+	if block == nil || len(bytes.TrimSpace(rest)) > 0 {
+		return &ValidationError{Msg: "invalid DH parameters: could not decode PEM data"}
+	}
+	if block.Type != "DH PARAMETERS" {
+		return &ValidationError{Msg: fmt.Sprintf("invalid DH parameters: unexpected PEM block %q", block.Type)}
+	}
+
+	var params struct {
+		P      *big.Int
+		G      *big.Int
+		Length int `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(block.Bytes, &params); err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("invalid DH parameters: %v", err)}
+	}
+	if params.G.Cmp(big.NewInt(1)) <= 0 || !params.P.ProbablyPrime(20) {
+		return &ValidationError{Msg: "invalid DH parameters: prime or generator is not valid"}
+	}
+	if bits := params.P.BitLen(); bits < minDHParamBits {
+		return &ValidationError{Msg: fmt.Sprintf("DH parameters of %d bits are too weak: at least %d bits are required", bits, minDHParamBits)}
+	}
+	return nil
+}
+
+// validateECDHCurves ensures the curves are known by NGINX, where "auto"
+// lets OpenSSL pick them and so can't be combined with others.
+func validateECDHCurves(curves []string) error {
+	if len(curves) == 1 && curves[0] == "auto" {
+		return nil
+	}
+	seen := make(map[string]bool, len(curves))
+	for _, curve := range curves {
+		if curve == "auto" {
+			return &ValidationError{Msg: "ECDH curve \"auto\" cannot be combined with other curves"}
+		}
+		if !knownECDHCurves[curve] {
+			return &ValidationError{Msg: fmt.Sprintf("unknown ECDH curve %q", curve)}
+		}
+		if seen[curve] {
+			return &ValidationError{Msg: fmt.Sprintf("ECDH curve %q is duplicated", curve)}
+		}
+		seen[curve] = true
+	}
+	return nil
+}
+
+// hasCertificate tells whether the instance holds a certificate with the
+// given name.
+func hasCertificate(instance v1alpha1.RpaasInstance, name string) bool {
+	if instance.Spec.Certificates == nil {
+		return false
+	}
+	for _, item := range instance.Spec.Certificates.Items {
+		if item.CertificateField == name+".crt" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasExtraFile(instance v1alpha1.RpaasInstance, name string) bool {
+	if instance.Spec.ExtraFiles == nil {
+		return false
+	}
+	_, found := instance.Spec.ExtraFiles.Files[convertPathToConfigMapKey(name)]
+	return found
+}
+
+var certificateNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// validateCertificateName ensures name is safe to be used as a Secret key and
+// doesn't collide with another certificate of the instance, which would
+// otherwise overwrite its fields.
+// ParseCertificate loads a PEM-encoded certificate and key pair, returning a
+// ValidationError when they're not valid.
+func ParseCertificate(rawCertificate, rawKey []byte) (tls.Certificate, error) {
+	if block, _ := pem.Decode(rawCertificate); block == nil {
+		return tls.Certificate{}, &ValidationError{Msg: "certificate file is not a valid PEM"}
+	}
+	if block, _ := pem.Decode(rawKey); block == nil {
+		return tls.Certificate{}, &ValidationError{Msg: "key file is not a valid PEM"}
+	}
+	certificate, err := tls.X509KeyPair(rawCertificate, rawKey)
+	if err != nil {
+		return tls.Certificate{}, &ValidationError{Msg: fmt.Sprintf("could not load the given certificate and key: %s", err)}
+	}
+	return certificate, nil
+}
+
+func validateCertificateName(instance v1alpha1.RpaasInstance, name string) error {
+	if !certificateNameRegexp.MatchString(name) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid certificate name %q: must contain only alphanumeric characters or dashes", name)}
+	}
+	if instance.Spec.Certificates == nil {
+		return nil
+	}
+	for _, item := range instance.Spec.Certificates.Items {
+		existing := strings.TrimSuffix(item.CertificateField, ".crt")
+		if existing != name && strings.EqualFold(existing, name) {
+			return &ValidationError{Msg: fmt.Sprintf("certificate name %q collides with the existing certificate %q", name, existing)}
+		}
+	}
+	return nil
+}
+
+// certificateDNSNames returns the DNS SANs of the leaf certificate, or its
+// Common Name when there are no SANs.
+func certificateDNSNames(c tls.Certificate) ([]string, error) {
+	leaf := c.Leaf
+	if leaf == nil {
+		if len(c.Certificate) == 0 {
+			return nil, &ValidationError{Msg: "certificate chain is empty"}
+		}
+
+		var err error
+		if leaf, err = x509.ParseCertificate(c.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(leaf.DNSNames) == 0 && leaf.Subject.CommonName != "" {
+		return []string{leaf.Subject.CommonName}, nil
+	}
+
+	return leaf.DNSNames, nil
+}
+
+// certificateCoversHost checks whether the leaf certificate is valid for host,
+// looking up its DNS SANs (or the Common Name when there are no SANs) and
+// supporting wildcard names for a single label, e.g. "*.example.com".
+func certificateCoversHost(c tls.Certificate, host string) (bool, error) {
+	names, err := certificateDNSNames(c)
+	if err != nil {
+		return false, err
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, name := range names {
+		if matchCertificateName(strings.ToLower(strings.TrimSuffix(name, ".")), host) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchCertificateName(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+
+	index := strings.Index(host, ".")
+	if index <= 0 {
+		return false
+	}
+
+	return host[index:] == pattern[1:]
+}
+
+func (m *k8sRpaasManager) getIngressAddress(ctx context.Context, instance *v1alpha1.RpaasInstance) (string, error) {
+	var ingress extensionsv1beta1.Ingress
+	err := m.cli.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &ingress)
+	if err != nil && IsNotFoundError(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(ingress.Status.LoadBalancer.Ingress) == 0 {
+		return "", nil
+	}
+
+	if ip := ingress.Status.LoadBalancer.Ingress[0].IP; ip != "" {
+		return ip, nil
+	}
+
+	return ingress.Status.LoadBalancer.Ingress[0].Hostname, nil
+}
+
+func (m *k8sRpaasManager) GetInstanceResources(ctx context.Context, name string) (*InstanceResources, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	resources := &InstanceResources{}
+	for _, value := range instance.Spec.Blocks {
+		resources.BlocksConfigMaps = appendConfigMapRef(resources.BlocksConfigMaps, &value)
+	}
+	for _, location := range instance.Spec.Locations {
+		resources.LocationsConfigMaps = appendConfigMapRef(resources.LocationsConfigMaps, location.Content)
+	}
+	sort.Strings(resources.BlocksConfigMaps)
+	sort.Strings(resources.LocationsConfigMaps)
+	if instance.Spec.ExtraFiles != nil {
+		resources.ExtraFilesConfigMap = instance.Spec.ExtraFiles.Name
+	}
+	if instance.Spec.Certificates != nil {
+		resources.CertificatesSecret = instance.Spec.Certificates.SecretName
+	}
+	return resources, nil
+}
+
+func (m *k8sRpaasManager) GetInstancePlanResources(ctx context.Context, name string) (*corev1.ResourceRequirements, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := m.getPlan(ctx, instance.Spec.PlanName)
+	if err != nil {
+		return nil, err
+	}
+	resources := plan.Spec.Resources.DeepCopy()
+	if instance.Spec.PlanTemplate != nil {
+		resources.Requests = overrideResourceList(resources.Requests, instance.Spec.PlanTemplate.Resources.Requests)
+		resources.Limits = overrideResourceList(resources.Limits, instance.Spec.PlanTemplate.Resources.Limits)
+	}
+	return resources, nil
+}
+
+// overrideResourceList applies the quantities of override over base, just
+// like the controller merges the plan template.
+func overrideResourceList(base, override corev1.ResourceList) corev1.ResourceList {
+	if len(override) == 0 {
+		return base
+	}
+	if base == nil {
+		base = make(corev1.ResourceList)
+	}
+	for name, quantity := range override {
+		base[name] = quantity
+	}
+	return base
+}
+
+func appendConfigMapRef(refs []string, value *v1alpha1.Value) []string {
+	if value == nil || value.ValueFrom == nil || value.ValueFrom.ConfigMapKeyRef == nil {
+		return refs
+	}
+	ref := value.ValueFrom.ConfigMapKeyRef.Name
+	if value.ValueFrom.Namespace != "" {
+		ref = fmt.Sprintf("%s/%s", value.ValueFrom.Namespace, ref)
+	}
+	for _, r := range refs {
+		if r == ref {
+			return refs
+		}
+	}
+	return append(refs, ref)
+}
+
+func (m *k8sRpaasManager) ExportInstance(ctx context.Context, name string) (*InstanceExport, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := m.ListBlocks(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.GetRoutes(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := m.GetExtraFiles(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	certificates, err := m.ListCertificateNames(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := sanitizedSpec(instance.Spec)
+	if spec.Service != nil {
+		spec.Service.Labels = withoutInstanceLabels(spec.Service.Labels, name)
+	}
+	spec.PodTemplate.Labels = withoutInstanceLabels(spec.PodTemplate.Labels, name)
+
+	return &InstanceExport{
+		Name:         name,
+		Team:         instance.Annotations[labelKey("team-owner")],
+		Plan:         instance.Spec.PlanName,
+		Description:  instance.Annotations[labelKey("description")],
+		Tags:         instanceTags(instance),
+		Spec:         spec,
+		Blocks:       blocks,
+		Routes:       routes,
+		ExtraFiles:   files,
+		Certificates: certificates,
+	}, nil
+}
+
+func (m *k8sRpaasManager) ImportInstance(ctx context.Context, name string, export InstanceExport) error {
+	return m.restoreInstance(ctx, CreateArgs{
+		Name:        name,
+		Team:        export.Team,
+		Plan:        export.Plan,
+		Description: export.Description,
+		Tags:        export.Tags,
+	}, export, "")
+}
+
+func (m *k8sRpaasManager) CloneInstance(ctx context.Context, source string, args CloneArgs) error {
+	export, err := m.ExportInstance(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	if !args.IncludeAppHost {
+		export.Spec.Host = ""
+	}
+	// Two instances publishing the same DNS name would fight over it.
+	export.Spec.ExternalHostname = ""
+
+	createArgs := args.CreateArgs
+	if createArgs.Team == "" {
+		createArgs.Team = export.Team
+	}
+	if createArgs.Plan == "" {
+		createArgs.Plan = export.Plan
+	}
+	if createArgs.Description == "" {
+		createArgs.Description = export.Description
+	}
+	if createArgs.Tags == nil {
+		createArgs.Tags = export.Tags
+	}
+
+	var certificatesSource string
+	if args.IncludeCertificates {
+		certificatesSource = source
+	}
+
+	return m.restoreInstance(ctx, createArgs, *export, certificatesSource)
+}
+
+// restoreInstance creates an instance from args and then applies the
+// exported spec and configuration on it. When certificatesSource is set,
+// the certificates of that instance are copied before the routes are
+// restored, as they may reference them.
+func (m *k8sRpaasManager) restoreInstance(ctx context.Context, args CreateArgs, export InstanceExport, certificatesSource string) error {
+	if err := validateImport(export); err != nil {
+		return err
+	}
+
+	if _, err := m.CreateInstance(ctx, args); err != nil {
+		return err
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := m.GetInstance(ctx, args.Name)
+		if err != nil {
+			return err
+		}
+
+		// The labels pointing to the new instance must win over the
+		// exported ones, otherwise its Service and pods wouldn't be found.
+		spec := sanitizedSpec(export.Spec)
+		spec.PlanName = instance.Spec.PlanName
+		if spec.Service == nil {
+			spec.Service = instance.Spec.Service
+		} else if instance.Spec.Service != nil {
+			spec.Service.Labels = mergeMap(spec.Service.Labels, instance.Spec.Service.Labels)
+		}
+		spec.PodTemplate.Affinity = instance.Spec.PodTemplate.Affinity
+		spec.PodTemplate.Labels = mergeMap(spec.PodTemplate.Labels, instance.Spec.PodTemplate.Labels)
+		spec.PodTemplate.Annotations = mergeMap(spec.PodTemplate.Annotations, instance.Spec.PodTemplate.Annotations)
+
+		instance.Spec = spec
+		return m.cli.Update(ctx, instance)
+	})
+	if err != nil {
+		return err
+	}
+
+	if certificatesSource != "" {
+		if err = m.copyCertificates(ctx, certificatesSource, args.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, block := range export.Blocks {
+		if err = m.UpdateBlock(ctx, args.Name, block); err != nil {
+			return err
+		}
+	}
+
+	for _, route := range export.Routes {
+		route.Source = ""
+		if err = m.UpdateRoute(ctx, args.Name, route); err != nil {
+			return err
+		}
+	}
+
+	if len(export.ExtraFiles) > 0 {
+		return m.CreateExtraFiles(ctx, args.Name, export.ExtraFiles...)
+	}
+
+	return nil
+}
+
+// copyCertificates copies the certificates of the source instance into a new
+// Secret owned by the target one, so they don't share any resource.
+func (m *k8sRpaasManager) copyCertificates(ctx context.Context, source, target string) error {
+	sourceInstance, err := m.GetInstance(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	if sourceInstance.Spec.Certificates == nil || sourceInstance.Spec.Certificates.SecretName == "" {
+		return nil
+	}
+
+	var secret corev1.Secret
+	err = m.cli.Get(ctx, types.NamespacedName{
+		Name:      sourceInstance.Spec.Certificates.SecretName,
+		Namespace: sourceInstance.Namespace,
+	}, &secret)
+	if err != nil {
+		return err
+	}
+
+	targetInstance, err := m.GetInstance(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string][]byte, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+
+	newSecret := newSecretForCertificates(*targetInstance, data)
+	if err = m.cli.Create(ctx, newSecret); err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := m.GetInstance(ctx, target)
+		if err != nil {
+			return err
+		}
+
+		instance.Spec.Certificates = &nginxv1alpha1.TLSSecret{
+			SecretName: newSecret.Name,
+			Items:      append([]nginxv1alpha1.TLSSecretItem{}, sourceInstance.Spec.Certificates.Items...),
+		}
+		return m.cli.Update(ctx, instance)
+	})
+}
+
+// validateImport checks the exported instance before creating anything, so
+// an invalid export doesn't leave a partially imported instance behind.
+func validateImport(export InstanceExport) error {
+	if err := validateNodeSelector(export.Spec.NodeSelector); err != nil {
+		return err
+	}
+
+	if err := validateTopologySpreadConstraints(export.Spec.TopologySpreadConstraints); err != nil {
+		return err
+	}
+
+	if err := validateExtraPorts(export.Spec.ExtraPorts); err != nil {
+		return err
+	}
+
+	if err := validateEnv(export.Spec.Env); err != nil {
+		return err
+	}
+
+	for _, block := range export.Blocks {
+		if !isBlockTypeAllowed(v1alpha1.BlockType(block.Name)) {
+			return &ValidationError{Msg: fmt.Sprintf("block %q is not allowed", block.Name)}
+		}
+		if block.TemplateRef != nil && block.Content != "" {
+			return &ValidationError{Msg: "cannot set both content and template_ref"}
+		}
+	}
+
+	for _, route := range export.Routes {
+		if err := validateRoute(&v1alpha1.RpaasInstance{Spec: export.Spec}, route); err != nil {
+			return err
+		}
+	}
+
+	if err := validateRoutePaths(export.Routes); err != nil {
+		return err
+	}
+
+	for _, file := range export.ExtraFiles {
+		if !isPathValid(file.Name) {
+			return &ValidationError{Msg: fmt.Sprintf("filename %q is not valid", file.Name)}
+		}
+	}
+
+	return nil
+}
+
+// sanitizedSpec returns a copy of spec without the fields referencing
+// resources owned by the instance, which are exported on their own.
+func sanitizedSpec(spec v1alpha1.RpaasInstanceSpec) v1alpha1.RpaasInstanceSpec {
+	sanitized := spec.DeepCopy()
+	sanitized.Blocks = nil
+	sanitized.Locations = nil
+	sanitized.ExtraFiles = nil
+	sanitized.Certificates = nil
+	return *sanitized
+}
+
+// RedactedValue replaces the sensitive fields of redacted instance specs.
+const RedactedValue = "<redacted>"
+
+// RedactInstanceSpec returns a copy of the spec without the names of the
+// Secrets and ConfigMaps it refers to, so read-only clients don't learn
+// about internal objects of the cluster.
+func RedactInstanceSpec(spec v1alpha1.RpaasInstanceSpec) v1alpha1.RpaasInstanceSpec {
+	redacted := spec.DeepCopy()
+	if redacted.Certificates != nil {
+		redacted.Certificates.SecretName = RedactedValue
+	}
+	if redacted.ExtraFiles != nil {
+		redacted.ExtraFiles.Name = RedactedValue
+	}
+	if redacted.Ingress != nil && redacted.Ingress.TLSSecretName != "" {
+		redacted.Ingress.TLSSecretName = RedactedValue
+	}
+	for blockType, block := range redacted.Blocks {
+		redactValue(&block)
+		redacted.Blocks[blockType] = block
+	}
+	for i := range redacted.Locations {
+		if redacted.Locations[i].Content != nil {
+			redactValue(redacted.Locations[i].Content)
+		}
+	}
+	return *redacted
+}
+
+func redactValue(value *v1alpha1.Value) {
+	if value.ValueFrom == nil || value.ValueFrom.ConfigMapKeyRef == nil {
+		return
+	}
+	value.ValueFrom.ConfigMapKeyRef.Name = RedactedValue
+	value.ValueFrom.Namespace = ""
+}
+
+func withoutInstanceLabels(labels map[string]string, name string) map[string]string {
+	generated := labelsForRpaasInstance(name)
+	generated[labelKey("team-owner")] = ""
+
+	result := make(map[string]string)
+	for k, v := range labels {
+		if _, ok := generated[k]; !ok {
+			result[k] = v
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// instanceTags returns the tags stored in the instance annotations, keeping
+// the plan-override tag (whose value may contain commas) in one piece.
+func instanceTags(instance *v1alpha1.RpaasInstance) []string {
+	raw := instance.Annotations[labelKey("tags")]
+	planOverride := currentPlanOverrideTag(instance)
+	if planOverride != "" {
+		raw = strings.Replace(raw, planOverride, "", 1)
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if planOverride != "" {
+		tags = append(tags, planOverride)
+	}
+	return tags
+}
+
+func (m *k8sRpaasManager) GetInstanceAddress(ctx context.Context, name string) (string, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if instance.Spec.Ingress != nil {
+		return m.getIngressAddress(ctx, instance)
+	}
+
+	var nginx nginxv1alpha1.Nginx
+	err = m.cli.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &nginx)
+	if err != nil && IsNotFoundError(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(nginx.Status.Services) == 0 {
+		return "", nil
+	}
+
+	svcName := nginx.Status.Services[0].Name
+	var svc corev1.Service
+	err = m.cli.Get(ctx, types.NamespacedName{Name: svcName, Namespace: instance.Namespace}, &svc)
+	if err != nil {
+		return "", err
+	}
+
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		if len(svc.Status.LoadBalancer.Ingress) == 0 {
+			return "", nil
+		}
+
+		return svc.Status.LoadBalancer.Ingress[0].IP, nil
+	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort:
+		return svc.Spec.ClusterIP, nil
+	}
+
+	return "", nil
+}
+
+func (m *k8sRpaasManager) GetInstance(ctx context.Context, name string) (*v1alpha1.RpaasInstance, error) {
+	list := &v1alpha1.RpaasInstanceList{}
+	listOpts := client.InNamespace(namespaceName()).
+		MatchingLabels(labelsForRpaasInstance(name))
+	err := m.cli.List(ctx, listOpts, list)
+	if err != nil {
+		return nil, err
+	}
+	// Let's filter the list again, field selector implementation is not always
+	// trustyworthy (mainly on tests). If it works correctly we're only
+	// iterating on 1 item at most, so no problem playing safe here.
+	for i := 0; i < len(list.Items); i++ {
+		if list.Items[i].Name != name {
+			list.Items[i] = list.Items[len(list.Items)-1]
+			list.Items = list.Items[:len(list.Items)-1]
+			i--
+		}
+	}
+	if len(list.Items) == 0 {
+		return nil, &NotFoundError{Msg: fmt.Sprintf("rpaas instance %q not found", name)}
+	}
+	if len(list.Items) > 1 {
+		return nil, &ConflictError{Msg: fmt.Sprintf("multiple instances found for name %q: %#v", name, list.Items)}
+	}
+	return &list.Items[0], nil
+}
+
+func (m *k8sRpaasManager) GetPlans(ctx context.Context) ([]v1alpha1.RpaasPlan, error) {
+	var planList v1alpha1.RpaasPlanList
+	if err := m.cli.List(ctx, client.InNamespace(namespaceName()), &planList); err != nil {
+		return nil, err
+	}
+
+	return planList.Items, nil
+}
+
+func (m *k8sRpaasManager) GetPlan(ctx context.Context, name, flavor string) (*v1alpha1.RpaasPlan, error) {
+	plan, err := m.getPlan(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if flavor == "" {
+		return plan, nil
+	}
+
+	flavorSpec := getFlavor(flavor)
+	if flavorSpec == nil {
+		return nil, &NotFoundError{Msg: fmt.Sprintf("flavor %q not found", flavor)}
+	}
+
+	if plan.Spec, err = util.MergePlans(plan.Spec, *flavorSpec); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (m *k8sRpaasManager) CreateExtraFiles(ctx context.Context, instanceName string, files ...File) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	newData := map[string][]byte{}
+	oldExtraFiles, err := m.getExtraFiles(ctx, *instance)
+	if err != nil && !IsNotFoundError(err) {
+		return err
+	}
+	if oldExtraFiles != nil && oldExtraFiles.BinaryData != nil {
+		newData = oldExtraFiles.BinaryData
+	}
+	for _, file := range files {
+		if !isPathValid(file.Name) {
+			return &ValidationError{Msg: fmt.Sprintf("filename %q is not valid", file.Name)}
+		}
+		key := convertPathToConfigMapKey(file.Name)
+		if _, ok := newData[key]; ok {
+			return &ConflictError{Msg: fmt.Sprintf("file %q already exists", file.Name)}
+		}
+		newData[key] = file.Content
+	}
+	newExtraFiles, err := m.createExtraFiles(ctx, *instance, newData)
+	if err != nil {
+		return err
+	}
+	if instance.Spec.ExtraFiles == nil {
+		instance.Spec.ExtraFiles = &nginxv1alpha1.FilesRef{
+			Files: map[string]string{},
+		}
+	}
+	for _, file := range files {
+		key := convertPathToConfigMapKey(file.Name)
+		instance.Spec.ExtraFiles.Files[key] = file.Name
+	}
+	instance.Spec.ExtraFiles.Name = newExtraFiles.Name
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) DeleteExtraFiles(ctx context.Context, instanceName string, filenames ...string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	if err != nil {
+		return err
+	}
+	newData := map[string][]byte{}
+	if extraFiles.BinaryData != nil {
+		newData = extraFiles.BinaryData
+	}
+	for _, filename := range filenames {
+		key := convertPathToConfigMapKey(filename)
+		if _, ok := newData[key]; !ok {
+			return &NotFoundError{Msg: fmt.Sprintf("file %q does not exist", filename)}
+		}
+		delete(newData, key)
+	}
+	if len(newData) == 0 {
+		instance.Spec.ExtraFiles = nil
+		return m.cli.Update(ctx, instance)
+	}
+	extraFiles, err = m.createExtraFiles(ctx, *instance, newData)
+	if err != nil && k8sErrors.IsAlreadyExists(err) {
+		return &ConflictError{Msg: "extra files already is defined"}
+	}
+	if err != nil {
+		return err
+	}
+	for _, filename := range filenames {
+		key := convertPathToConfigMapKey(filename)
+		delete(instance.Spec.ExtraFiles.Files, key)
+	}
+	instance.Spec.ExtraFiles.Name = extraFiles.Name
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) GetExtraFiles(ctx context.Context, instanceName string) ([]File, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	if err != nil && IsNotFoundError(err) {
+		return []File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	files := []File{}
+	for key, path := range instance.Spec.ExtraFiles.Files {
+		files = append(files, File{
+			Name:    path,
+			Content: extraFiles.BinaryData[key],
+		})
+	}
+	return files, nil
+}
+
+func (m *k8sRpaasManager) UpdateExtraFiles(ctx context.Context, instanceName string, files ...File) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	if err != nil {
+		return err
+	}
+	newData := map[string][]byte{}
+	if extraFiles.BinaryData != nil {
+		newData = extraFiles.BinaryData
+	}
+	for _, file := range files {
+		key := convertPathToConfigMapKey(file.Name)
+		if _, ok := newData[key]; !ok {
+			return &NotFoundError{Msg: fmt.Sprintf("file %q does not exist", file.Name)}
+		}
+		newData[key] = file.Content
+	}
+	extraFiles, err = m.createExtraFiles(ctx, *instance, newData)
+	if err != nil && k8sErrors.IsAlreadyExists(err) {
+		return &ConflictError{Msg: "extra files already is defined"}
+	}
+	if err != nil {
+		return err
+	}
+	instance.Spec.ExtraFiles.Name = extraFiles.Name
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) BindApp(ctx context.Context, instanceName string, args BindAppArgs) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if args.AppHost == "" {
+		return &ValidationError{Msg: "application host cannot be empty"}
+	}
+
+	if instance.Spec.Host != "" && instance.Spec.Host != args.AppHost {
+		return &ConflictError{Msg: "instance already bound with another application"}
+	}
+
+	instance.Spec.Host = args.AppHost
+
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) UnbindApp(ctx context.Context, instanceName string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if instance.Spec.Host == "" {
+		return &ValidationError{Msg: "instance not bound"}
+	}
+
+	instance.Spec.Host = ""
+
+	return m.cli.Update(ctx, instance)
+}
+
+// headerNameRegexp matches the HTTP header field names, as defined on RFC 7230.
+var headerNameRegexp = regexp.MustCompile("^[a-zA-Z0-9!#$%&'*+.^_`|~-]+$")
+
+func (m *k8sRpaasManager) PurgeCache(ctx context.Context, instanceName string, args PurgeCacheArgs) (int, error) {
+	ctx, span := trace.StartSpan(ctx, "rpaas.PurgeCache")
+	defer span.End()
+
+	podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return 0, err
+	}
+	if args.Path == "" {
+		return 0, &ValidationError{Msg: "path is required"}
+	}
+	if args.QueryStringVariants && strings.Contains(args.Path, "?") {
+		return 0, &ValidationError{Msg: "cannot purge query string variants of a path with a query string"}
+	}
+	variants := nginxManager.CacheKeyVariants{AllQueryStrings: args.QueryStringVariants}
+	purgeCount := 0
+	for _, podStatus := range podMap {
+		if !podStatus.Running {
+			continue
+		}
+		if err = m.cacheManager.PurgeCache(podStatus.Address, args.Path, args.PreservePath, variants); err != nil {
+			continue
+		}
+		purgeCount += 1
+	}
+	return purgeCount, nil
+}
+
+func (m *k8sRpaasManager) DeleteRoute(ctx context.Context, instanceName, path string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	index, found := hasPath(*instance, path)
+	if !found {
+		return &NotFoundError{Msg: "path does not exist"}
+	}
+
+	if err = m.deleteRouteAuth(ctx, instance, path); err != nil {
+		return err
+	}
+
+	if isStoredInLocationsConfigMap(*instance, instance.Spec.Locations[index]) {
+		if err = m.setLocationContent(ctx, *instance, convertPathToConfigMapKey(path), nil); err != nil {
+			return err
+		}
+	}
+
+	instance.Spec.Locations = append(instance.Spec.Locations[:index], instance.Spec.Locations[index+1:]...)
+	if len(instance.Spec.Locations) == 0 {
+		instance.Spec.Locations = nil
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) DeleteRoutes(ctx context.Context, instanceName string, paths ...string) error {
+	if len(paths) == 0 {
+		return &ValidationError{Msg: "at least one path is required"}
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	toRemove := make(map[string]bool)
+	var missing []string
+	for _, path := range paths {
+		if _, found := hasPath(*instance, path); !found {
+			missing = append(missing, path)
+			continue
+		}
+		toRemove[path] = true
+	}
+
+	if len(missing) > 0 {
+		return &NotFoundError{Msg: fmt.Sprintf("paths do not exist: %s", strings.Join(missing, ", "))}
+	}
+
+	for path := range toRemove {
+		if err = m.deleteRouteAuth(ctx, instance, path); err != nil {
+			return err
+		}
+	}
+
+	var locations []v1alpha1.Location
+	for _, location := range instance.Spec.Locations {
+		if !toRemove[location.Path] {
+			locations = append(locations, location)
+			continue
+		}
+		if isStoredInLocationsConfigMap(*instance, location) {
+			if err = m.setLocationContent(ctx, *instance, convertPathToConfigMapKey(location.Path), nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	instance.Spec.Locations = locations
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) UpdateMap(ctx context.Context, instanceName string, args MapArgs) error {
+	if err := validateMap(args); err != nil {
+		return err
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	var entries []v1alpha1.RpaasInstanceMapEntry
+	for _, e := range args.Entries {
+		entries = append(entries, v1alpha1.RpaasInstanceMapEntry{Key: e.Key, Value: e.Value})
+	}
+	newMap := v1alpha1.RpaasInstanceMap{
+		Variable: args.Variable,
+		Source:   args.Source,
+		Default:  args.Default,
+		Entries:  entries,
+	}
+
+	if index, found := hasMap(*instance, args.Variable); found {
+		instance.Spec.Maps[index] = newMap
+	} else {
+		instance.Spec.Maps = append(instance.Spec.Maps, newMap)
+	}
+
+	return m.cli.Update(ctx, instance)
+}
+
+func (m *k8sRpaasManager) GetMaps(ctx context.Context, instanceName string) ([]MapArgs, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var maps []MapArgs
+	for _, nm := range instance.Spec.Maps {
+		var entries []MapEntry
+		for _, e := range nm.Entries {
+			entries = append(entries, MapEntry{Key: e.Key, Value: e.Value})
+		}
+		maps = append(maps, MapArgs{
+			Variable: nm.Variable,
+			Source:   nm.Source,
+			Default:  nm.Default,
+			Entries:  entries,
+		})
+	}
+
+	return maps, nil
+}
+
+func (m *k8sRpaasManager) DeleteMap(ctx context.Context, instanceName, variable string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	index, found := hasMap(*instance, variable)
+	if !found {
+		return &NotFoundError{Msg: fmt.Sprintf("map of variable %q does not exist", variable)}
+	}
+
+	instance.Spec.Maps = append(instance.Spec.Maps[:index], instance.Spec.Maps[index+1:]...)
+	if len(instance.Spec.Maps) == 0 {
+		instance.Spec.Maps = nil
+	}
+	return m.cli.Update(ctx, instance)
+}
+
+func hasMap(instance v1alpha1.RpaasInstance, variable string) (int, bool) {
+	for i, nm := range instance.Spec.Maps {
+		if nm.Variable == variable {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+var (
+	mapVariableRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	mapSourceRegexp   = regexp.MustCompile(`^(\$[a-zA-Z_][a-zA-Z0-9_]*)+$`)
+	mapKeyRegexp      = regexp.MustCompile(`^[^\s"';{}#\\]+$`)
+	mapValueRegexp    = regexp.MustCompile(`^[^"\\;{}\n\r]*$`)
+
+	// mapKeywords are the keys the map directive reads as parameters rather
+	// than as values of the source, even when quoted.
+	mapKeywords = []string{"default", "hostnames", "include", "volatile"}
+
+	// nginxVariables are the variables NGINX itself (or one of the modules
+	// built into the image) defines, which a map can't redefine.
+	nginxVariables = []string{
+		"ancient_browser", "args", "binary_remote_addr", "body_bytes_sent",
+		"bytes_sent", "connection", "connection_requests", "connection_time",
+		"content_length", "content_type", "date_gmt", "date_local",
+		"document_root", "document_uri", "fastcgi_path_info",
+		"fastcgi_script_name", "gzip_ratio", "host", "hostname", "http2",
+		"https", "invalid_referer", "is_args", "limit_conn_status",
+		"limit_rate", "limit_req_status", "modern_browser", "msec", "msie",
+		"nginx_version", "pid", "pipe", "proxy_add_x_forwarded_for",
+		"proxy_host", "proxy_port", "proxy_protocol_addr",
+		"proxy_protocol_port", "proxy_protocol_server_addr",
+		"proxy_protocol_server_port", "query_string", "realip_remote_addr",
+		"realip_remote_port", "realpath_root", "remote_addr", "remote_port",
+		"remote_user", "request", "request_body", "request_body_file",
+		"request_completion", "request_filename", "request_id",
+		"request_length", "request_method", "request_time", "request_uri",
+		"scheme", "secure_link", "secure_link_expires", "server_addr",
+		"server_name", "server_port", "server_protocol", "status",
+		"tcpinfo_rcv_space", "tcpinfo_rtt", "tcpinfo_rttvar",
+		"tcpinfo_snd_cwnd", "time_iso8601", "time_local", "uid_got",
+		"uid_reset", "uid_set", "uri",
+	}
+
+	// nginxVariablePrefixes are the prefixes of the variables NGINX defines
+	// on demand, such as $http_<header> or $cookie_<name>.
+	nginxVariablePrefixes = []string{
+		"arg_", "cookie_", "geoip_", "http_", "sent_http_", "sent_trailer_",
+		"ssl_", "upstream_",
+	}
+)
+
+func isNGINXVariable(name string) bool {
+	for _, v := range nginxVariables {
+		if name == v {
+			return true
+		}
+	}
+	for _, prefix := range nginxVariablePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateMap(args MapArgs) error {
+	if !mapVariableRegexp.MatchString(args.Variable) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid variable name %q", args.Variable)}
+	}
+	if strings.HasPrefix(args.Variable, "rpaas_") || isNGINXVariable(args.Variable) {
+		return &ValidationError{Msg: fmt.Sprintf("variable name %q is reserved", args.Variable)}
+	}
+	if !mapSourceRegexp.MatchString(args.Source) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid map source %q: must be made of variables only (e.g. \"$host$uri\")", args.Source)}
+	}
+	if !mapValueRegexp.MatchString(args.Default) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid map default value %q", args.Default)}
+	}
+	if len(args.Entries) == 0 {
+		return &ValidationError{Msg: "at least one map entry is required"}
+	}
+
+	keys := make(map[string]bool)
+	for _, e := range args.Entries {
+		if !mapKeyRegexp.MatchString(e.Key) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid map key %q", e.Key)}
+		}
+		for _, keyword := range mapKeywords {
+			if e.Key == keyword {
+				return &ValidationError{Msg: fmt.Sprintf("map key %q is reserved: use the default value of the map instead", e.Key)}
+			}
+		}
+		if strings.HasPrefix(e.Key, "~") {
+			if _, err := regexp.Compile(strings.TrimPrefix(strings.TrimPrefix(e.Key, "~"), "*")); err != nil {
+				return &ValidationError{Msg: fmt.Sprintf("invalid regular expression on map key %q: %v", e.Key, err)}
+			}
+		}
+		if !mapValueRegexp.MatchString(e.Value) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid value %q of map key %q", e.Value, e.Key)}
+		}
+		if keys[e.Key] {
+			return &ValidationError{Msg: fmt.Sprintf("duplicated map key %q", e.Key)}
+		}
+		keys[e.Key] = true
+	}
+
+	return nil
+}
+
+func (m *k8sRpaasManager) GetRoutes(ctx context.Context, instanceName string) ([]Route, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Many locations are usually stored in the same ConfigMap, which is then
+	// read only once.
+	resolver := util.NewValueResolver(m.cli, instance.Namespace)
+
+	var routes []Route
+	for _, location := range instance.Spec.Locations {
+		var content string
+
+		if location.Content != nil {
+			content, err = resolver.GetValue(ctx, location.Content)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if location.Destination == "" && len(location.Destinations) == 0 && content == "" && location.StaticFile == "" {
+			continue
+		}
+
+		var destinations []RouteDestination
+		for _, d := range location.Destinations {
+			destinations = append(destinations, RouteDestination{
+				Address:     d.Address,
+				Weight:      d.Weight,
+				MaxFails:    d.MaxFails,
+				FailTimeout: d.FailTimeout,
+			})
+		}
+
+		var source string
+		if content != "" {
+			source = RouteSourceInline
+			if location.Content.ValueFrom != nil {
+				source = RouteSourceConfigMap
+			}
+		}
+
+		var auth *RouteAuth
+		if location.Auth != nil {
+			auth = &RouteAuth{Users: make(map[string]string)}
+			for _, user := range location.Auth.Users {
+				auth.Users[user] = ""
+			}
+		}
+
+		routes = append(routes, Route{
+			Path:          location.Path,
+			MatchType:     string(location.MatchType),
+			Destination:   location.Destination,
+			Destinations:  destinations,
+			HTTPSOnly:     location.ForceHTTPS,
+			Buffering:     location.Buffering,
+			LoadBalancing: string(location.LoadBalancing),
+			Content:       content,
+			StaticFile:    location.StaticFile,
+			Source:        source,
+			Auth:          auth,
+			AllowCIDRs:    location.AllowCIDRs,
+			DenyCIDRs:     location.DenyCIDRs,
+
+			ClientCertificate: location.UpstreamClientCertificate,
+			UpstreamTLSVerify: location.UpstreamTLSVerify,
+			UpstreamTrustedCA: location.UpstreamTrustedCA,
+			Canary:            (*RouteCanary)(location.Canary),
+			CORS:              (*RouteCORS)(location.CORS),
+		})
+	}
+
+	return routes, nil
+}
+
+// FindRoutesByDestination returns the routes forwarding requests to a
+// destination containing the given value. Routes with custom NGINX
+// configuration are never returned.
+func (m *k8sRpaasManager) FindRoutesByDestination(ctx context.Context, instanceName, destination string) ([]Route, error) {
+	routes, err := m.GetRoutes(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Route
+	for _, route := range routes {
+		if routeHasDestination(route, destination) {
+			found = append(found, route)
+		}
+	}
+
+	return found, nil
+}
+
+func routeHasDestination(route Route, destination string) bool {
+	if route.Destination != "" && strings.Contains(route.Destination, destination) {
+		return true
+	}
+	for _, d := range route.Destinations {
+		if strings.Contains(d.Address, destination) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *k8sRpaasManager) TestDestination(ctx context.Context, instanceName, destination string) (*DestinationCheck, error) {
+	if destination == "" {
+		return nil, &ValidationError{Msg: "destination is required"}
+	}
+	if err := validateDestinationAddress(destination); err != nil {
+		return nil, err
+	}
+
+	podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var podNames []string
+	for podName := range podMap {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	// A single pod is enough, as they all share the same network.
+	for _, podName := range podNames {
+		pod := podMap[podName]
+		if !pod.Running {
+			continue
+		}
+		check := &DestinationCheck{Destination: destination, Pod: podName}
+		latency, err := m.destinationChecker.CheckDestination(pod.Address, destination)
+		if err != nil {
+			check.Error = err.Error()
+			return check, nil
+		}
+		check.Reachable = true
+		check.LatencyMs = float64(latency) / float64(time.Millisecond)
+		return check, nil
+	}
+	return nil, &ConflictError{Msg: "instance has no running pods to check the destination from"}
+}
+
+func (m *k8sRpaasManager) UpdateRoute(ctx context.Context, instanceName string, route Route) error {
+	ctx, span := trace.StartSpan(ctx, "rpaas.UpdateRoute")
+	defer span.End()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := m.GetInstance(ctx, instanceName)
+		if err != nil {
+			return err
+		}
+
+		if err = validateRoute(instance, route); err != nil {
+			return err
+		}
+
+		if route.ClientCertificate != "" && !hasCertificate(*instance, route.ClientCertificate) {
+			return &ValidationError{Msg: fmt.Sprintf("certificate %q not found", route.ClientCertificate)}
+		}
+
+		if route.UpstreamTrustedCA != "" && !hasExtraFile(*instance, route.UpstreamTrustedCA) {
+			return &ValidationError{Msg: fmt.Sprintf("extra file %q not found", route.UpstreamTrustedCA)}
+		}
+
+		if route.StaticFile != "" && !hasExtraFile(*instance, route.StaticFile) {
+			return &ValidationError{Msg: fmt.Sprintf("extra file %q not found", route.StaticFile)}
+		}
+
+		var content *v1alpha1.Value
+		if route.Content != "" {
+			content = &v1alpha1.Value{Value: route.Content}
+		}
+
+		key := convertPathToConfigMapKey(route.Path)
+		if limit := config.Get().RouteContentInlineLimit; limit > 0 && len(route.Content) > limit {
+			if err = m.setLocationContent(ctx, *instance, key, &route.Content); err != nil {
+				return err
+			}
+			content = &v1alpha1.Value{
+				ValueFrom: &v1alpha1.ValueSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: locationsConfigMapName(*instance),
+						},
+						Key: key,
+					},
+				},
+			}
+		} else if len(route.Content) > RouteContentMaxInlineSize() {
+			return &ValidationError{Msg: "route content too large, use a ConfigMap-backed source"}
+		} else if index, found := hasPath(*instance, route.Path); found && isStoredInLocationsConfigMap(*instance, instance.Spec.Locations[index]) {
+			if err = m.setLocationContent(ctx, *instance, key, nil); err != nil {
+				return err
+			}
+		}
+
+		var destinations []v1alpha1.LocationDestination
+		for _, d := range route.Destinations {
+			destinations = append(destinations, v1alpha1.LocationDestination{
+				Address:     d.Address,
+				Weight:      d.Weight,
+				MaxFails:    d.MaxFails,
+				FailTimeout: d.FailTimeout,
+			})
+		}
+
+		auth, err := m.updateRouteAuth(ctx, instance, route)
+		if err != nil {
+			return err
+		}
+
+		newLocation := v1alpha1.Location{
+			Path:          route.Path,
+			MatchType:     v1alpha1.LocationMatchType(route.MatchType),
+			Destination:   route.Destination,
+			Destinations:  destinations,
+			ForceHTTPS:    route.HTTPSOnly,
+			Buffering:     route.Buffering,
+			LoadBalancing: v1alpha1.LoadBalancingMethod(route.LoadBalancing),
+			Content:       content,
+			StaticFile:    route.StaticFile,
+			Auth:          auth,
+			AllowCIDRs:    route.AllowCIDRs,
+			DenyCIDRs:     route.DenyCIDRs,
+
+			UpstreamClientCertificate: route.ClientCertificate,
+			UpstreamTLSVerify:         route.UpstreamTLSVerify,
+			UpstreamTrustedCA:         route.UpstreamTrustedCA,
+			Canary:                    (*v1alpha1.LocationCanary)(route.Canary),
+			CORS:                      (*v1alpha1.LocationCORS)(route.CORS),
+		}
+
+		if index, found := hasPath(*instance, route.Path); found {
+			instance.Spec.Locations[index] = newLocation
+		} else {
+			instance.Spec.Locations = append(instance.Spec.Locations, newLocation)
+		}
+
+		return m.cli.Update(ctx, instance)
+	})
+}
+
+// updateRouteAuth stores the credentials of the route's users as an htpasswd
+// extra file, removing it when the route is no longer protected. The caller
+// is responsible for updating the instance.
+func (m *k8sRpaasManager) updateRouteAuth(ctx context.Context, instance *v1alpha1.RpaasInstance, route Route) (*v1alpha1.LocationAuth, error) {
+	if route.Auth == nil {
+		return nil, m.deleteRouteAuth(ctx, instance, route.Path)
+	}
+
+	fileName := routeAuthFileName(route.Path)
+	current := map[string]string{}
+	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	if err != nil && !IsNotFoundError(err) {
+		return nil, err
+	}
+	if extraFiles != nil {
+		current = parseHtpasswd(extraFiles.BinaryData[convertPathToConfigMapKey(fileName)])
+	}
+
+	hashes := make(map[string]string)
+	var users []string
+	for user, password := range route.Auth.Users {
+		users = append(users, user)
+		if password == "" {
+			hash, ok := current[user]
+			if !ok {
+				return nil, &ValidationError{Msg: fmt.Sprintf("password of user %q is required", user)}
+			}
+			hashes[user] = hash
+			continue
+		}
+
+		if hashes[user], err = hashPassword(password); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(users)
+
+	if err = m.setExtraFile(ctx, instance, fileName, formatHtpasswd(hashes)); err != nil {
+		return nil, err
+	}
+
+	return &v1alpha1.LocationAuth{UserFile: fileName, Users: users}, nil
+}
+
+// deleteRouteAuth removes the htpasswd extra file of the route, if any.
+func (m *k8sRpaasManager) deleteRouteAuth(ctx context.Context, instance *v1alpha1.RpaasInstance, path string) error {
+	index, found := hasPath(*instance, path)
+	if !found || instance.Spec.Locations[index].Auth == nil {
+		return nil
+	}
+	return m.setExtraFile(ctx, instance, instance.Spec.Locations[index].Auth.UserFile, nil)
+}
+
+func routeAuthFileName(path string) string {
+	return fmt.Sprintf("auth/%s.htpasswd", convertPathToConfigMapKey(path))
+}
+
+// setExtraFile writes a single file into the instance's extra files, or
+// removes it when content is nil. The caller is responsible for updating the
+// instance.
+func (m *k8sRpaasManager) setExtraFile(ctx context.Context, instance *v1alpha1.RpaasInstance, name string, content []byte) error {
+	extraFiles, err := m.getExtraFiles(ctx, *instance)
+	if err != nil && !IsNotFoundError(err) {
+		return err
+	}
+
+	data := make(map[string][]byte)
+	if extraFiles != nil {
+		for key, value := range extraFiles.BinaryData {
+			data[key] = value
+		}
+	}
+
+	key := convertPathToConfigMapKey(name)
+	if content == nil {
+		delete(data, key)
+	} else {
+		data[key] = content
+	}
+
+	if len(data) == 0 {
+		instance.Spec.ExtraFiles = nil
+		return nil
+	}
+
+	newExtraFiles, err := m.createExtraFiles(ctx, *instance, data)
+	if err != nil {
+		return err
+	}
+
+	if instance.Spec.ExtraFiles == nil {
+		instance.Spec.ExtraFiles = &nginxv1alpha1.FilesRef{}
+	}
+	if instance.Spec.ExtraFiles.Files == nil {
+		instance.Spec.ExtraFiles.Files = map[string]string{}
+	}
+	if content == nil {
+		delete(instance.Spec.ExtraFiles.Files, key)
+	} else {
+		instance.Spec.ExtraFiles.Files[key] = name
+	}
+	instance.Spec.ExtraFiles.Name = newExtraFiles.Name
+	return nil
+}
+
+// RouteContentMaxInlineSize returns the largest route content accepted
+// inline in the instance.
+func RouteContentMaxInlineSize() int {
+	if size := config.Get().RouteContentMaxInlineSize; size > 0 {
+		return size
+	}
+	return defaultRouteContentMaxInlineSize
+}
+
+func locationsConfigMapName(instance v1alpha1.RpaasInstance) string {
+	return fmt.Sprintf("%s-locations", instance.Name)
+}
+
+func isStoredInLocationsConfigMap(instance v1alpha1.RpaasInstance, location v1alpha1.Location) bool {
+	return location.Content != nil &&
+		location.Content.ValueFrom != nil &&
+		location.Content.ValueFrom.ConfigMapKeyRef != nil &&
+		location.Content.ValueFrom.ConfigMapKeyRef.Name == locationsConfigMapName(instance)
+}
+
+// setLocationContent stores the content under key in the instance's locations
+// ConfigMap, creating it when needed. A nil content removes the key.
+func (m *k8sRpaasManager) setLocationContent(ctx context.Context, instance v1alpha1.RpaasInstance, key string, content *string) error {
+	var cm corev1.ConfigMap
+	err := m.cli.Get(ctx, types.NamespacedName{Name: locationsConfigMapName(instance), Namespace: instance.Namespace}, &cm)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+
+	if k8sErrors.IsNotFound(err) {
+		if content == nil {
+			return nil
+		}
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      locationsConfigMapName(instance),
+				Namespace: instance.Namespace,
+				Labels:    labelsForRpaasInstance(instance.Name),
+				OwnerReferences: []metav1.OwnerReference{
+					*newOwnerReference(instance),
+				},
+			},
+			Data: map[string]string{key: *content},
+		}
+		return m.cli.Create(ctx, &cm)
+	}
+
+	if content == nil {
+		delete(cm.Data, key)
+	} else {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = *content
+	}
+
+	return m.cli.Update(ctx, &cm)
+}
+
+// NormalizeRoutePath returns the route path as NGINX reads it from the
+// location directive, i.e. without surrounding spaces.
+func NormalizeRoutePath(path string) string {
+	return strings.TrimSpace(path)
+}
+
+// validateRoutePaths rejects batches with more than one route for the same
+// path, as the last one would silently replace the others.
+func validateRoutePaths(routes []Route) error {
+	seen := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		path := NormalizeRoutePath(route.Path)
+		if seen[path] {
+			return &ValidationError{Msg: fmt.Sprintf("duplicate route path %q", path)}
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+func hasPath(instance v1alpha1.RpaasInstance, path string) (index int, found bool) {
+	for i, location := range instance.Spec.Locations {
+		if location.Path == path {
+			return i, true
+		}
+	}
+
+	return
+}
+
+func (m *k8sRpaasManager) ValidateInstanceConfig(ctx context.Context, instanceName string, desired InstanceConfig) ([]ValidationError, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := []ValidationError{}
+	addError := func(prefix string, err error) {
+		errs = append(errs, ValidationError{Msg: fmt.Sprintf("%s: %s", prefix, err)})
+	}
+
+	for i, block := range desired.Blocks {
+		if !isBlockTypeAllowed(v1alpha1.BlockType(block.Name)) {
+			addError(fmt.Sprintf("blocks[%d]", i), fmt.Errorf("block %q is not allowed", block.Name))
+			continue
+		}
+		if err := validateBlockDirectives(v1alpha1.BlockType(block.Name), block.Content); err != nil {
+			addError(fmt.Sprintf("blocks[%d]", i), err)
+		}
+	}
+
+	seenPaths := make(map[string]bool)
+	for i, route := range desired.Routes {
+		if err := validateRoute(instance, route); err != nil {
+			addError(fmt.Sprintf("routes[%d]", i), err)
+		}
+		if path := NormalizeRoutePath(route.Path); seenPaths[path] {
+			addError(fmt.Sprintf("routes[%d]", i), fmt.Errorf("duplicate route path %q", path))
+		} else {
+			seenPaths[path] = true
+		}
+	}
+
+	for i, cert := range desired.Certificates {
+		if cert.Name != "" && !certificateNameRegexp.MatchString(cert.Name) {
+			addError(fmt.Sprintf("certificates[%d]", i), fmt.Errorf("invalid certificate name %q: must contain only alphanumeric characters or dashes", cert.Name))
+		}
+		if _, err := tls.X509KeyPair([]byte(cert.Certificate), []byte(cert.Key)); err != nil {
+			addError(fmt.Sprintf("certificates[%d]", i), fmt.Errorf("could not load the given certificate and key: %s", err))
+		}
+	}
+
+	if err := setTags(instance.DeepCopy(), desired.Tags); err != nil {
+		addError("tags", err)
+	}
+
+	return errs, nil
+}
+
+// validateDirectives rejects the NGINX configuration using any directive
+// forbidden by the directive policy set on config.
+func validateDirectives(content string) error {
+	policy := config.Get().DirectivePolicy
+	if len(policy.Allow) == 0 && len(policy.Deny) == 0 {
+		return nil
+	}
+
+	directives, err := nginxManager.Directives(content)
+	if err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("could not parse the configuration: %v", err)}
+	}
+
+	for _, directive := range directives {
+		if matchDirective(policy.Deny, directive) || (len(policy.Allow) > 0 && !matchDirective(policy.Allow, directive)) {
+			return &ValidationError{Msg: fmt.Sprintf("directive %q is not allowed", directive)}
+		}
+	}
+
+	return nil
+}
+
+func matchDirective(patterns []string, directive string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(directive, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+		if pattern == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// ReservedPaths returns the locations managed by the generated NGINX
+// configuration, which routes can't take over.
+func ReservedPaths(instance *v1alpha1.RpaasInstance) []string {
+	paths := []string{nginxManager.DefaultHealthcheckPath}
+	if path := nginxManager.HealthcheckPath(instance); path != nginxManager.DefaultHealthcheckPath {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func validateRoute(instance *v1alpha1.RpaasInstance, r Route) error {
+	if r.Path == "" {
+		return &ValidationError{Msg: "path is required"}
+	}
+
+	for _, path := range ReservedPaths(instance) {
+		if NormalizeRoutePath(r.Path) == path {
+			return &ValidationError{Msg: fmt.Sprintf("path %q is reserved", path)}
+		}
+	}
+
+	switch v1alpha1.LocationMatchType(r.MatchType) {
+	case "", v1alpha1.LocationMatchTypePrefix, v1alpha1.LocationMatchTypeExact:
+		if !regexp.MustCompile(`^/[^ ]*`).MatchString(r.Path) {
+			return &ValidationError{Msg: "invalid path format"}
+		}
+	case v1alpha1.LocationMatchTypeRegex:
+		if strings.ContainsAny(r.Path, " {};#\"'\n\r") {
+			return &ValidationError{Msg: "invalid path format"}
+		}
+		// RE2 is close enough to PCRE to catch most typos, but NGINX has the
+		// final word on the pattern when it reloads the configuration.
+		if _, err := regexp.Compile(r.Path); err != nil {
+			return &ValidationError{Msg: fmt.Sprintf("invalid regular expression (checked with Go's RE2, so NGINX's PCRE may still reject patterns accepted here): %v", err)}
+		}
+	default:
+		return &ValidationError{Msg: fmt.Sprintf("invalid match type %q", r.MatchType)}
+	}
+
+	hasDestination := r.Destination != "" || len(r.Destinations) > 0
+	if r.StaticFile != "" {
+		if err := validateRouteStaticFile(r); err != nil {
+			return err
+		}
+	} else if r.Content == "" && !hasDestination {
+		return &ValidationError{Msg: "either content or destination are required"}
+	}
+
+	if r.Content != "" && hasDestination {
+		return &ValidationError{Msg: "cannot set both content and destination"}
+	}
+
+	if r.Destination != "" && len(r.Destinations) > 0 {
+		return &ValidationError{Msg: "cannot set both destination and destinations"}
+	}
+
+	if r.Destination != "" {
+		if err := validateDestinationAddress(r.Destination); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range r.Destinations {
+		if err := validateRouteDestination(d); err != nil {
+			return err
+		}
+	}
 
-	if args.AppHost == "" {
-		return &ValidationError{Msg: "application host cannot be empty"}
+	if r.Content != "" && r.HTTPSOnly {
+		return &ValidationError{Msg: "cannot set both content and httpsonly"}
 	}
 
-	if instance.Spec.Host != "" && instance.Spec.Host != args.AppHost {
-		return &ConflictError{Msg: "instance already bound with another application"}
+	if err := validateDirectives(r.Content); err != nil {
+		return err
 	}
 
-	instance.Spec.Host = args.AppHost
+	if r.Content != "" && r.Buffering != nil {
+		return &ValidationError{Msg: "cannot set both content and buffering"}
+	}
 
-	return m.cli.Update(ctx, instance)
-}
+	switch v1alpha1.LoadBalancingMethod(r.LoadBalancing) {
+	case "", v1alpha1.LoadBalancingRoundRobin, v1alpha1.LoadBalancingLeastConn, v1alpha1.LoadBalancingIPHash:
+	default:
+		return &ValidationError{Msg: fmt.Sprintf("invalid load balancing method %q", r.LoadBalancing)}
+	}
 
-func (m *k8sRpaasManager) UnbindApp(ctx context.Context, instanceName string) error {
-	instance, err := m.GetInstance(ctx, instanceName)
-	if err != nil {
-		return err
+	if r.Content != "" && r.LoadBalancing != "" {
+		return &ValidationError{Msg: "cannot set both content and load balancing"}
 	}
 
-	if instance.Spec.Host == "" {
-		return &ValidationError{Msg: "instance not bound"}
+	if r.ClientCertificate != "" {
+		if r.Content != "" {
+			return &ValidationError{Msg: "cannot set both content and client certificate"}
+		}
+		if !certificateNameRegexp.MatchString(r.ClientCertificate) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid client certificate name %q", r.ClientCertificate)}
+		}
 	}
 
-	instance.Spec.Host = ""
+	if r.UpstreamTLSVerify && r.Content != "" {
+		return &ValidationError{Msg: "cannot set both content and upstream TLS verification"}
+	}
 
-	return m.cli.Update(ctx, instance)
-}
+	if r.UpstreamTrustedCA != "" && !r.UpstreamTLSVerify {
+		return &ValidationError{Msg: "upstream trusted CA requires upstream TLS verification"}
+	}
 
-func (m *k8sRpaasManager) PurgeCache(ctx context.Context, instanceName string, args PurgeCacheArgs) (int, error) {
-	podMap, err := m.GetInstanceStatus(ctx, instanceName)
-	if err != nil {
-		return 0, err
+	if r.Canary != nil {
+		if err := validateRouteCanary(r); err != nil {
+			return err
+		}
 	}
-	if args.Path == "" {
-		return 0, ValidationError{Msg: "path is required"}
+
+	if r.CORS != nil {
+		if err := validateRouteCORS(r); err != nil {
+			return err
+		}
 	}
-	purgeCount := 0
-	for _, podStatus := range podMap {
-		if !podStatus.Running {
-			continue
+
+	if r.Auth != nil {
+		if len(r.Auth.Users) == 0 {
+			return &ValidationError{Msg: "at least one user is required to protect the route"}
 		}
-		if err = m.cacheManager.PurgeCache(podStatus.Address, args.Path, args.PreservePath); err != nil {
-			continue
+		for user := range r.Auth.Users {
+			if !routeAuthUserRegexp.MatchString(user) {
+				return &ValidationError{Msg: fmt.Sprintf("invalid user name %q", user)}
+			}
 		}
-		purgeCount += 1
 	}
-	return purgeCount, nil
+
+	return validateRouteCIDRs(r.AllowCIDRs, r.DenyCIDRs)
 }
 
-func (m *k8sRpaasManager) DeleteRoute(ctx context.Context, instanceName, path string) error {
-	instance, err := m.GetInstance(ctx, instanceName)
-	if err != nil {
-		return err
+// validateRouteStaticFile checks the route serving an extra file sets none
+// of the fields about the content or the destination.
+func validateRouteStaticFile(r Route) error {
+	if !isPathValid(r.StaticFile) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid static file name %q", r.StaticFile)}
 	}
 
-	index, found := hasPath(*instance, path)
-	if !found {
-		return &NotFoundError{Msg: "path does not exist"}
+	switch {
+	case r.Content != "":
+		return &ValidationError{Msg: "cannot set both static file and content"}
+	case r.Destination != "" || len(r.Destinations) > 0:
+		return &ValidationError{Msg: "cannot set both static file and destination"}
+	case r.HTTPSOnly, r.Buffering != nil, r.LoadBalancing != "", r.ClientCertificate != "", r.UpstreamTLSVerify, r.UpstreamTrustedCA != "", r.Canary != nil:
+		return &ValidationError{Msg: "static file routes only support the auth, CIDR and CORS settings"}
 	}
 
-	instance.Spec.Locations = append(instance.Spec.Locations[:index], instance.Spec.Locations[index+1:]...)
-	return m.cli.Update(ctx, instance)
+	return nil
 }
 
-func (m *k8sRpaasManager) GetRoutes(ctx context.Context, instanceName string) ([]Route, error) {
-	instance, err := m.GetInstance(ctx, instanceName)
-	if err != nil {
-		return nil, err
+var (
+	canaryHeaderRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+	canaryCookieRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+)
+
+// validateRouteCanary checks the canary is matched by either a header or a
+// cookie, whose names must be representable as NGINX variables.
+func validateRouteCanary(r Route) error {
+	if r.Content != "" {
+		return &ValidationError{Msg: "cannot set both content and canary"}
+	}
+	if r.ClientCertificate != "" {
+		return &ValidationError{Msg: "cannot set both client certificate and canary"}
 	}
+	if r.UpstreamTLSVerify {
+		return &ValidationError{Msg: "cannot set both upstream TLS verification and canary"}
+	}
+	if r.Canary.Destination == "" {
+		return &ValidationError{Msg: "canary destination is required"}
+	}
+	if err := validateDestinationAddress(r.Canary.Destination); err != nil {
+		return err
+	}
+	switch {
+	case r.Canary.Header == "" && r.Canary.Cookie == "":
+		return &ValidationError{Msg: "either canary header or cookie is required"}
+	case r.Canary.Header != "" && r.Canary.Cookie != "":
+		return &ValidationError{Msg: "cannot set both canary header and cookie"}
+	case r.Canary.Header != "" && !canaryHeaderRegexp.MatchString(r.Canary.Header):
+		return &ValidationError{Msg: fmt.Sprintf("invalid canary header name %q", r.Canary.Header)}
+	case r.Canary.Cookie != "" && !canaryCookieRegexp.MatchString(r.Canary.Cookie):
+		return &ValidationError{Msg: fmt.Sprintf("invalid canary cookie name %q", r.Canary.Cookie)}
+	}
+	if r.Canary.Value == "" || !mapValueRegexp.MatchString(r.Canary.Value) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid canary value %q", r.Canary.Value)}
+	}
+	return nil
+}
 
-	var routes []Route
-	for _, location := range instance.Spec.Locations {
-		var content string
+var (
+	corsOriginRegexp = regexp.MustCompile(`^https?://([a-zA-Z0-9-]+\.)*[a-zA-Z0-9-]+(:[0-9]{1,5})?$`)
+	corsMethods      = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+)
 
-		if location.Content != nil {
-			content, err = util.GetValue(ctx, m.cli, instance.Namespace, location.Content)
-			if err != nil {
-				return nil, err
-			}
+// validateRouteCORS checks the CORS origins are either "*" or made of the
+// scheme, host and optional port only, as sent by the browsers on the Origin
+// header.
+func validateRouteCORS(r Route) error {
+	if r.Content != "" {
+		return &ValidationError{Msg: "cannot set both content and CORS"}
+	}
+	if len(r.CORS.AllowOrigins) == 0 {
+		return &ValidationError{Msg: "at least one CORS origin is required"}
+	}
+	for _, origin := range r.CORS.AllowOrigins {
+		if origin == "*" && r.CORS.AllowCredentials {
+			return &ValidationError{Msg: "cannot allow credentials from any CORS origin"}
 		}
-
-		if location.Destination == "" && content == "" {
-			continue
+		if origin != "*" && !corsOriginRegexp.MatchString(origin) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid CORS origin %q", origin)}
 		}
-
-		routes = append(routes, Route{
-			Path:        location.Path,
-			Destination: location.Destination,
-			HTTPSOnly:   location.ForceHTTPS,
-			Content:     content,
-		})
 	}
-
-	return routes, nil
+	for _, method := range r.CORS.AllowMethods {
+		valid := false
+		for _, m := range corsMethods {
+			valid = valid || method == m
+		}
+		if !valid {
+			return &ValidationError{Msg: fmt.Sprintf("invalid CORS method %q", method)}
+		}
+	}
+	for _, header := range r.CORS.AllowHeaders {
+		if !headerNameRegexp.MatchString(header) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid CORS header %q", header)}
+		}
+	}
+	if r.CORS.MaxAge < 0 {
+		return &ValidationError{Msg: "CORS max age cannot be negative"}
+	}
+	return nil
 }
 
-func (m *k8sRpaasManager) UpdateRoute(ctx context.Context, instanceName string, route Route) error {
-	instance, err := m.GetInstance(ctx, instanceName)
+var routeAuthUserRegexp = regexp.MustCompile(`^[a-zA-Z0-9._@-]{1,64}$`)
+
+// validateRouteCIDRs checks every entry is a network in CIDR notation and that
+// no allowed network overlaps with a denied one, as the outcome would depend
+// on the rules order.
+func validateRouteCIDRs(allow, deny []string) error {
+	parse := func(cidrs []string) ([]*net.IPNet, error) {
+		var networks []*net.IPNet
+		for _, cidr := range cidrs {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, &ValidationError{Msg: fmt.Sprintf("invalid CIDR %q", cidr)}
+			}
+			networks = append(networks, network)
+		}
+		return networks, nil
+	}
+
+	allowed, err := parse(allow)
 	if err != nil {
 		return err
 	}
-
-	if err = validateRoute(route); err != nil {
+	denied, err := parse(deny)
+	if err != nil {
 		return err
 	}
 
-	var content *v1alpha1.Value
-	if route.Content != "" {
-		content = &v1alpha1.Value{Value: route.Content}
+	for i, a := range allowed {
+		for j, d := range denied {
+			if a.Contains(d.IP) || d.Contains(a.IP) {
+				return &ValidationError{Msg: fmt.Sprintf("allowed CIDR %q overlaps with denied CIDR %q", allow[i], deny[j])}
+			}
+		}
 	}
 
-	newLocation := v1alpha1.Location{
-		Path:        route.Path,
-		Destination: route.Destination,
-		ForceHTTPS:  route.HTTPSOnly,
-		Content:     content,
+	return nil
+}
+
+// validateRouteDestination checks the address of an upstream pool member is
+// either a hostname or an IP, optionally followed by a port, along with its
+// weight and passive health check parameters.
+func validateRouteDestination(d RouteDestination) error {
+	if d.Address == "" {
+		return &ValidationError{Msg: "destination address is required"}
 	}
 
-	if index, found := hasPath(*instance, route.Path); found {
-		instance.Spec.Locations[index] = newLocation
-	} else {
-		instance.Spec.Locations = append(instance.Spec.Locations, newLocation)
+	if err := validateDestinationAddress(d.Address); err != nil {
+		return err
 	}
 
-	return m.cli.Update(ctx, instance)
-}
+	if d.Weight < 0 {
+		return &ValidationError{Msg: fmt.Sprintf("weight of destination %q must not be negative", d.Address)}
+	}
 
-func hasPath(instance v1alpha1.RpaasInstance, path string) (index int, found bool) {
-	for i, location := range instance.Spec.Locations {
-		if location.Path == path {
-			return i, true
+	if d.MaxFails != nil && *d.MaxFails < 0 {
+		return &ValidationError{Msg: fmt.Sprintf("max fails of destination %q must not be negative", d.Address)}
+	}
+
+	if d.FailTimeout != "" {
+		if timeout, err := time.ParseDuration(d.FailTimeout); err != nil || timeout <= 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid fail timeout %q of destination %q", d.FailTimeout, d.Address)}
 		}
 	}
 
-	return
+	return nil
 }
 
-func validateRoute(r Route) error {
-	if r.Path == "" {
-		return &ValidationError{Msg: "path is required"}
-	}
-
-	if !regexp.MustCompile(`^/[^ ]*`).MatchString(r.Path) {
-		return &ValidationError{Msg: "invalid path format"}
+// validateDestinationAddress checks a "host[:port]" address, where the host
+// is a DNS name or an IP. IPv6 literals must be enclosed in brackets (e.g.
+// "[2001:db8::1]:8080"), as NGINX would read a bare one as host and port.
+func validateDestinationAddress(address string) error {
+	host := address
+	if h, port, err := net.SplitHostPort(address); err == nil {
+		n, err := strconv.Atoi(port)
+		if err != nil || len(validation.IsValidPortNum(n)) > 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid port in destination %q", address)}
+		}
+		host = h
+	} else if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		host = address[1 : len(address)-1]
+	} else if net.ParseIP(address) != nil && strings.Contains(address, ":") {
+		return &ValidationError{Msg: fmt.Sprintf("IPv6 destination %q must be enclosed in brackets", address)}
 	}
 
-	if r.Content == "" && r.Destination == "" {
-		return &ValidationError{Msg: "either content or destination are required"}
+	bracketed := strings.HasPrefix(address, "[")
+	isIPv6 := strings.Contains(host, ":") && net.ParseIP(host) != nil
+	if bracketed != isIPv6 {
+		return &ValidationError{Msg: fmt.Sprintf("invalid destination address %q", address)}
 	}
-
-	if r.Content != "" && r.Destination != "" {
-		return &ValidationError{Msg: "cannot set both content and destination"}
+	if isIPv6 {
+		return nil
 	}
 
-	if r.Content != "" && r.HTTPSOnly {
-		return &ValidationError{Msg: "cannot set both content and httpsonly"}
+	if net.ParseIP(host) == nil && len(validation.IsDNS1123Subdomain(host)) > 0 {
+		return &ValidationError{Msg: fmt.Sprintf("invalid destination address %q", address)}
 	}
 
 	return nil
@@ -671,12 +3906,9 @@ func (m *k8sRpaasManager) createExtraFiles(ctx context.Context, instance v1alpha
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-extra-files-%s", instance.Name, hash[:10]),
 			Namespace: instance.Namespace,
+			Labels:    labelsForRpaasInstance(instance.Name),
 			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(&instance, schema.GroupVersionKind{
-					Group:   v1alpha1.SchemeGroupVersion.Group,
-					Version: v1alpha1.SchemeGroupVersion.Version,
-					Kind:    "RpaasInstance",
-				}),
+				*newOwnerReference(instance),
 			},
 			Annotations: map[string]string{
 				"rpaas.extensions.tsuru.io/sha256-hash": hash,
@@ -720,7 +3952,7 @@ func (m *k8sRpaasManager) getPlan(ctx context.Context, name string) (*v1alpha1.R
 			return nil, err
 		}
 
-		return nil, NotFoundError{Msg: fmt.Sprintf("plan %q not found", name)}
+		return nil, &NotFoundError{Msg: fmt.Sprintf("plan %q not found", name)}
 	}
 
 	return &plan, nil
@@ -741,7 +3973,7 @@ func (m *k8sRpaasManager) getDefaultPlan(ctx context.Context) (*v1alpha1.RpaasPl
 
 	switch len(defaultPlans) {
 	case 0:
-		return nil, NotFoundError{Msg: "no default plan found"}
+		return nil, &NotFoundError{Msg: "no default plan found"}
 	case 1:
 		return &defaultPlans[0], nil
 	default:
@@ -749,7 +3981,7 @@ func (m *k8sRpaasManager) getDefaultPlan(ctx context.Context) (*v1alpha1.RpaasPl
 		for _, p := range defaultPlans {
 			names = append(names, p.Name)
 		}
-		return nil, ConflictError{Msg: fmt.Sprintf("several default plans found: %v", strings.Join(names, ","))}
+		return nil, &ConflictError{Msg: fmt.Sprintf("several default plans found: %v", strings.Join(names, ","))}
 	}
 }
 
@@ -796,15 +4028,43 @@ func convertPrivateKeyToPem(key crypto.PrivateKey) ([]byte, error) {
 
 func (m *k8sRpaasManager) validateCreate(ctx context.Context, args CreateArgs) error {
 	if args.Name == "" {
-		return ValidationError{Msg: "name is required"}
+		return &ValidationError{Msg: "name is required"}
 	}
 
 	if args.Team == "" {
-		return ValidationError{Msg: "team name is required"}
+		return &ValidationError{Msg: "team name is required"}
 	}
 
 	if _, err := m.getPlan(ctx, args.Plan); err != nil && IsNotFoundError(err) {
-		return ValidationError{Msg: "invalid plan"}
+		return &ValidationError{Msg: "invalid plan"}
+	}
+
+	if args.ServiceType != "" && !isServiceTypeAllowed(corev1.ServiceType(args.ServiceType)) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid service type %q", args.ServiceType)}
+	}
+
+	if err := validateIngressArgs(args); err != nil {
+		return err
+	}
+
+	if err := validateNodeSelector(args.NodeSelector); err != nil {
+		return err
+	}
+
+	if err := validateTopologySpreadConstraints(args.TopologySpreadConstraints); err != nil {
+		return err
+	}
+
+	if err := validateServiceAnnotations(args.ServiceAnnotations); err != nil {
+		return err
+	}
+
+	if err := validateExtraPorts(args.ExtraPorts); err != nil {
+		return err
+	}
+
+	if err := validateEnv(args.Env); err != nil {
+		return err
 	}
 
 	_, err := m.GetInstance(ctx, args.Name)
@@ -813,7 +4073,7 @@ func (m *k8sRpaasManager) validateCreate(ctx context.Context, args CreateArgs) e
 	}
 
 	if err == nil {
-		return ConflictError{Msg: fmt.Sprintf("rpaas instance named %q already exists", args.Name)}
+		return &ConflictError{Msg: fmt.Sprintf("rpaas instance named %q already exists", args.Name)}
 	}
 
 	return nil
@@ -821,25 +4081,58 @@ func (m *k8sRpaasManager) validateCreate(ctx context.Context, args CreateArgs) e
 
 func parseTagArg(tags []string, name string, destination *string) {
 	for _, tag := range tags {
-		parts := strings.SplitN(tag, "=", 2)
-		if parts[0] == name {
-			*destination = parts[1]
+		if key, value, ok := splitTag(tag); ok && key == name {
+			*destination = value
 			break
 		}
 	}
 }
 
-func isBlockTypeAllowed(bt v1alpha1.BlockType) bool {
-	allowedBlockTypes := map[v1alpha1.BlockType]bool{
-		v1alpha1.BlockTypeRoot:      true,
-		v1alpha1.BlockTypeServer:    true,
-		v1alpha1.BlockTypeHTTP:      true,
-		v1alpha1.BlockTypeLuaServer: true,
-		v1alpha1.BlockTypeLuaWorker: true,
+// splitTag splits a "key=value" tag on its first "=", as the value may hold
+// others, e.g. the JSON of plan-override.
+func splitTag(tag string) (string, string, bool) {
+	parts := strings.SplitN(tag, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// parseTags splits the tags into "key=value" pairs and bare tags. As in
+// parseTagArg, the first tag of a key wins.
+func parseTags(tags []string) InstanceTags {
+	var result InstanceTags
+	for _, tag := range tags {
+		key, value, ok := splitTag(tag)
+		if !ok {
+			result.Bare = append(result.Bare, tag)
+			continue
+		}
+		if result.Values == nil {
+			result.Values = make(map[string]string)
+		}
+		if _, found := result.Values[key]; !found {
+			result.Values[key] = value
+		}
 	}
+	return result
+}
+
+var allowedBlockTypes = []v1alpha1.BlockType{
+	v1alpha1.BlockTypeRoot,
+	v1alpha1.BlockTypeServer,
+	v1alpha1.BlockTypeHTTP,
+	v1alpha1.BlockTypeLuaServer,
+	v1alpha1.BlockTypeLuaWorker,
+}
 
-	_, ok := allowedBlockTypes[bt]
-	return ok
+func isBlockTypeAllowed(bt v1alpha1.BlockType) bool {
+	for _, allowed := range allowedBlockTypes {
+		if bt == allowed {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *k8sRpaasManager) GetInstanceStatus(ctx context.Context, name string) (PodStatusMap, error) {
@@ -852,18 +4145,171 @@ func (m *k8sRpaasManager) GetInstanceStatus(ctx context.Context, name string) (P
 	if err != nil {
 		return nil, err
 	}
-	podMap := PodStatusMap{}
-	for _, podInfo := range nginx.Status.Pods {
-		st, err := m.podStatus(ctx, podInfo.Name, rpaasInstance.Namespace)
+	podMap := PodStatusMap{}
+	for _, podInfo := range nginx.Status.Pods {
+		st, err := m.podStatus(ctx, podInfo.Name, rpaasInstance.Namespace)
+		if k8sErrors.IsNotFound(err) {
+			st = PodStatus{
+				Terminating: true,
+				Status:      "pod is terminating or was already removed",
+			}
+		} else if err != nil {
+			st = PodStatus{
+				Running: false,
+				Status:  fmt.Sprintf("%+v", err),
+			}
+		}
+		podMap[podInfo.Name] = st
+	}
+	return podMap, nil
+}
+
+func (m *k8sRpaasManager) GetNginxStatus(ctx context.Context, name string) (*NginxStatus, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var nginx nginxv1alpha1.Nginx
+	err = m.cli.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &nginx)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := &NginxStatus{CurrentReplicas: nginx.Status.CurrentReplicas}
+	if nginx.Spec.Replicas != nil {
+		status.DesiredReplicas = *nginx.Spec.Replicas
+	}
+
+	// The Deployment is created by nginx-operator after the Nginx object,
+	// so it may still be missing.
+	var deployment appsv1.Deployment
+	err = m.cli.Get(ctx, types.NamespacedName{Name: nginx.Name, Namespace: nginx.Namespace}, &deployment)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return status, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status.UpdatedReplicas = deployment.Status.UpdatedReplicas
+	status.AvailableReplicas = deployment.Status.AvailableReplicas
+	for _, c := range deployment.Status.Conditions {
+		if status.Rollout != nil && !c.LastUpdateTime.Time.After(status.Rollout.LastUpdateTime) {
+			continue
+		}
+		status.Rollout = &RolloutCondition{
+			Type:           string(c.Type),
+			Status:         string(c.Status),
+			Reason:         c.Reason,
+			Message:        c.Message,
+			LastUpdateTime: c.LastUpdateTime.Time.UTC(),
+		}
+	}
+
+	return status, nil
+}
+
+func (m *k8sRpaasManager) GetReloadStatus(ctx context.Context, name string) (*ReloadStatus, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var nginx nginxv1alpha1.Nginx
+	if err = m.cli.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &nginx); err != nil {
+		return nil, err
+	}
+	if nginx.Spec.Config == nil {
+		return nil, &NotFoundError{Msg: "instance configuration not found"}
+	}
+
+	var configMap corev1.ConfigMap
+	if err = m.cli.Get(ctx, types.NamespacedName{Name: nginx.Spec.Config.Name, Namespace: instance.Namespace}, &configMap); err != nil {
+		return nil, err
+	}
+	configID := nginxManager.ConfigID(configMap.Data["nginx.conf"])
+	if configID == "" {
+		return nil, &NotFoundError{Msg: "instance configuration does not report its reload status"}
+	}
+
+	podMap, err := m.GetInstanceStatus(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ReloadStatus{ConfigID: configID, Applied: true, Pods: make(map[string]PodReloadStatus)}
+	for podName, pod := range podMap {
+		podStatus := m.podReloadStatus(pod, configID)
+		status.Applied = status.Applied && podStatus.Reloaded
+		status.Pods[podName] = podStatus
+	}
+	return status, nil
+}
+
+func (m *k8sRpaasManager) podReloadStatus(pod PodStatus, configID string) PodReloadStatus {
+	if !pod.Running {
+		return PodReloadStatus{Error: "pod is not running"}
+	}
+	loaded, err := m.reloadChecker.LoadedConfigID(pod.Address)
+	if err != nil {
+		return PodReloadStatus{Error: err.Error()}
+	}
+	if loaded != configID {
+		return PodReloadStatus{Error: fmt.Sprintf("pod failed to reload, still serving configuration %q", loaded)}
+	}
+	return PodReloadStatus{Reloaded: true}
+}
+
+func (m *k8sRpaasManager) GetInstanceNginxInfo(ctx context.Context, name string) (*NginxInfo, error) {
+	instance, err := m.GetInstance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := m.getPlan(ctx, instance.Spec.PlanName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &NginxInfo{Image: plan.Spec.Image}
+	if instance.Spec.PlanTemplate != nil && instance.Spec.PlanTemplate.Image != "" {
+		info.Image = instance.Spec.PlanTemplate.Image
+	}
+
+	podMap, err := m.GetInstanceStatus(ctx, name)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var podNames []string
+	for podName := range podMap {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	// Any running pod is enough, as they all share the same image.
+	for _, podName := range podNames {
+		pod := podMap[podName]
+		if !pod.Running {
+			continue
+		}
+		version, err := m.versionChecker.Version(pod.Address)
 		if err != nil {
-			st = PodStatus{
-				Running: false,
-				Status:  fmt.Sprintf("%+v", err),
-			}
+			continue
 		}
-		podMap[podInfo.Name] = st
+		info.Version = version
+		info.Pod = podName
+		break
 	}
-	return podMap, nil
+	return info, nil
 }
 
 func (m *k8sRpaasManager) podStatus(ctx context.Context, podName, ns string) (PodStatus, error) {
@@ -879,15 +4325,95 @@ func (m *k8sRpaasManager) podStatus(ctx context.Context, podName, ns string) (Po
 	if err != nil {
 		return PodStatus{}, err
 	}
+	return newPodStatus(pod, evts), nil
+}
+
+func newPodStatus(pod corev1.Pod, evts []corev1.Event) PodStatus {
 	allRunning := true
 	for _, cs := range pod.Status.ContainerStatuses {
 		allRunning = allRunning && cs.Ready
 	}
 	return PodStatus{
-		Address: pod.Status.PodIP,
-		Running: allRunning,
-		Status:  formatPodEvents(evts),
-	}, nil
+		Address:     pod.Status.PodIP,
+		Running:     allRunning && pod.DeletionTimestamp == nil,
+		Terminating: pod.DeletionTimestamp != nil,
+		Status:      formatPodEvents(evts),
+	}
+}
+
+func (m *k8sRpaasManager) GetInstancesStatus(ctx context.Context, names []string) (map[string]PodStatusMap, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	ns := namespaceName()
+
+	var instances v1alpha1.RpaasInstanceList
+	if err := m.cli.List(ctx, client.InNamespace(ns), &instances); err != nil {
+		return nil, err
+	}
+	var nginxes nginxv1alpha1.NginxList
+	if err := m.cli.List(ctx, client.InNamespace(ns), &nginxes); err != nil {
+		return nil, err
+	}
+	nginxByName := make(map[string]*nginxv1alpha1.Nginx, len(nginxes.Items))
+	for i := range nginxes.Items {
+		nginxByName[nginxes.Items[i].Name] = &nginxes.Items[i]
+	}
+
+	var instanceNames []string
+	for _, instance := range instances.Items {
+		if wanted[instance.Name] && nginxByName[instance.Name] != nil {
+			instanceNames = append(instanceNames, instance.Name)
+		}
+	}
+	statuses := make(map[string]PodStatusMap, len(instanceNames))
+	if len(instanceNames) == 0 {
+		return statuses, nil
+	}
+
+	selector, err := labels.NewRequirement(nginxResourceNameLabel, selection.In, instanceNames)
+	if err != nil {
+		return nil, err
+	}
+	var pods corev1.PodList
+	if err = m.cli.List(ctx, &client.ListOptions{Namespace: ns, LabelSelector: labels.NewSelector().Add(*selector)}, &pods); err != nil {
+		return nil, err
+	}
+	podByName := make(map[string]corev1.Pod, len(pods.Items))
+	for _, pod := range pods.Items {
+		podByName[pod.Name] = pod
+	}
+
+	listOpts := client.MatchingField("involvedObject.kind", "Pod")
+	listOpts.Namespace = ns
+	var events corev1.EventList
+	if err = m.nonCachedCli.List(ctx, listOpts, &events); err != nil {
+		return nil, err
+	}
+	eventsByPod := make(map[string][]corev1.Event)
+	for _, evt := range events.Items {
+		if evt.InvolvedObject.Kind == "Pod" {
+			eventsByPod[evt.InvolvedObject.Name] = append(eventsByPod[evt.InvolvedObject.Name], evt)
+		}
+	}
+
+	for _, name := range instanceNames {
+		podMap := PodStatusMap{}
+		for _, podInfo := range nginxByName[name].Status.Pods {
+			pod, ok := podByName[podInfo.Name]
+			if !ok {
+				podMap[podInfo.Name] = PodStatus{
+					Terminating: true,
+					Status:      "pod is terminating or was already removed",
+				}
+				continue
+			}
+			podMap[podInfo.Name] = newPodStatus(pod, eventsByPod[podInfo.Name])
+		}
+		statuses[name] = podMap
+	}
+	return statuses, nil
 }
 
 func (m *k8sRpaasManager) eventsForPod(ctx context.Context, podName, ns string) ([]corev1.Event, error) {
@@ -918,12 +4444,9 @@ func newSecretForCertificates(instance v1alpha1.RpaasInstance, data map[string][
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-certificates-%s", instance.Name, hash[:10]),
 			Namespace: instance.Namespace,
+			Labels:    labelsForRpaasInstance(instance.Name),
 			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(&instance, schema.GroupVersionKind{
-					Group:   v1alpha1.SchemeGroupVersion.Group,
-					Version: v1alpha1.SchemeGroupVersion.Version,
-					Kind:    "RpaasInstance",
-				}),
+				*newOwnerReference(instance),
 			},
 			Annotations: map[string]string{
 				"rpaas.extensions.tsuru.io/sha256-hash": hash,
@@ -1007,6 +4530,198 @@ func newRpaasInstance(name string) *v1alpha1.RpaasInstance {
 	}
 }
 
+func isServiceTypeAllowed(serviceType corev1.ServiceType) bool {
+	switch serviceType {
+	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		return true
+	}
+	return false
+}
+
+// getServiceType returns the Service type for a new instance: the requested
+// one if any, otherwise the configured default (LoadBalancer when unset).
+func getServiceType(requested string) (corev1.ServiceType, error) {
+	if requested != "" {
+		return corev1.ServiceType(requested), nil
+	}
+
+	serviceType := config.Get().DefaultServiceType
+	if serviceType == "" {
+		return corev1.ServiceTypeLoadBalancer, nil
+	}
+
+	if !isServiceTypeAllowed(serviceType) {
+		return "", errors.Errorf("invalid default service type %q in config", serviceType)
+	}
+
+	return serviceType, nil
+}
+
+func validateIngressArgs(args CreateArgs) error {
+	if args.IngressClass == "" && args.IngressHost == "" && args.IngressTLSSecret == "" {
+		return nil
+	}
+
+	if args.IngressClass == "" {
+		return &ValidationError{Msg: "ingress class is required"}
+	}
+
+	if args.IngressHost == "" {
+		return &ValidationError{Msg: "ingress host is required"}
+	}
+
+	if errs := validation.IsDNS1123Subdomain(args.IngressHost); len(errs) > 0 {
+		return &ValidationError{Msg: fmt.Sprintf("invalid ingress host %q: %s", args.IngressHost, strings.Join(errs, "; "))}
+	}
+
+	return nil
+}
+
+func validateNodeSelector(nodeSelector map[string]string) error {
+	var keys []string
+	for key := range nodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid node selector key %q: %s", key, strings.Join(errs, "; "))}
+		}
+		if errs := validation.IsValidLabelValue(nodeSelector[key]); len(errs) > 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid node selector value %q for key %q: %s", nodeSelector[key], key, strings.Join(errs, "; "))}
+		}
+	}
+
+	return nil
+}
+
+func validateExtraPorts(ports []int32) error {
+	seen := make(map[int32]bool)
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid extra port %d: must be between 1 and 65535", port)}
+		}
+		if nginxManager.IsReservedPort(port) {
+			return &ValidationError{Msg: fmt.Sprintf("extra port %d is reserved", port)}
+		}
+		if seen[port] {
+			return &ValidationError{Msg: fmt.Sprintf("duplicated extra port %d", port)}
+		}
+		seen[port] = true
+	}
+
+	return nil
+}
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvPrefixes are the prefixes of the variables set by Kubernetes on
+// every container and of those kept for the operator's own use.
+var reservedEnvPrefixes = []string{"KUBERNETES_", "RPAAS_"}
+
+func validateEnv(env map[string]string) error {
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !envNameRegexp.MatchString(name) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid environment variable name %q: must consist of letters, digits and underscores, not starting with a digit", name)}
+		}
+		for _, prefix := range reservedEnvPrefixes {
+			if strings.HasPrefix(strings.ToUpper(name), prefix) {
+				return &ValidationError{Msg: fmt.Sprintf("environment variable %q is reserved: names starting with %q can't be set", name, prefix)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// allowedServiceAnnotationPrefixes holds the prefixes of the cloud-provider
+// Service annotations users are allowed to set, e.g. to request an internal
+// load balancer.
+var allowedServiceAnnotationPrefixes = []string{
+	"service.beta.kubernetes.io/",
+	"service.kubernetes.io/",
+	"cloud.google.com/",
+	"networking.gke.io/",
+}
+
+func validateServiceAnnotations(annotations map[string]string) error {
+	var keys []string
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, defaultKeyLabelPrefix+"/") {
+			return &ValidationError{Msg: fmt.Sprintf("service annotation %q is reserved", key)}
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid service annotation %q: %s", key, strings.Join(errs, "; "))}
+		}
+		if !isServiceAnnotationAllowed(key) {
+			return &ValidationError{Msg: fmt.Sprintf("service annotation %q is not allowed", key)}
+		}
+	}
+
+	return nil
+}
+
+func isServiceAnnotationAllowed(key string) bool {
+	for _, prefix := range allowedServiceAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeServiceAnnotations returns a copy of current with annotations set on
+// it, removing the ones with empty values.
+func mergeServiceAnnotations(current, annotations map[string]string) map[string]string {
+	if len(current) == 0 && len(annotations) == 0 {
+		return current
+	}
+	merged := make(map[string]string)
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range annotations {
+		if v == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+func validateTopologySpreadConstraints(constraints []v1alpha1.TopologySpreadConstraint) error {
+	for i, c := range constraints {
+		if c.MaxSkew <= 0 {
+			return &ValidationError{Msg: fmt.Sprintf("topology spread constraint %d: maxSkew must be greater than zero", i)}
+		}
+		if c.TopologyKey == "" {
+			return &ValidationError{Msg: fmt.Sprintf("topology spread constraint %d: topologyKey is required", i)}
+		}
+		switch c.WhenUnsatisfiable {
+		case v1alpha1.DoNotSchedule, v1alpha1.ScheduleAnyway:
+		case "":
+			return &ValidationError{Msg: fmt.Sprintf("topology spread constraint %d: whenUnsatisfiable is required", i)}
+		default:
+			return &ValidationError{Msg: fmt.Sprintf("topology spread constraint %d: invalid whenUnsatisfiable %q", i, c.WhenUnsatisfiable)}
+		}
+	}
+
+	return nil
+}
+
 func getAffinity(team string) *corev1.Affinity {
 	conf := config.Get()
 	if conf.TeamAffinity != nil {
@@ -1038,12 +4753,47 @@ func setDescription(instance *v1alpha1.RpaasInstance, description string) {
 	})
 }
 
+// reservedTags lists the tags which change the instance spec, in the order
+// they're placed after the user-provided tags.
+var reservedTags = []string{"flavor", "plan-override"}
+
+// orderTags keeps the user-provided tags in their original order and moves
+// the reserved ones to the end, following the reservedTags order, so the
+// resulting annotation is stable across updates.
+func orderTags(tags []string) []string {
+	ordered := make([]string, 0, len(tags))
+	reserved := make(map[string][]string)
+	for _, tag := range tags {
+		name := strings.SplitN(tag, "=", 2)[0]
+		if isReservedTag(name) {
+			reserved[name] = append(reserved[name], tag)
+			continue
+		}
+		ordered = append(ordered, tag)
+	}
+
+	for _, name := range reservedTags {
+		ordered = append(ordered, reserved[name]...)
+	}
+
+	return ordered
+}
+
+func isReservedTag(name string) bool {
+	for _, reserved := range reservedTags {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
 func setTags(instance *v1alpha1.RpaasInstance, tags []string) error {
 	if instance == nil {
 		return nil
 	}
 
-	sort.Strings(tags)
+	tags = orderTags(tags)
 
 	instance.Annotations = mergeMap(instance.Annotations, map[string]string{
 		labelKey("tags"): strings.Join(tags, ","),
@@ -1072,18 +4822,209 @@ func setTags(instance *v1alpha1.RpaasInstance, tags []string) error {
 			return errors.Errorf("flavor %q not found", flavor)
 		}
 
+		if err := validateSecurityPolicy(fmt.Sprintf("flavor %q", flavor), *planTemplate); err != nil {
+			return err
+		}
+
+		if err := validateNginxWorkers(fmt.Sprintf("flavor %q", flavor), planTemplate.Config); err != nil {
+			return err
+		}
+
 		instance.Spec.PlanTemplate = planTemplate
 	}
 
 	if planOverride != "" {
+		if err := validatePlanOverrideResources(planOverride); err != nil {
+			return err
+		}
+
 		var planTemplate v1alpha1.RpaasPlanSpec
 		if err := json.Unmarshal([]byte(planOverride), &planTemplate); err != nil {
 			return errors.Wrapf(err, "unable to parse plan-override from data %q", planOverride)
 		}
 
+		if err := validateSecurityPolicy("plan-override", planTemplate); err != nil {
+			return err
+		}
+
+		if err := validateNginxWorkers("plan-override", planTemplate.Config); err != nil {
+			return err
+		}
+
 		instance.Spec.PlanTemplate = &planTemplate
 	}
 
+	var workerProcesses, workerConnections string
+	parseTagArg(tags, "worker-processes", &workerProcesses)
+	parseTagArg(tags, "worker-connections", &workerConnections)
+
+	return setNginxWorkers(instance, workerProcesses, workerConnections)
+}
+
+// setPlanOverride sets the plan template of the instance from a structured
+// override, which is applied before the tags so the worker tags can still
+// change it.
+func setPlanOverride(instance *v1alpha1.RpaasInstance, tags []string, override *PlanTemplate) error {
+	if override == nil {
+		return nil
+	}
+
+	var flavor, planOverride string
+	parseTagArg(tags, "flavor", &flavor)
+	parseTagArg(tags, "plan-override", &planOverride)
+
+	if planOverride != "" {
+		return &ValidationError{Msg: "cannot set both plan override and plan-override tag"}
+	}
+
+	if flavor != "" {
+		return &ValidationError{Msg: "cannot set both plan override and flavor"}
+	}
+
+	planTemplate := v1alpha1.RpaasPlanSpec(*override)
+	if err := validateSecurityPolicy("plan override", planTemplate); err != nil {
+		return err
+	}
+
+	if err := validateNginxWorkers("plan override", planTemplate.Config); err != nil {
+		return err
+	}
+
+	instance.Spec.PlanTemplate = planTemplate.DeepCopy()
+	return nil
+}
+
+// setNginxWorkers overrides the worker_processes and worker_connections
+// directives of the instance's plan with the values from the
+// "worker-processes" and "worker-connections" tags.
+func setNginxWorkers(instance *v1alpha1.RpaasInstance, processes, connections string) error {
+	if processes == "" && connections == "" {
+		return nil
+	}
+
+	var config v1alpha1.NginxConfig
+	if processes != "" {
+		value := intstr.Parse(processes)
+		config.WorkerProcesses = &value
+	}
+
+	if connections != "" {
+		value, err := strconv.Atoi(connections)
+		if err != nil || value <= 0 {
+			return &ValidationError{Msg: fmt.Sprintf("tags: worker connections must be a positive integer, got %q", connections)}
+		}
+		config.WorkerConnections = value
+	}
+
+	if err := validateNginxWorkers("tags", config); err != nil {
+		return err
+	}
+
+	if instance.Spec.PlanTemplate == nil {
+		instance.Spec.PlanTemplate = &v1alpha1.RpaasPlanSpec{}
+	}
+
+	if config.WorkerProcesses != nil {
+		instance.Spec.PlanTemplate.Config.WorkerProcesses = config.WorkerProcesses
+	}
+
+	if config.WorkerConnections != 0 {
+		instance.Spec.PlanTemplate.Config.WorkerConnections = config.WorkerConnections
+	}
+
+	return nil
+}
+
+func validateNginxWorkers(source string, config v1alpha1.NginxConfig) error {
+	if wp := config.WorkerProcesses; wp != nil {
+		if (wp.Type == intstr.Int && wp.IntVal <= 0) || (wp.Type == intstr.String && wp.StrVal != "auto") {
+			return &ValidationError{Msg: fmt.Sprintf("%s: worker processes must be a positive integer or \"auto\", got %q", source, wp.String())}
+		}
+	}
+
+	if config.WorkerConnections < 0 {
+		return &ValidationError{Msg: fmt.Sprintf("%s: worker connections must be a positive integer, got %d", source, config.WorkerConnections)}
+	}
+
+	return nil
+}
+
+// validateSecurityPolicy rejects the plan templates, from either a flavor or
+// a plan-override, which weaken the security policy set on config.
+func validateSecurityPolicy(source string, planTemplate v1alpha1.RpaasPlanSpec) error {
+	minTLSVersion := planTemplate.Config.TLSMinVersion
+	if minTLSVersion == "" {
+		return nil
+	}
+
+	if err := nginxManager.ValidateTLSVersion(minTLSVersion); err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("%s: %v", source, err)}
+	}
+
+	policy := config.Get().SecurityPolicy
+	if policy.MinTLSVersion == "" {
+		return nil
+	}
+
+	cmp, err := nginxManager.CompareTLSVersions(minTLSVersion, policy.MinTLSVersion)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return &ValidationError{Msg: fmt.Sprintf("%s violates the security policy \"min-tls-version\": TLS version %q is older than %q", source, minTLSVersion, policy.MinTLSVersion)}
+	}
+	return nil
+}
+
+func validatePlanOverrideResources(data string) error {
+	var override struct {
+		Resources *struct {
+			Limits   map[string]json.RawMessage `json:"limits"`
+			Requests map[string]json.RawMessage `json:"requests"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal([]byte(data), &override); err != nil || override.Resources == nil {
+		return nil
+	}
+
+	parse := func(field string, values map[string]json.RawMessage) (map[string]resource.Quantity, error) {
+		var names []string
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		quantities := make(map[string]resource.Quantity)
+		for _, name := range names {
+			var value string
+			if err := json.Unmarshal(values[name], &value); err != nil {
+				value = string(values[name])
+			}
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, &ValidationError{Msg: fmt.Sprintf("invalid quantity %q for resources.%s.%s", value, field, name)}
+			}
+			quantities[name] = q
+		}
+		return quantities, nil
+	}
+
+	limits, err := parse("limits", override.Resources.Limits)
+	if err != nil {
+		return err
+	}
+	requests, err := parse("requests", override.Resources.Requests)
+	if err != nil {
+		return err
+	}
+
+	for name, request := range requests {
+		limit, ok := limits[name]
+		if ok && limit.Cmp(request) < 0 {
+			return &ValidationError{Msg: fmt.Sprintf("resources.limits.%s must be greater than or equal to resources.requests.%s", name, name)}
+		}
+	}
+
 	return nil
 }
 
@@ -1099,6 +5040,16 @@ func setTeamOwner(instance *v1alpha1.RpaasInstance, team string) {
 	instance.Spec.PodTemplate.Labels = mergeMap(instance.Spec.PodTemplate.Labels, newLabels)
 }
 
+func setOwner(instance *v1alpha1.RpaasInstance, user string) {
+	if instance == nil || user == "" {
+		return
+	}
+
+	instance.Annotations = mergeMap(instance.Annotations, map[string]string{
+		labelKey("owner"): user,
+	})
+}
+
 func getFlavor(name string) *v1alpha1.RpaasPlanSpec {
 	for _, flavor := range config.Get().Flavors {
 		if name == flavor.Name {
@@ -5,18 +5,34 @@
 package rpaas
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	nginxv1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
+	nginxManager "github.com/tsuru/rpaas-operator/internal/pkg/rpaas/nginx"
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 type ConfigurationBlock struct {
 	Name    string `form:"block_name" json:"block_name"`
 	Content string `form:"content" json:"content"`
+	// TemplateRef fills the block content with a server-side snippet
+	// instead. It's mutually exclusive with Content.
+	TemplateRef *TemplateRef `json:"template_ref,omitempty"`
+}
+
+// TemplateRef references a snippet from the config by name along with the
+// parameters to render it.
+type TemplateRef struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params,omitempty"`
 }
 
 // ConfigurationBlockHandler defines some functions to handle the custom
@@ -32,11 +48,28 @@ type ConfigurationBlockHandler interface {
 	// otherwise a non-nil one which describes the reached problem.
 	ListBlocks(ctx context.Context, instanceName string) ([]ConfigurationBlock, error)
 
+	// GetAllowedBlocks returns the names of configuration blocks which can be
+	// set on instances, sorted by name.
+	GetAllowedBlocks(ctx context.Context) ([]string, error)
+
 	// UpdateBlock overwrites the older configuration block content with the one.
 	// Whether the configuration block entry does not exist, it will already be
 	// created with the new content. It returns a nil error meaning it was
 	// successful, otherwise a non-nil one which describes the reached problem.
 	UpdateBlock(ctx context.Context, instanceName string, block ConfigurationBlock) error
+
+	// UpdateBlockAcrossInstances applies the configuration block to every
+	// instance matching the label selector, returning the outcome for each
+	// one, sorted by instance name. A failing instance doesn't stop the
+	// others from being updated.
+	UpdateBlockAcrossInstances(ctx context.Context, selector string, block ConfigurationBlock) ([]InstanceBlockResult, error)
+}
+
+// InstanceBlockResult is the outcome of an instance of a batch block update.
+// Instances with Error set were left unchanged.
+type InstanceBlockResult struct {
+	Instance string `json:"instance"`
+	Error    string `json:"error,omitempty"`
 }
 
 type File struct {
@@ -65,15 +98,136 @@ type ExtraFileHandler interface {
 
 type Route struct {
 	Path        string `json:"path" form:"path"`
+	MatchType   string `json:"match_type,omitempty" form:"match_type"`
 	Destination string `json:"destination" form:"destination"`
-	Content     string `json:"content" form:"content"`
-	HTTPSOnly   bool   `json:"https_only" form:"https_only"`
+	// Destinations forms a pool of upstream servers. It's an alternative to
+	// Destination, which is handled as a single-member pool.
+	Destinations []RouteDestination `json:"destinations,omitempty"`
+	Content      string             `json:"content" form:"content"`
+	HTTPSOnly    bool               `json:"https_only" form:"https_only"`
+	Buffering    *bool              `json:"buffering,omitempty" form:"buffering"`
+	// StaticFile names the extra file served by the route, instead of
+	// forwarding the requests to a destination.
+	StaticFile string `json:"static_file,omitempty" form:"static_file"`
+	// LoadBalancing is the method used to pick the destination address:
+	// "round_robin" (default), "least_conn" or "ip_hash".
+	LoadBalancing string `json:"load_balancing,omitempty" form:"load_balancing"`
+	// Source tells where the route content is stored, either "inline" or
+	// "configmap". It's only filled on routes with content.
+	Source string `json:"source,omitempty"`
+	// Auth protects the route with HTTP basic authentication.
+	Auth *RouteAuth `json:"auth,omitempty"`
+	// AllowCIDRs and DenyCIDRs restrict the clients allowed to access the
+	// route by their source networks (e.g. "10.0.0.0/8").
+	AllowCIDRs []string `json:"allow_cidrs,omitempty" form:"allow_cidrs"`
+	DenyCIDRs  []string `json:"deny_cidrs,omitempty" form:"deny_cidrs"`
+	// ClientCertificate is the name of an instance certificate presented
+	// to the destination, which is then reached over HTTPS (mTLS).
+	ClientCertificate string `json:"client_certificate,omitempty" form:"client_certificate"`
+	// UpstreamTLSVerify verifies the destination's certificate, reaching it
+	// over HTTPS. UpstreamTrustedCA optionally names the extra file holding
+	// the trusted CA bundle, defaulting to the system one.
+	UpstreamTLSVerify bool   `json:"upstream_tls_verify,omitempty" form:"upstream_tls_verify"`
+	UpstreamTrustedCA string `json:"upstream_trusted_ca,omitempty" form:"upstream_trusted_ca"`
+	// Canary sends the requests carrying a header or cookie to another
+	// destination, e.g. to try it out before weighting traffic to it.
+	Canary *RouteCanary `json:"canary,omitempty"`
+	// CORS answers the cross-origin requests to the route, including the
+	// preflight ones, so the destination doesn't need to.
+	CORS *RouteCORS `json:"cors,omitempty"`
+}
+
+// RouteCORS holds the Cross-Origin Resource Sharing settings of a route.
+// AllowOrigins are formatted as "scheme://host[:port]", or "*" for any
+// origin, in which case credentials can't be allowed. MaxAge is in seconds.
+type RouteCORS struct {
+	AllowOrigins     []string `json:"allow_origins"`
+	AllowMethods     []string `json:"allow_methods,omitempty"`
+	AllowHeaders     []string `json:"allow_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty"`
+	MaxAge           int32    `json:"max_age,omitempty"`
+}
+
+// RouteCanary matches the requests sent to the canary destination by either
+// a header or a cookie whose value is equal to Value.
+type RouteCanary struct {
+	Destination string `json:"destination"`
+	Header      string `json:"header,omitempty"`
+	Cookie      string `json:"cookie,omitempty"`
+	Value       string `json:"value"`
+}
+
+// RouteAuth holds the passwords of the users allowed to access a route, by
+// user name. Passwords are never returned, so an empty one keeps the current
+// password of an existing user.
+type RouteAuth struct {
+	Users map[string]string `json:"users"`
 }
 
+// RouteDestination is a member of a route's upstream pool.
+type RouteDestination struct {
+	Address string `json:"address"`
+	Weight  int32  `json:"weight,omitempty"`
+	// MaxFails and FailTimeout configure the passive health check of the
+	// member, e.g. 3 failures within "30s".
+	MaxFails    *int32 `json:"max_fails,omitempty"`
+	FailTimeout string `json:"fail_timeout,omitempty"`
+}
+
+const (
+	RouteSourceInline    = "inline"
+	RouteSourceConfigMap = "configmap"
+)
+
 type RouteHandler interface {
 	DeleteRoute(ctx context.Context, instanceName, path string) error
+	// DeleteRoutes removes every route matching the given paths in a single
+	// update. When some paths do not exist, none is removed and the returned
+	// NotFoundError lists all of them.
+	DeleteRoutes(ctx context.Context, instanceName string, paths ...string) error
 	GetRoutes(ctx context.Context, instanceName string) ([]Route, error)
+	FindRoutesByDestination(ctx context.Context, instanceName, destination string) ([]Route, error)
 	UpdateRoute(ctx context.Context, instanceName string, route Route) error
+	// TestDestination checks whether a route destination is reachable from
+	// one of the running pods of the instance.
+	TestDestination(ctx context.Context, instanceName, destination string) (*DestinationCheck, error)
+}
+
+// MapArgs describes a NGINX map directive, which sets Variable according to
+// the value of Source, or to Default when none of the entries matches.
+type MapArgs struct {
+	Variable string     `json:"variable"`
+	Source   string     `json:"source"`
+	Default  string     `json:"default,omitempty"`
+	Entries  []MapEntry `json:"entries"`
+}
+
+type MapEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type MapHandler interface {
+	// UpdateMap creates the map or overwrites the one setting the same
+	// variable.
+	UpdateMap(ctx context.Context, instanceName string, args MapArgs) error
+	GetMaps(ctx context.Context, instanceName string) ([]MapArgs, error)
+	DeleteMap(ctx context.Context, instanceName, variable string) error
+}
+
+// NodeSelector holds the labels of the nodes the instance's pods may run on.
+// Form-encoded requests send it as comma-separated "name=value" pairs (e.g.
+// "disktype=ssd,zone=a").
+type NodeSelector map[string]string
+
+// UnmarshalParam implements echo.BindUnmarshaler.
+func (s *NodeSelector) UnmarshalParam(param string) error {
+	selector, err := labels.ConvertSelectorToLabelsMap(param)
+	if err != nil {
+		return fmt.Errorf("invalid node selector %q: %v", param, err)
+	}
+	*s = NodeSelector(selector)
+	return nil
 }
 
 type CreateArgs struct {
@@ -82,6 +236,31 @@ type CreateArgs struct {
 	Team        string   `json:"team" form:"team"`
 	Tags        []string `json:"tags" form:"tags"`
 	Description string   `json:"description" form:"description"`
+	// User is who requested the instance, recorded as its owner.
+	User string `json:"user,omitempty" form:"user"`
+	// ServiceType overrides the configured default Service type.
+	ServiceType string `json:"service_type,omitempty" form:"service_type"`
+	// IngressClass, IngressHost and IngressTLSSecret expose the instance
+	// through an Ingress instead of a LoadBalancer Service.
+	IngressClass     string `json:"ingress_class,omitempty" form:"ingress_class"`
+	IngressHost      string `json:"ingress_host,omitempty" form:"ingress_host"`
+	IngressTLSSecret string `json:"ingress_tls_secret,omitempty" form:"ingress_tls_secret"`
+	// NodeSelector restricts the instance's pods to nodes with these labels.
+	NodeSelector NodeSelector `json:"node_selector,omitempty" form:"node_selector"`
+	// TopologySpreadConstraints spreads the instance's pods across zones.
+	TopologySpreadConstraints []v1alpha1.TopologySpreadConstraint `json:"topology_spread_constraints,omitempty"`
+	// ServiceAnnotations are cloud-provider load balancer settings added to
+	// the instance's Service.
+	ServiceAnnotations map[string]string `json:"service_annotations,omitempty"`
+	// ExtraPorts are additional ports the instance serves plain HTTP on.
+	ExtraPorts []int32 `json:"extra_ports,omitempty"`
+	// Env are environment variables set on the NGINX worker processes.
+	Env map[string]string `json:"env,omitempty"`
+	// PlanOverride sets the instance's plan template. It can't be combined
+	// with the flavor and plan-override tags.
+	PlanOverride *PlanTemplate `json:"plan_override,omitempty"`
+	// DryRun validates and builds the instance without creating it.
+	DryRun bool `json:"-" form:"-"`
 }
 
 type UpdateInstanceArgs struct {
@@ -89,14 +268,225 @@ type UpdateInstanceArgs struct {
 	Plan        string   `json:"plan" form:"plan"`
 	Tags        []string `json:"tags" form:"tags"`
 	Team        string   `json:"team" form:"team"`
+	// NodeSelector replaces the instance's node selector when not nil.
+	NodeSelector NodeSelector `json:"node_selector,omitempty" form:"node_selector"`
+	// TopologySpreadConstraints replaces the instance's constraints when not nil.
+	TopologySpreadConstraints []v1alpha1.TopologySpreadConstraint `json:"topology_spread_constraints,omitempty"`
+	// ServiceAnnotations are merged into the Service annotations. An empty
+	// value removes the annotation.
+	ServiceAnnotations map[string]string `json:"service_annotations,omitempty"`
+	// ExtraPorts replaces the instance's extra ports when not nil.
+	ExtraPorts []int32 `json:"extra_ports,omitempty"`
+	// Env replaces the instance's environment variables when not nil, so an
+	// empty map removes them all.
+	Env map[string]string `json:"env,omitempty"`
+	// PlanOverride replaces the instance's plan template when not nil. It
+	// can't be combined with the flavor and plan-override tags.
+	PlanOverride *PlanTemplate `json:"plan_override,omitempty"`
+	// DryRun validates and applies the changes without updating the instance.
+	DryRun bool `json:"-" form:"-"`
+}
+
+// PlanTemplate overrides the fields of the instance's plan. Unlike the
+// plan-override tag, it's decoded strictly, so misspelled fields are rejected
+// instead of being silently ignored.
+type PlanTemplate v1alpha1.RpaasPlanSpec
+
+func (p *PlanTemplate) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode((*v1alpha1.RpaasPlanSpec)(p)); err != nil {
+		return fmt.Errorf("invalid plan override: %v", err)
+	}
+	return nil
+}
+
+// UpdateInstanceMetadataArgs holds the instance metadata to change. Nil
+// fields are left untouched.
+type UpdateInstanceMetadataArgs struct {
+	Description *string  `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// InstanceMetadata holds the descriptive data stored in the instance
+// annotations.
+type InstanceMetadata struct {
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Team        string   `json:"team"`
+	Owner       string   `json:"owner,omitempty"`
+}
+
+type CertificateInfo struct {
+	Name string `json:"name"`
+	// Default tells whether the certificate is served to the clients whose
+	// SNI matches no server name.
+	Default bool `json:"default"`
+}
+
+// InstanceTags holds the instance tags, those in the "key=value" form split
+// into Values.
+type InstanceTags struct {
+	Values map[string]string `json:"values,omitempty"`
+	Bare   []string          `json:"bare,omitempty"`
 }
 
 type PodStatusMap map[string]PodStatus
 
 type PodStatus struct {
-	Running bool   `json:"running"`
-	Status  string `json:"status"`
-	Address string `json:"address"`
+	Running     bool   `json:"running"`
+	Terminating bool   `json:"terminating"`
+	Status      string `json:"status"`
+	Address     string `json:"address"`
+}
+
+// NginxStatus summarizes the status of the Nginx object backing an instance
+// and of its Deployment rollout.
+type NginxStatus struct {
+	DesiredReplicas   int32 `json:"desired_replicas"`
+	CurrentReplicas   int32 `json:"current_replicas"`
+	UpdatedReplicas   int32 `json:"updated_replicas"`
+	AvailableReplicas int32 `json:"available_replicas"`
+	// Rollout is the most recently updated condition of the Deployment
+	// rollout, if any.
+	Rollout *RolloutCondition `json:"rollout,omitempty"`
+}
+
+type RolloutCondition struct {
+	Type           string    `json:"type"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	LastUpdateTime time.Time `json:"last_update_time"`
+}
+
+// InstanceResources references the ConfigMaps and Secrets backing an
+// instance, as pointed by its spec. ConfigMaps from another namespace are
+// written as "<namespace>/<name>".
+type InstanceResources struct {
+	BlocksConfigMaps    []string `json:"blocks_config_maps,omitempty"`
+	LocationsConfigMaps []string `json:"locations_config_maps,omitempty"`
+	ExtraFilesConfigMap string   `json:"extra_files_config_map,omitempty"`
+	CertificatesSecret  string   `json:"certificates_secret,omitempty"`
+}
+
+// InstanceExport is a portable description of an instance, without any
+// cluster-specific field (e.g. resourceVersion and status), which can be used
+// to recreate it under another name. Certificates are listed by name only
+// and must be uploaded again after the import.
+type InstanceExport struct {
+	Name         string                     `json:"name"`
+	Team         string                     `json:"team"`
+	Plan         string                     `json:"plan"`
+	Description  string                     `json:"description,omitempty"`
+	Tags         []string                   `json:"tags,omitempty"`
+	Spec         v1alpha1.RpaasInstanceSpec `json:"spec"`
+	Blocks       []ConfigurationBlock       `json:"blocks,omitempty"`
+	Routes       []Route                    `json:"routes,omitempty"`
+	ExtraFiles   []File                     `json:"extra_files,omitempty"`
+	Certificates []string                   `json:"certificates,omitempty"`
+}
+
+// CloneArgs describes the instance created from another one. Fields of
+// CreateArgs left empty are taken from the source instance.
+type CloneArgs struct {
+	CreateArgs
+	// IncludeAppHost keeps the application bound to the source instance.
+	IncludeAppHost bool `json:"include_app_host" form:"include_app_host"`
+	// IncludeCertificates copies the source certificates to the new instance.
+	IncludeCertificates bool `json:"include_certificates" form:"include_certificates"`
+}
+
+type Autoscale struct {
+	MinReplicas *int32            `json:"min_replicas,omitempty"`
+	MaxReplicas int32             `json:"max_replicas"`
+	CPU         *int32            `json:"cpu,omitempty"`
+	Memory      *int32            `json:"memory,omitempty"`
+	Metrics     []AutoscaleMetric `json:"metrics,omitempty"`
+}
+
+// AutoscaleMetric describes a metric (other than the CPU and memory
+// utilization shortcuts) used to scale an instance. Type must be one of
+// "resource", "pods" or "external".
+type AutoscaleMetric struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target int32  `json:"target"`
+}
+
+// ClientAuth holds the PEM-encoded CA bundle trusted to issue the client
+// certificates and whether presenting one is "required" (default) or
+// "optional".
+type ClientAuth struct {
+	CABundle string `json:"ca_bundle" form:"ca_bundle"`
+	Verify   string `json:"verify,omitempty" form:"verify"`
+}
+
+// TLSConfig holds the PEM-encoded DH parameters and the ECDH curves, in
+// order of preference, used on the TLS handshakes.
+type TLSConfig struct {
+	DHParam    string   `json:"dhparam,omitempty" form:"dhparam"`
+	ECDHCurves []string `json:"ecdh_curves,omitempty" form:"ecdh_curves"`
+}
+
+// ScaleToZero holds whether an idle instance is scaled down to zero replicas
+// and how long, in seconds, it must be idle before that. A zero idle timeout
+// uses the default one.
+type ScaleToZero struct {
+	Enabled     bool  `json:"enabled" form:"enabled"`
+	IdleTimeout int32 `json:"idle_timeout,omitempty" form:"idle_timeout"`
+}
+
+// PodDisruptionBudget holds either the minimum number of available pods or
+// the maximum number of unavailable ones, as an integer or a percentage
+// (e.g. "1" or "50%").
+type PodDisruptionBudget struct {
+	MinAvailable   string `json:"min_available,omitempty" form:"min_available"`
+	MaxUnavailable string `json:"max_unavailable,omitempty" form:"max_unavailable"`
+}
+
+// Compression holds the response compression settings of an instance.
+type Compression struct {
+	Enabled   bool     `json:"enabled" form:"enabled"`
+	MinLength int      `json:"min_length" form:"min_length"`
+	MimeTypes []string `json:"mime_types,omitempty" form:"mime_types"`
+	Brotli    bool     `json:"brotli" form:"brotli"`
+}
+
+// GarbageCollectedObject identifies a stale object reclaimed (or, on dry
+// runs, to be reclaimed) by GarbageCollect.
+type GarbageCollectedObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// AccessLog holds the access log sampling and filtering of an instance.
+type AccessLog struct {
+	SampleRate        int  `json:"sample_rate" form:"sample_rate"`
+	ExcludeSuccessful bool `json:"exclude_successful" form:"exclude_successful"`
+}
+
+// RateLimit holds the requests and connections limits applied to each client
+// address of an instance. Zero values disable the respective limit.
+type RateLimit struct {
+	ZoneSize          string `json:"zone_size" form:"zone_size"`
+	RequestsPerSecond int    `json:"requests_per_second" form:"requests_per_second"`
+	Burst             int    `json:"burst" form:"burst"`
+	Connections       int    `json:"connections" form:"connections"`
+}
+
+type AutoscaleStatus struct {
+	MinReplicas     int32                   `json:"min_replicas"`
+	MaxReplicas     int32                   `json:"max_replicas"`
+	CurrentReplicas int32                   `json:"current_replicas"`
+	DesiredReplicas int32                   `json:"desired_replicas"`
+	Metrics         []AutoscaleMetricStatus `json:"metrics,omitempty"`
+}
+
+type AutoscaleMetricStatus struct {
+	Name    string `json:"name"`
+	Current string `json:"current,omitempty"`
+	Target  string `json:"target,omitempty"`
 }
 
 type BindAppArgs struct {
@@ -107,29 +497,232 @@ type BindAppArgs struct {
 }
 
 type CacheManager interface {
-	PurgeCache(host, path string, preservePath bool) error
+	PurgeCache(host, path string, preservePath bool, variants nginxManager.CacheKeyVariants) error
+}
+
+type ReloadChecker interface {
+	LoadedConfigID(host string) (string, error)
+}
+
+type VersionChecker interface {
+	Version(host string) (string, error)
+}
+
+type DestinationChecker interface {
+	CheckDestination(host, destination string) (time.Duration, error)
 }
 
+// DestinationCheck is the outcome of connecting to a route destination from
+// Pod, one of the instance pods. Latency is only set when it's Reachable.
+type DestinationCheck struct {
+	Destination string  `json:"destination"`
+	Pod         string  `json:"pod"`
+	Reachable   bool    `json:"reachable"`
+	LatencyMs   float64 `json:"latency_ms,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// ReloadStatus tells whether the pods of an instance have loaded its latest
+// configuration, identified by ConfigID. Applied is only true when all of
+// them did.
+type ReloadStatus struct {
+	ConfigID string                     `json:"config_id"`
+	Applied  bool                       `json:"applied"`
+	Pods     map[string]PodReloadStatus `json:"pods"`
+}
+
+type PodReloadStatus struct {
+	Reloaded bool   `json:"reloaded"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NginxInfo tells which NGINX image an instance is configured to run and,
+// when any of its pods is running, the version reported by Pod.
+type NginxInfo struct {
+	Image   string `json:"image,omitempty"`
+	Version string `json:"version,omitempty"`
+	Pod     string `json:"pod,omitempty"`
+}
+
+// PurgeCacheArgs describes which cached objects should be purged.
+// QueryStringVariants must match the proxy_cache_key used by the instance,
+// otherwise no cached object is going to be found.
 type PurgeCacheArgs struct {
-	Path         string `json:"path" form:"path"`
-	PreservePath bool   `json:"preserve_path" form:"preserve_path"`
+	Path                string `json:"path" form:"path"`
+	PreservePath        bool   `json:"preserve_path" form:"preserve_path"`
+	QueryStringVariants bool   `json:"query_string_variants" form:"query_string_variants"`
+}
+
+// InstanceConfig is a desired-state bundle of an instance's configuration
+// which can be validated as a whole before being applied.
+type InstanceConfig struct {
+	Blocks       []ConfigurationBlock `json:"blocks,omitempty"`
+	Routes       []Route              `json:"routes,omitempty"`
+	Certificates []CertificateData    `json:"certificates,omitempty"`
+	Tags         []string             `json:"tags,omitempty"`
+}
+
+// CertificateData is a PEM-encoded certificate and key pair stored under
+// Name, as validated in an InstanceConfig or sent to UpdateCertificates.
+type CertificateData struct {
+	Name        string `json:"name"`
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+}
+
+// CertificateResult is the outcome of a certificate of a batch. Certificates
+// with Error set were left out of the batch.
+type CertificateResult struct {
+	Name     string   `json:"name"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
 }
 
 type RpaasManager interface {
 	ConfigurationBlockHandler
 	ExtraFileHandler
 	RouteHandler
+	MapHandler
 
-	UpdateCertificate(ctx context.Context, instance, name string, cert tls.Certificate) error
-	CreateInstance(ctx context.Context, args CreateArgs) error
+	// UpdateCertificate stores the certificate into the instance. When the
+	// certificate does not cover the instance host, the returned warnings
+	// describe the mismatch; in strict mode it's a ValidationError instead.
+	UpdateCertificate(ctx context.Context, instance, name string, cert tls.Certificate, strict bool) ([]string, error)
+	// DeriveCertificateName names the certificate after its primary DNS
+	// name, so nameless certificates don't replace each other.
+	DeriveCertificateName(ctx context.Context, instance string, cert tls.Certificate) (string, error)
+	// UpdateCertificates stores a batch of certificates into the instance at
+	// once. Invalid certificates are reported in their results, without
+	// preventing the others from being stored, while duplicated names in
+	// the batch reject it as a whole.
+	UpdateCertificates(ctx context.Context, instance string, certs []CertificateData, strict bool) ([]CertificateResult, error)
+	ListCertificateNames(ctx context.Context, instance string) ([]string, error)
+	// GetCertificates lists the certificates of the instance, telling which
+	// one the default server uses.
+	GetCertificates(ctx context.Context, instance string) ([]CertificateInfo, error)
+	// UpdateDefaultCertificate sets the certificate served to the clients
+	// whose SNI matches no server name. An empty name restores the
+	// "default" certificate.
+	UpdateDefaultCertificate(ctx context.Context, instance, name string) error
+	// UpdateClientAuth requires the HTTPS clients of the instance to present
+	// a certificate issued by one of the CAs in the bundle.
+	UpdateClientAuth(ctx context.Context, instance string, clientAuth ClientAuth) error
+	DeleteClientAuth(ctx context.Context, instance string) error
+	GetTLSConfig(ctx context.Context, instance string) (*TLSConfig, error)
+	// UpdateTLSConfig replaces the DH parameters and the ECDH curves of the
+	// instance.
+	UpdateTLSConfig(ctx context.Context, instance string, tlsConfig TLSConfig) error
+	DeleteTLSConfig(ctx context.Context, instance string) error
+	// CreateInstance creates the instance, returning it. In dry-run mode,
+	// the instance is only validated and built.
+	CreateInstance(ctx context.Context, args CreateArgs) (*v1alpha1.RpaasInstance, error)
+	// PreviewService returns the Service spec an instance created with the
+	// given arguments would have, without creating anything.
+	PreviewService(ctx context.Context, args CreateArgs) (*nginxv1alpha1.NginxService, error)
 	DeleteInstance(ctx context.Context, name string) error
-	UpdateInstance(ctx context.Context, name string, args UpdateInstanceArgs) error
+	// WaitInstanceTeardown blocks until the pods and Services of a deleted
+	// instance are gone, returning a TimeoutError listing the remaining
+	// ones when they outlive timeout.
+	WaitInstanceTeardown(ctx context.Context, name string, timeout time.Duration) error
+	// GarbageCollect deletes the ConfigMaps and Secrets labeled for the
+	// instance that its spec no longer references, returning them. With
+	// dryRun, nothing is deleted.
+	GarbageCollect(ctx context.Context, name string, dryRun bool) ([]GarbageCollectedObject, error)
+	// UpdateInstance updates the instance, returning it. In dry-run mode,
+	// the changes are only validated and applied to the returned instance.
+	UpdateInstance(ctx context.Context, name string, args UpdateInstanceArgs) (*v1alpha1.RpaasInstance, error)
+	// UpdateInstanceMetadata changes only the description and tags of an
+	// instance, keeping any plan-override tag already set.
+	UpdateInstanceMetadata(ctx context.Context, name string, args UpdateInstanceMetadataArgs) error
 	GetInstance(ctx context.Context, name string) (*v1alpha1.RpaasInstance, error)
+	// GetInstanceMetadata returns the description, tags, team and owner
+	// of an instance, parsed from its annotations.
+	GetInstanceMetadata(ctx context.Context, name string) (*InstanceMetadata, error)
+	// GetInstanceTags returns the instance tags, parsing the "key=value"
+	// ones.
+	GetInstanceTags(ctx context.Context, name string) (*InstanceTags, error)
 	GetInstanceAddress(ctx context.Context, name string) (string, error)
 	GetInstanceStatus(ctx context.Context, name string) (PodStatusMap, error)
+	// GetInstancesStatus returns the pod statuses of several instances at
+	// once, by instance name. Instances not found are left out of the result.
+	GetInstancesStatus(ctx context.Context, names []string) (map[string]PodStatusMap, error)
+	// GetNginxStatus returns the status summary of the instance's Nginx
+	// object, or nil when it wasn't created yet.
+	GetNginxStatus(ctx context.Context, name string) (*NginxStatus, error)
+	// GetReloadStatus checks whether each pod of the instance is serving its
+	// latest configuration or failed to reload, still serving an older one.
+	GetReloadStatus(ctx context.Context, name string) (*ReloadStatus, error)
+	// GetInstanceNginxInfo returns the NGINX image configured by the plan
+	// (or its override) and the version actually run by the instance pods.
+	GetInstanceNginxInfo(ctx context.Context, name string) (*NginxInfo, error)
+	// GetInstanceResources returns the names of the resources referenced by
+	// the instance, so clients don't need to infer them.
+	GetInstanceResources(ctx context.Context, name string) (*InstanceResources, error)
+	// GetInstancePlanResources returns the resource requests and limits of
+	// the instance's plan, with the ones overridden by the instance applied.
+	GetInstancePlanResources(ctx context.Context, name string) (*corev1.ResourceRequirements, error)
+	// ExportInstance returns a portable description of the instance.
+	ExportInstance(ctx context.Context, name string) (*InstanceExport, error)
+	// ImportInstance creates a new instance named name from an exported one,
+	// validating it with the same rules used on creation.
+	ImportInstance(ctx context.Context, name string, export InstanceExport) error
+	// CloneInstance creates a new instance with the configuration of the
+	// source one. Bound application and certificates aren't copied unless
+	// asked to.
+	CloneInstance(ctx context.Context, source string, args CloneArgs) error
 	Scale(ctx context.Context, name string, replicas int32) error
+	RestartInstance(ctx context.Context, name string) error
+	// SyncInstance asks the operator to reconcile the instance, e.g. after
+	// out-of-band changes on the resources it references. It doesn't wait
+	// for the reconciliation.
+	SyncInstance(ctx context.Context, name string) error
+	// SetTeam transfers the instance to another team, updating only the
+	// team owner labels and annotation.
+	SetTeam(ctx context.Context, name, team string) error
+	// RepairLabels restores the labels set on the instance, its Service and
+	// pod template on creation, fixing any drift.
+	RepairLabels(ctx context.Context, name string) error
+	UpdateServerTokens(ctx context.Context, name string, hide bool) error
+	UpdateProxyProtocol(ctx context.Context, name string, enabled bool) error
+	// UpdateExternalHostname sets the DNS name published for the instance,
+	// which is unrelated to the bound application's host. An empty hostname
+	// removes it.
+	UpdateExternalHostname(ctx context.Context, name, hostname string) error
+	// UpdateRejectUnknownHosts toggles whether requests for hosts other
+	// than the instance's Ingress host are rejected.
+	UpdateRejectUnknownHosts(ctx context.Context, name string, enabled bool) error
+	// UpdateRequestID toggles whether NGINX propagates, generating it when
+	// missing, the X-Request-ID header to the upstreams and access log.
+	UpdateRequestID(ctx context.Context, name string, enabled bool) error
+	// UpdateHTTP3 toggles whether NGINX serves HTTP/3 (QUIC) along with
+	// HTTPS.
+	UpdateHTTP3(ctx context.Context, name string, enabled bool) error
+	// UpdateHealthCheck sets the path and status of the health check
+	// location. Empty values restore the defaults.
+	UpdateHealthCheck(ctx context.Context, name, path string, status int) error
+	UpdateCompression(ctx context.Context, name string, compression Compression) error
+	UpdateAccessLog(ctx context.Context, name string, accessLog AccessLog) error
+	UpdateRateLimit(ctx context.Context, name string, rateLimit RateLimit) error
+	// UpdateStatusCallback sets the HTTPS URL notified on status changes of
+	// the instance. An empty URL disables the notifications.
+	UpdateStatusCallback(ctx context.Context, name, url string) error
+	UpdateAutoscale(ctx context.Context, name string, autoscale Autoscale) error
+	GetAutoscaleStatus(ctx context.Context, name string) (*AutoscaleStatus, error)
+	// UpdateScaleToZero enables or disables scaling the instance to zero
+	// replicas while it's idle.
+	UpdateScaleToZero(ctx context.Context, name string, scaleToZero ScaleToZero) error
+	UpdatePodDisruptionBudget(ctx context.Context, name string, pdb PodDisruptionBudget) error
+	DeletePodDisruptionBudget(ctx context.Context, name string) error
 	GetPlans(ctx context.Context) ([]v1alpha1.RpaasPlan, error)
+	// GetPlan returns the plan, or the default one when name is empty. When
+	// flavor is set, the plan spec is merged with the flavor's, just like
+	// on instances created with that flavor.
+	GetPlan(ctx context.Context, name, flavor string) (*v1alpha1.RpaasPlan, error)
 	BindApp(ctx context.Context, instanceName string, args BindAppArgs) error
 	UnbindApp(ctx context.Context, instanceName string) error
 	PurgeCache(ctx context.Context, instanceName string, args PurgeCacheArgs) (int, error)
+	// ValidateInstanceConfig runs every validation against the desired
+	// configuration, returning all the problems found instead of stopping
+	// at the first one.
+	ValidateInstanceConfig(ctx context.Context, instanceName string, desired InstanceConfig) ([]ValidationError, error)
 }
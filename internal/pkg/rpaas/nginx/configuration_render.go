@@ -6,9 +6,14 @@ package nginx
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
 )
@@ -38,7 +43,28 @@ type rpaasConfigurationRenderer struct {
 func (r *rpaasConfigurationRenderer) Render(c ConfigurationData) (string, error) {
 	buffer := &bytes.Buffer{}
 	err := r.t.Execute(buffer, c)
-	return buffer.String(), err
+	if err != nil {
+		return buffer.String(), err
+	}
+	hash := sha256.Sum256(buffer.Bytes())
+	return strings.Replace(buffer.String(), configIDPlaceholder, hex.EncodeToString(hash[:8]), -1), nil
+}
+
+// configIDPlaceholder is replaced, once the configuration is rendered, by
+// the hash of the whole configuration. NGINX serves it on the manage port, so
+// it tells which configuration a pod has actually loaded.
+const configIDPlaceholder = "__RPAAS_CONFIG_ID__"
+
+var configIDRegexp = regexp.MustCompile(`location = ` + defaultConfigIDLocation + ` \{\s*default_type "text/plain";\s*return 200 "([0-9a-f]+)";`)
+
+// ConfigID returns the identifier of the rendered configuration, or an empty
+// string when it doesn't serve one (e.g. plans with a custom main template).
+func ConfigID(config string) string {
+	matches := configIDRegexp.FindStringSubmatch(config)
+	if len(matches) < 2 {
+		return ""
+	}
+	return matches[1]
 }
 
 func NewRpaasConfigurationRenderer(cb ConfigurationBlocks) ConfigurationRenderer {
@@ -56,6 +82,34 @@ func NewRpaasConfigurationRenderer(cb ConfigurationBlocks) ConfigurationRenderer
 	return &rpaasConfigurationRenderer{t: finalTemplate}
 }
 
+func hideServerTokens(instance *v1alpha1.RpaasInstance) bool {
+	if instance == nil || instance.Spec.HideServerTokens == nil {
+		return true
+	}
+	return *instance.Spec.HideServerTokens
+}
+
+// DefaultHealthcheckPath is the location used by the pod probes when the
+// instance doesn't set one.
+const DefaultHealthcheckPath = "/_nginx_healthcheck"
+
+// HealthcheckPath returns the location used by the pod probes.
+func HealthcheckPath(instance *v1alpha1.RpaasInstance) string {
+	if instance == nil || instance.Spec.HealthCheckPath == "" {
+		return DefaultHealthcheckPath
+	}
+	return instance.Spec.HealthCheckPath
+}
+
+func healthcheckStatus(instance *v1alpha1.RpaasInstance) int {
+	if instance == nil {
+		return 0
+	}
+	return instance.Spec.HealthCheckStatus
+}
+
+var locationKeyReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
 func buildLocationKey(prefix, path string) string {
 	if path == "" {
 		panic("cannot build location key due path is missing")
@@ -67,29 +121,246 @@ func buildLocationKey(prefix, path string) string {
 
 	key := "root"
 	if path != "/" {
-		key = strings.ReplaceAll(path, "/", "_")
+		key = locationKeyReplacer.ReplaceAllString(path, "_")
 	}
 
 	return fmt.Sprintf("%s%s", prefix, key)
 }
 
+var variableNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// buildVariableName is like buildLocationKey, but the result is also a valid
+// NGINX variable name, which can't hold dots or hyphens.
+func buildVariableName(prefix, path string) string {
+	return variableNameReplacer.ReplaceAllString(buildLocationKey(prefix, path), "_")
+}
+
 func hasRootPath(locations []v1alpha1.Location) bool {
 	for _, location := range locations {
-		if location.Path == "/" {
+		if location.Path == "/" && locationModifier(location) == "" {
 			return true
 		}
 	}
 	return false
 }
 
+func locationModifier(location v1alpha1.Location) string {
+	switch location.MatchType {
+	case v1alpha1.LocationMatchTypeExact:
+		return "="
+	case v1alpha1.LocationMatchTypeRegex:
+		return "~"
+	}
+	return ""
+}
+
+// loadBalancingDirective returns the upstream directive for the location's
+// load balancing method, which is empty for the NGINX's default (round-robin).
+func loadBalancingDirective(location v1alpha1.Location) string {
+	switch location.LoadBalancing {
+	case v1alpha1.LoadBalancingLeastConn, v1alpha1.LoadBalancingIPHash:
+		return string(location.LoadBalancing)
+	}
+	return ""
+}
+
+func hasDestination(location v1alpha1.Location) bool {
+	return location.Destination != "" || len(location.Destinations) > 0
+}
+
+// locationDestinations returns the members of the location's upstream pool,
+// handling the single Destination as a one-member pool.
+func locationDestinations(location v1alpha1.Location) []v1alpha1.LocationDestination {
+	if len(location.Destinations) > 0 {
+		return location.Destinations
+	}
+	return []v1alpha1.LocationDestination{{Address: location.Destination}}
+}
+
+// destinationHost returns the Host header sent to the location's upstream,
+// which is the address of the first pool member.
+func destinationHost(location v1alpha1.Location) string {
+	return locationDestinations(location)[0].Address
+}
+
+// destinationHostname returns the location's destination host without the
+// port, as expected for the TLS server name (SNI).
+func destinationHostname(location v1alpha1.Location) string {
+	host := destinationHost(location)
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+	return host
+}
+
+// canaryMatchVariable returns the NGINX variable holding the header or
+// cookie which sends requests to the canary destination.
+func canaryMatchVariable(canary v1alpha1.LocationCanary) string {
+	if canary.Cookie != "" {
+		return "$cookie_" + canary.Cookie
+	}
+	return "$http_" + strings.ToLower(strings.Replace(canary.Header, "-", "_", -1))
+}
+
+// useUpstream tells whether the location must proxy to its upstream block
+// instead of to the destination address directly.
+func useUpstream(location v1alpha1.Location) bool {
+	return len(location.Destinations) > 0 || loadBalancingDirective(location) != ""
+}
+
+// nginxDuration converts a Go duration (e.g. "1m30s") into NGINX's time
+// format, using milliseconds when it isn't a whole number of seconds.
+func nginxDuration(s string) (string, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return "", err
+	}
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second), nil
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond), nil
+}
+
+var nginxStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quoteString wraps s in double quotes, so NGINX reads it as a single
+// argument whatever it holds.
+func quoteString(s string) string {
+	return `"` + nginxStringEscaper.Replace(s) + `"`
+}
+
+// accessLogSamplePercentage converts a "1 in N" sample rate into the
+// percentage of requests split_clients should pick.
+func accessLogSamplePercentage(rate int32) string {
+	return fmt.Sprintf("%.2f%%", 100/float64(rate))
+}
+
+// limitZoneName returns the name of the limit_req/limit_conn zone of kind
+// ("req" or "conn") for the given scope. Instance-wide zones use the
+// "instance" scope, which cannot collide with location scopes as these are
+// built from paths, always starting with "_".
+func limitZoneName(kind, scope string) string {
+	if scope == "instance" {
+		return fmt.Sprintf("rpaas_limit_%s_instance", kind)
+	}
+	return buildLocationKey(fmt.Sprintf("rpaas_limit_%s_", kind), scope)
+}
+
+// serverName returns the host the main server is restricted to when the
+// instance rejects unknown hosts, or an empty string otherwise.
+func serverName(instance v1alpha1.RpaasInstance) string {
+	if !instance.Spec.RejectUnknownHosts || instance.Spec.Ingress == nil {
+		return ""
+	}
+	return instance.Spec.Ingress.Host
+}
+
+// DefaultCertificateName returns the name of the certificate served by the
+// default server.
+func DefaultCertificateName(instance v1alpha1.RpaasInstance) string {
+	if instance.Spec.DefaultCertificate == "" {
+		return v1alpha1.CertificateNameDefault
+	}
+	return instance.Spec.DefaultCertificate
+}
+
+// serverCertificateName returns the name of the certificate served by the
+// main server. Unless it's restricted to the instance host, the main server
+// is the default one.
+func serverCertificateName(instance v1alpha1.RpaasInstance) string {
+	if serverName(instance) != "" {
+		return v1alpha1.CertificateNameDefault
+	}
+	return DefaultCertificateName(instance)
+}
+
+// tlsVersions lists the TLS versions supported by NGINX, from the oldest to
+// the newest.
+var tlsVersions = []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+var defaultTLSProtocols = []string{"TLSv1", "TLSv1.1", "TLSv1.2"}
+
+func tlsVersionIndex(version string) (int, error) {
+	for i, v := range tlsVersions {
+		if v == version {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown TLS version %q: must be one of %s", version, strings.Join(tlsVersions, ", "))
+}
+
+// ValidateTLSVersion ensures the TLS version is supported by NGINX.
+func ValidateTLSVersion(version string) error {
+	_, err := tlsVersionIndex(version)
+	return err
+}
+
+// CompareTLSVersions returns -1, 0 or +1 whether the TLS version a is older
+// than, the same as or newer than b.
+func CompareTLSVersions(a, b string) (int, error) {
+	i, err := tlsVersionIndex(a)
+	if err != nil {
+		return 0, err
+	}
+	j, err := tlsVersionIndex(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case i < j:
+		return -1, nil
+	case i > j:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func sslProtocols(config *v1alpha1.NginxConfig) (string, error) {
+	if config == nil || config.TLSMinVersion == "" {
+		return strings.Join(defaultTLSProtocols, " "), nil
+	}
+	i, err := tlsVersionIndex(config.TLSMinVersion)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(tlsVersions[i:], " "), nil
+}
+
 var templateFuncs = template.FuncMap(map[string]interface{}{
-	"buildLocationKey":   buildLocationKey,
-	"hasRootPath":        hasRootPath,
-	"toLower":            strings.ToLower,
-	"toUpper":            strings.ToUpper,
-	"managePort":         managePort,
-	"purgeLocationMatch": purgeLocationMatch,
-	"vtsLocationMatch":   vtsLocationMatch,
+	"accessLogSamplePercentage": accessLogSamplePercentage,
+	"boolValue":                 v1alpha1.BoolValue,
+	"buildLocationKey":          buildLocationKey,
+	"buildVariableName":         buildVariableName,
+	"canaryMatchVariable":       canaryMatchVariable,
+	"configIDLocation":          configIDLocation,
+	"configIDPlaceholder":       func() string { return configIDPlaceholder },
+	"defaultCertificateName":    DefaultCertificateName,
+	"destinationCheckLocation":  destinationCheckLocation,
+	"destinationHost":           destinationHost,
+	"destinationHostname":       destinationHostname,
+	"destinations":              locationDestinations,
+	"hasDestination":            hasDestination,
+	"hasRootPath":               hasRootPath,
+	"healthcheckPath":           HealthcheckPath,
+	"healthcheckStatus":         healthcheckStatus,
+	"hideServerTokens":          hideServerTokens,
+	"join":                      strings.Join,
+	"limitZoneName":             limitZoneName,
+	"loadBalancing":             loadBalancingDirective,
+	"locationModifier":          locationModifier,
+	"nginxDuration":             nginxDuration,
+	"toLower":                   strings.ToLower,
+	"toUpper":                   strings.ToUpper,
+	"useUpstream":               useUpstream,
+	"managePort":                managePort,
+	"purgeLocationMatch":        purgeLocationMatch,
+	"quote":                     quoteString,
+	"regexQuote":                regexp.QuoteMeta,
+	"serverCertificateName":     serverCertificateName,
+	"serverName":                serverName,
+	"sslProtocols":              sslProtocols,
+	"versionLocation":           versionLocation,
+	"vtsLocationMatch":          vtsLocationMatch,
 })
 
 var defaultMainTemplate = template.Must(template.New("main").
@@ -111,6 +382,9 @@ var rawNginxConfiguration = `
 
 user {{with .Config.User}}{{.}}{{else}}nginx{{end}};
 worker_processes {{with .Config.WorkerProcesses}}{{.}}{{else}}1{{end}};
+{{- range $name, $value := $instance.Spec.Env}}
+env {{printf "%s=%s" $name $value | quote}};
+{{- end}}
 
 include modules/*.conf;
 
@@ -123,7 +397,7 @@ events {
 http {
     include       mime.types;
     default_type  application/octet-stream;
-    server_tokens off;
+    server_tokens {{if hideServerTokens $instance}}off{{else}}on{{end}};
 
     sendfile          on;
     keepalive_timeout 65;
@@ -136,6 +410,13 @@ http {
     }
 {{end}}
 
+{{if $instance.Spec.RequestID}}
+    map $http_x_request_id $rpaas_request_id {
+        default $request_id;
+        "~."    $http_x_request_id;
+    }
+{{end}}
+
     map $http_x_real_ip $real_ip_final {
         default $remote_addr;
         "~."    $http_x_real_ip;
@@ -159,16 +440,61 @@ http {
         '${upstream_response_length}\t${upstream_response_time}\t${request_uri}\t'
 {{if .Config.RequestIDEnabled}}
         'Agent:\t${http_user_agent}\t$request_id_final\t'
+{{else if $instance.Spec.RequestID}}
+        'Agent:\t${http_user_agent}\t$rpaas_request_id\t'
 {{else}}
         'Agent:\t${http_user_agent}\t'
 {{end}}
         'Fwd:\t${http_x_forwarded_for}';
 
+{{with $instance.Spec.AccessLog}}
+{{if gt .SampleRate 1}}
+    split_clients $request_id $rpaas_access_log_sampled {
+        {{accessLogSamplePercentage .SampleRate}} 1;
+        *       0;
+    }
+{{end}}
+
+    map $status $rpaas_access_log_status {
+{{if .ExcludeSuccessful}}
+        ~^2     0;
+{{end}}
+        default 1;
+    }
+
+    map "{{if gt .SampleRate 1}}$rpaas_access_log_sampled{{else}}1{{end}}:$rpaas_access_log_status" $rpaas_access_log {
+        "1:1"   1;
+        default 0;
+    }
+{{end}}
+
+{{with $instance.Spec.RateLimit}}
+{{if .RequestsPerSecond}}
+    limit_req_zone $binary_remote_addr zone={{limitZoneName "req" "instance"}}:{{.ZoneSize}} rate={{.RequestsPerSecond}}r/s;
+    limit_req_status 429;
+{{end}}
+{{if .Connections}}
+    limit_conn_zone $binary_remote_addr zone={{limitZoneName "conn" "instance"}}:{{.ZoneSize}};
+    limit_conn_status 429;
+{{end}}
+{{end}}
+
+{{range $instance.Spec.Maps}}
+    map {{.Source}} ${{.Variable}} {
+{{with .Default}}
+        default "{{.}}";
+{{end}}
+{{range .Entries}}
+        {{.Key}} "{{.Value}}";
+{{end}}
+    }
+{{end}}
+
 {{if .Config.SyslogEnabled}}
-    access_log syslog:server={{.Config.SyslogServerAddress}},facility={{with .Config.SyslogFacility}}{{.}}{{else}}local6{{end}},tag={{with .Config.SyslogTag}}{{.}}{{else}}rpaas{{end}} rpaas_combined;
+    access_log syslog:server={{.Config.SyslogServerAddress}},facility={{with .Config.SyslogFacility}}{{.}}{{else}}local6{{end}},tag={{with .Config.SyslogTag}}{{.}}{{else}}rpaas{{end}} rpaas_combined{{if $instance.Spec.AccessLog}} if=$rpaas_access_log{{end}};
     error_log syslog:server={{.Config.SyslogServerAddress}},facility={{with .Config.SyslogFacility}}{{.}}{{else}}local6{{end}},tag={{with .Config.SyslogTag}}{{.}}{{else}}rpaas{{end}};
 {{else}}
-    access_log /dev/stdout rpaas_combined;
+    access_log /dev/stdout rpaas_combined{{if $instance.Spec.AccessLog}} if=$rpaas_access_log{{end}};
     error_log  /dev/stderr;
 {{end}}
 
@@ -177,6 +503,25 @@ http {
     proxy_temp_path  {{.Config.CachePath}}/nginx_temp 1 2;
 {{end}}
 
+{{with $instance.Spec.Compression}}
+{{if .Enabled}}
+    gzip                on;
+    gzip_buffers        128 4k;
+    gzip_comp_level     5;
+    gzip_http_version   1.0;
+    gzip_min_length     {{.MinLength}};
+    gzip_proxied        any;
+    gzip_vary           on;
+    gzip_types          {{join .MimeTypes " "}};
+{{if .Brotli}}
+    brotli              on;
+    brotli_min_length   {{.MinLength}};
+    brotli_types        {{join .MimeTypes " "}};
+{{end}}
+{{else}}
+    gzip                off;
+{{end}}
+{{else}}
     gzip                on;
     gzip_buffers        128 4k;
     gzip_comp_level     5;
@@ -188,6 +533,7 @@ http {
                         application/json application/rss+xml
                         application/xml application/x-javascript
                         text/css text/javascript text/plain text/xml;
+{{end}}
 
 {{if .Config.VTSEnabled}}
     vhost_traffic_status_zone;
@@ -201,11 +547,45 @@ http {
 {{end}}
 
 {{range $_, $location := $instance.Spec.Locations}}
-{{if $location.Destination}}
+{{if hasDestination $location}}
     upstream {{buildLocationKey "" $location.Path}} {
-        server {{$location.Destination}};
+        {{with loadBalancing $location}}{{.}};{{end}}
+        {{range destinations $location}}
+        server {{.Address}}{{with .Weight}} weight={{.}}{{end}}{{with .MaxFails}} max_fails={{.}}{{end}}{{with .FailTimeout}} fail_timeout={{nginxDuration .}}{{end}};
+        {{end}}
+        {{with $config.UpstreamKeepalive}}keepalive {{.}};{{end}}
+    }
+{{with $location.Canary}}
+
+    upstream {{buildLocationKey "rpaas_canary_" $location.Path}} {
+        server {{.Destination}};
         {{with $config.UpstreamKeepalive}}keepalive {{.}};{{end}}
     }
+
+    map {{canaryMatchVariable .}} ${{buildVariableName "rpaas_canary_upstream_" $location.Path}} {
+        "{{.Value}}" {{buildLocationKey "rpaas_canary_" $location.Path}};
+        default {{buildLocationKey "" $location.Path}};
+    }
+
+    map {{canaryMatchVariable .}} ${{buildVariableName "rpaas_canary_host_" $location.Path}} {
+        "{{.Value}}" "{{.Destination}}";
+        default "{{destinationHost $location}}";
+    }
+{{end}}
+{{end}}
+{{end}}
+
+{{range $_, $location := $instance.Spec.Locations}}
+{{with $location.CORS}}
+    map $http_origin ${{buildVariableName "rpaas_cors_origin_" $location.Path}} {
+{{range .AllowOrigins}}
+{{if eq . "*"}}
+        default "*";
+{{else}}
+        "{{.}}" $http_origin;
+{{end}}
+{{end}}
+    }
 {{end}}
 {{end}}
 
@@ -222,6 +602,36 @@ http {
 		server {
 			listen {{ managePort }};
 
+			location = {{ configIDLocation }} {
+				default_type "text/plain";
+				return 200 "{{ configIDPlaceholder }}";
+			}
+
+			location = {{ versionLocation }} {
+				default_type "text/plain";
+				return 200 "$nginx_version";
+			}
+
+			location = {{ destinationCheckLocation }} {
+				default_type "text/plain";
+				content_by_lua_block {
+					local sock = ngx.socket.tcp()
+					sock:settimeout(500)
+					ngx.update_time()
+					local start = ngx.now()
+					local ok, err = sock:connect(ngx.var.arg_host, tonumber(ngx.var.arg_port))
+					if not ok then
+						ngx.status = ngx.HTTP_BAD_GATEWAY
+						ngx.say(err)
+						return
+					end
+					ngx.update_time()
+					local elapsed = ngx.now() - start
+					sock:close()
+					ngx.say(string.format("%.3f", elapsed))
+				}
+			}
+
 {{if .Config.CacheEnabled}}
       location ~ {{ purgeLocationMatch }} {
         proxy_cache_purge  rpaas $1$is_args$args;
@@ -237,28 +647,135 @@ http {
 
 		}
 
+{{if $instance.Spec.ProxyProtocol}}
+    server {
+        listen 127.0.0.1:8080;
+{{if $instance.Spec.Certificates}}
+{{$certificate := defaultCertificateName $instance}}
+{{range $_, $item := $instance.Spec.Certificates.Items}}
+{{if and (eq $item.CertificateField (print $certificate ".crt")) (eq $item.KeyField (print $certificate ".key"))}}
+        listen 127.0.0.1:8443 ssl;
+
+        ssl_certificate     certs/{{with $item.CertificatePath}}{{.}}{{else}}{{$item.CertificateField}}{{end}};
+        ssl_certificate_key certs/{{with $item.KeyPath}}{{.}}{{else}}{{$item.KeyField}}{{end}};
+{{end}}
+{{end}}
+{{end}}
+
+        location = {{healthcheckPath $instance}} {
+            default_type "text/plain";
+{{with healthcheckStatus $instance}}
+            return {{.}} "WORKING";
+{{else}}
+            echo "WORKING";
+{{end}}
+        }
+
+        location / {
+            return 444;
+        }
+    }
+{{end}}
+
+{{with serverName $instance}}
     server {
-        listen 8080 default_server{{with .Config.HTTPListenOptions}} {{.}}{{end}};
+        listen 8080 default_server{{if $instance.Spec.ProxyProtocol}} proxy_protocol{{end}}{{with $.Config.HTTPListenOptions}} {{.}}{{end}};
+{{if $instance.Spec.ProxyProtocol}}
+        set_real_ip_from 0.0.0.0/0;
+        set_real_ip_from ::/0;
+        real_ip_header proxy_protocol;
+{{end}}
+{{if $instance.Spec.Certificates}}
+{{$certificate := defaultCertificateName $instance}}
+{{range $_, $item := $instance.Spec.Certificates.Items}}
+{{if and (eq $item.CertificateField (print $certificate ".crt")) (eq $item.KeyField (print $certificate ".key"))}}
+        listen 8443 ssl default_server{{if $instance.Spec.ProxyProtocol}} proxy_protocol{{end}}{{with $.Config.HTTPSListenOptions}} {{.}}{{end}};
+{{if $instance.Spec.HTTP3}}
+        listen 8443 quic default_server reuseport;
+{{end}}
+
+        ssl_certificate     certs/{{with $item.CertificatePath}}{{.}}{{else}}{{$item.CertificateField}}{{end}};
+        ssl_certificate_key certs/{{with $item.KeyPath}}{{.}}{{else}}{{$item.KeyField}}{{end}};
+{{end}}
+{{end}}
+{{end}}
+
+        location = {{healthcheckPath $instance}} {
+            default_type "text/plain";
+{{with healthcheckStatus $instance}}
+            return {{.}} "WORKING";
+{{else}}
+            echo "WORKING";
+{{end}}
+        }
+
+        location / {
+            return 444;
+        }
+    }
+{{end}}
+
+    server {
+{{with serverName $instance}}
+        listen 8080{{if $instance.Spec.ProxyProtocol}} proxy_protocol{{end}};
+        server_name {{.}};
+{{else}}
+        listen 8080 default_server{{if $instance.Spec.ProxyProtocol}} proxy_protocol{{end}}{{with .Config.HTTPListenOptions}} {{.}}{{end}};
+{{end}}
+{{range $instance.Spec.ExtraPorts}}
+        listen {{.}}{{if $instance.Spec.ProxyProtocol}} proxy_protocol{{end}};
+{{end}}
+{{if $instance.Spec.ProxyProtocol}}
+        set_real_ip_from 0.0.0.0/0;
+        set_real_ip_from ::/0;
+        real_ip_header proxy_protocol;
+{{end}}
 
 {{if $instance.Spec.Certificates }}
 {{ $opts := .Config.HTTPSListenOptions }}
+{{ $certificate := serverCertificateName $instance }}
 {{range $index, $item := $instance.Spec.Certificates.Items}}
-{{if and (eq $item.CertificateField "default.crt") (eq $item.KeyField "default.key")}}
-        listen 8443 ssl{{with $opts}} {{.}}{{end}};
+{{if and (eq $item.CertificateField (print $certificate ".crt")) (eq $item.KeyField (print $certificate ".key"))}}
+        listen 8443 ssl{{if $instance.Spec.ProxyProtocol}} proxy_protocol{{end}}{{if not (serverName $instance)}}{{with $opts}} {{.}}{{end}}{{end}};
+{{if $instance.Spec.HTTP3}}
+        listen 8443 quic{{if not (serverName $instance)}} reuseport{{end}};
+        add_header Alt-Svc 'h3=":443"; ma=86400' always;
+{{end}}
 
         ssl_certificate     certs/{{with $item.CertificatePath}}{{.}}{{else}}{{$item.CertificateField}}{{end}};
         ssl_certificate_key certs/{{with $item.KeyPath}}{{.}}{{else}}{{$item.KeyField}}{{end}};
 
-        ssl_protocols TLSv1 TLSv1.1 TLSv1.2;
+        ssl_protocols {{sslProtocols $config}};
         ssl_ciphers 'ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES256-SHA384:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES128-SHA:DHE-RSA-AES256-SHA256:DHE-RSA-AES256-SHA:ECDHE-ECDSA-DES-CBC3-SHA:ECDHE-RSA-DES-CBC3-SHA:EDH-RSA-DES-CBC3-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:!DSS';
         ssl_prefer_server_ciphers on;
         ssl_session_cache shared:SSL:200m;
         ssl_session_timeout 1h;
+{{with $instance.Spec.TLS}}
+{{with .DHParamFile}}
+        ssl_dhparam /etc/nginx/extra_files/{{.}};
+{{end}}
+{{with .ECDHCurves}}
+        ssl_ecdh_curve {{join . ":"}};
+{{end}}
+{{end}}
+{{with $instance.Spec.ClientAuth}}
+
+        ssl_client_certificate /etc/nginx/extra_files/{{.CAFile}};
+        ssl_verify_client {{if eq .Verify "optional"}}optional{{else}}on{{end}};
+{{end}}
 {{end}}
 {{end}}
 {{end}}
 
         port_in_redirect off;
+{{with $instance.Spec.RateLimit}}
+{{if .RequestsPerSecond}}
+        limit_req zone={{limitZoneName "req" "instance"}}{{with .Burst}} burst={{.}} nodelay{{end}};
+{{end}}
+{{if .Connections}}
+        limit_conn {{limitZoneName "conn" "instance"}} {{.Connections}};
+{{end}}
+{{end}}
 {{if .Config.CacheEnabled}}
         proxy_cache rpaas;
         proxy_cache_use_stale error timeout updating invalid_header http_500 http_502 http_503 http_504;
@@ -272,30 +789,113 @@ http {
         proxy_send_timeout 20s;
         proxy_http_version 1.1;
 
-        location = /_nginx_healthcheck {
+        location = {{healthcheckPath $instance}} {
             default_type "text/plain";
+{{with healthcheckStatus $instance}}
+            return {{.}} "WORKING";
+{{else}}
             echo "WORKING";
+{{end}}
         }
 
 {{if $instance.Spec.Locations}}
 {{range $_, $location := $instance.Spec.Locations}}
-        location {{$location.Path}} {
+        location {{with locationModifier $location}}{{.}} {{end}}{{$location.Path}} {
+{{with $location.CORS}}
+{{$origin := buildVariableName "rpaas_cors_origin_" $location.Path}}
+            if ($request_method = 'OPTIONS') {
+                add_header Access-Control-Allow-Origin ${{$origin}} always;
+{{if .AllowCredentials}}
+                add_header Access-Control-Allow-Credentials "true" always;
+{{end}}
+{{with .AllowMethods}}
+                add_header Access-Control-Allow-Methods "{{join . ", "}}" always;
+{{end}}
+{{with .AllowHeaders}}
+                add_header Access-Control-Allow-Headers "{{join . ", "}}" always;
+{{end}}
+{{with .MaxAge}}
+                add_header Access-Control-Max-Age {{.}} always;
+{{end}}
+                add_header Vary Origin always;
+                return 204;
+            }
+            add_header Access-Control-Allow-Origin ${{$origin}} always;
+{{if .AllowCredentials}}
+            add_header Access-Control-Allow-Credentials "true" always;
+{{end}}
+            add_header Vary Origin always;
+{{if and $instance.Spec.HTTP3 $instance.Spec.Certificates}}
+            add_header Alt-Svc 'h3=":443"; ma=86400' always;
+{{end}}
+{{end}}
+{{with $location.Auth}}
+            auth_basic "Restricted";
+            auth_basic_user_file /etc/nginx/extra_files/{{.UserFile}};
+{{end}}
+{{range $location.DenyCIDRs}}
+            deny {{.}};
+{{end}}
+{{range $location.AllowCIDRs}}
+            allow {{.}};
+{{end}}
+{{if $location.AllowCIDRs}}
+            deny all;
+{{end}}
 
-{{if $location.Destination}}
+{{if hasDestination $location}}
 {{if $location.ForceHTTPS}}
             if ($scheme = 'http') {
                 return 301 https://$http_host$request_uri;
             }
 {{end}}
-            proxy_set_header Host {{$location.Destination}};
+            proxy_set_header Host {{if $location.Canary}}${{buildVariableName "rpaas_canary_host_" $location.Path}}{{else}}{{destinationHost $location}}{{end}};
             proxy_set_header X-Real-IP $remote_addr;
             proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
             proxy_set_header X-Forwarded-Proto $scheme;
             proxy_set_header X-Forwarded-Host $host;
+{{if $instance.Spec.RequestID}}
+            proxy_set_header X-Request-ID $rpaas_request_id;
+{{end}}
             proxy_set_header Connection "";
             proxy_http_version 1.1;
-            proxy_pass http://{{$location.Destination}}/;
-            proxy_redirect ~^http://{{buildLocationKey "" $location.Path}}(:\d+)?/(.*)$ {{$location.Path}}$2;
+{{with $location.Buffering}}
+            proxy_buffering {{if boolValue .}}on{{else}}off{{end}};
+{{end}}
+{{$scheme := "http"}}
+{{if or $location.UpstreamClientCertificate $location.UpstreamTLSVerify}}
+{{$scheme = "https"}}
+{{with $location.UpstreamClientCertificate}}
+            proxy_ssl_certificate     certs/{{.}}.crt;
+            proxy_ssl_certificate_key certs/{{.}}.key;
+{{end}}
+{{if $location.UpstreamTLSVerify}}
+            proxy_ssl_verify on;
+            proxy_ssl_trusted_certificate {{with $location.UpstreamTrustedCA}}/etc/nginx/extra_files/{{.}}{{else}}/etc/ssl/certs/ca-certificates.crt{{end}};
+{{end}}
+            proxy_ssl_server_name on;
+            proxy_ssl_name {{destinationHostname $location}};
+{{end}}
+{{$upstream := destinationHost $location}}
+{{if useUpstream $location}}{{$upstream = buildLocationKey "" $location.Path}}{{end}}
+{{if $location.Canary}}
+{{if ne (locationModifier $location) "~"}}
+            rewrite "^{{regexQuote $location.Path}}(.*)$" /$1 break;
+{{end}}
+            proxy_pass {{$scheme}}://${{buildVariableName "rpaas_canary_upstream_" $location.Path}};
+{{if ne (locationModifier $location) "~"}}
+            proxy_redirect ~^{{$scheme}}://{{buildLocationKey "" $location.Path}}(:\d+)?/(.*)$ {{$location.Path}}$2;
+            proxy_redirect ~^{{$scheme}}://{{buildLocationKey "rpaas_canary_" $location.Path}}(:\d+)?/(.*)$ {{$location.Path}}$2;
+{{end}}
+{{else if eq (locationModifier $location) "~"}}
+            proxy_pass {{$scheme}}://{{$upstream}};
+{{else}}
+            proxy_pass {{$scheme}}://{{$upstream}}/;
+            proxy_redirect ~^{{$scheme}}://{{buildLocationKey "" $location.Path}}(:\d+)?/(.*)$ {{$location.Path}}$2;
+{{end}}
+{{else if $location.StaticFile}}
+            root /etc/nginx/extra_files;
+            try_files /{{$location.StaticFile}} =404;
 {{else}}
 {{with $location.Content.Value}}
             {{.}}
@@ -313,6 +913,9 @@ http {
             proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
             proxy_set_header X-Forwarded-Proto $scheme;
             proxy_set_header X-Forwarded-Host $host;
+{{if $instance.Spec.RequestID}}
+            proxy_set_header X-Request-ID $rpaas_request_id;
+{{end}}
             proxy_set_header Connection "";
             proxy_http_version 1.1;
             proxy_pass http://rpaas_default_upstream/;
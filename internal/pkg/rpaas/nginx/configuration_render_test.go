@@ -11,9 +11,11 @@ import (
 	"github.com/stretchr/testify/require"
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestRpaasConfigurationRenderer_Render(t *testing.T) {
+	auto := intstr.FromString("auto")
 	testCases := []struct {
 		renderer  ConfigurationRenderer
 		data      ConfigurationData
@@ -33,10 +35,44 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 				assert.Regexp(t, `access_log /dev/stdout rpaas_combined;`, result)
 				assert.Regexp(t, `error_log  /dev/stderr;`, result)
 				assert.Regexp(t, `listen 8080 default_server;`, result)
+				assert.Regexp(t, `server_tokens off;`, result)
 				assert.Regexp(t, `location = /_nginx_healthcheck {\n\s+default_type "text/plain";\n\s+echo "WORKING";\n\s+}`, result)
+				assert.Regexp(t, `location = /nginx-version {\n\s+default_type "text/plain";\n\s+return 200 "\$nginx_version";\n\s+}`, result)
+				assert.Regexp(t, `location = /check-destination {\n\s+default_type "text/plain";\n\s+content_by_lua_block {\n\s+local sock = ngx.socket.tcp\(\)`, result)
 				assert.Regexp(t, `location / {\n\s+default_type "text/plain";\n\s+echo "instance not bound yet";\n\s+}`, result)
 			},
 		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					WorkerProcesses:   &auto,
+					WorkerConnections: 4096,
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `worker_processes auto;`, result)
+				assert.Regexp(t, `worker_connections 4096;`, result)
+				assert.NotRegexp(t, `(?m)^env `, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Env: map[string]string{"LUA_PATH": "/etc/nginx/lua/?.lua", "API_URL": "http://api.example.com", "GREETING": `say "hi"; \o/`},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, result, "worker_processes 1;\nenv \"API_URL=http://api.example.com\";\nenv \"GREETING=say \\\"hi\\\"; \\\\o/\";\nenv \"LUA_PATH=/etc/nginx/lua/?.lua\";\n")
+			},
+		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
@@ -51,104 +87,882 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					RequestIDEnabled: v1alpha1.Bool(true),
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						HealthCheckPath:   "/healthz",
+						HealthCheckStatus: 204,
+					},
 				},
-				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				require.NoError(t, err)
-				assert.Regexp(t, `uuid4 \$request_id_uuid;`, result)
-				assert.Regexp(t, `map \$http_x_request_id \$request_id_final {\n\s+default \$request_id_uuid;\n\s+"~\."\s+\$http_x_request_id;\n\s*}`, result)
+				assert.Regexp(t, `location = /healthz {\n\s+default_type "text/plain";\n\s+return 204 "WORKING";\n\s+}`, result)
+				assert.NotContains(t, result, "/_nginx_healthcheck")
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					CacheEnabled:     v1alpha1.Bool(true),
-					CachePath:        "/path/to/cache/dir",
-					CacheInactive:    "12h",
-					CacheLoaderFiles: 1000,
-					CacheSize:        "300m",
-					CacheZoneSize:    "100m",
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						HideServerTokens: v1alpha1.Bool(false),
+					},
 				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `server_tokens on;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config:   &v1alpha1.NginxConfig{},
 				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				require.NoError(t, err)
-				assert.Regexp(t, `proxy_cache_path /path/to/cache/dir/nginx levels=1:2 keys_zone=rpaas:100m inactive=12h max_size=300m loader_files=1000;`, result)
-				assert.Regexp(t, `proxy_temp_path  /path/to/cache/dir/nginx_temp 1 2;`, result)
-				assert.Regexp(t, `proxy_cache rpaas;`, result)
-				assert.Regexp(t, `proxy_cache_use_stale error timeout updating invalid_header http_500 http_502 http_503 http_504;`, result)
-				assert.Regexp(t, `proxy_cache_lock on;`, result)
-				assert.Regexp(t, `proxy_cache_lock_age 60s;`, result)
-				assert.Regexp(t, `proxy_cache_lock_timeout 60s;`, result)
-				assert.Regexp(t, `proxy_cache_key \$scheme\$request_uri;`, result)
+				assert.Regexp(t, `gzip\s+on;`, result)
+				assert.Regexp(t, `gzip_min_length\s+20;`, result)
+				assert.NotContains(t, result, "brotli")
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					SyslogEnabled:       v1alpha1.Bool(true),
-					SyslogServerAddress: "syslog.server.example.com",
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Compression: &v1alpha1.RpaasInstanceCompressionSpec{
+							Enabled:   true,
+							MinLength: 1024,
+							MimeTypes: []string{"application/json", "text/css"},
+							Brotli:    true,
+						},
+					},
 				},
-				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				require.NoError(t, err)
-				assert.Regexp(t, `access_log syslog:server=syslog.server.example.com,facility=local6,tag=rpaas rpaas_combined;`, result)
-				assert.Regexp(t, `error_log syslog:server=syslog.server.example.com,facility=local6,tag=rpaas;`, result)
+				assert.Regexp(t, `gzip\s+on;`, result)
+				assert.Regexp(t, `gzip_min_length\s+1024;`, result)
+				assert.Regexp(t, `gzip_types\s+application/json text/css;`, result)
+				assert.Regexp(t, `brotli\s+on;`, result)
+				assert.Regexp(t, `brotli_min_length\s+1024;`, result)
+				assert.Regexp(t, `brotli_types\s+application/json text/css;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Compression: &v1alpha1.RpaasInstanceCompressionSpec{},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `gzip\s+off;`, result)
+				assert.NotContains(t, result, "gzip_types")
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
 				Config: &v1alpha1.NginxConfig{
-					SyslogEnabled:       v1alpha1.Bool(true),
-					SyslogServerAddress: "syslog.server.example.com",
-					SyslogFacility:      "local1",
-					SyslogTag:           "my-tag",
+					HTTPListenOptions:  "backlog=2048",
+					HTTPSListenOptions: "http2",
+				},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						ProxyProtocol: true,
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "my-instance-certificates",
+							Items: []nginxv1alpha1.TLSSecretItem{
+								{CertificateField: "default.crt", KeyField: "default.key"},
+							},
+						},
+					},
 				},
-				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				require.NoError(t, err)
-				assert.Regexp(t, `access_log syslog:server=syslog.server.example.com,facility=local1,tag=my-tag rpaas_combined;`, result)
-				assert.Regexp(t, `error_log syslog:server=syslog.server.example.com,facility=local1,tag=my-tag;`, result)
+				assert.Regexp(t, `listen 8080 default_server proxy_protocol backlog=2048;`, result)
+				assert.Regexp(t, `listen 8443 ssl proxy_protocol http2;`, result)
+				assert.Regexp(t, `set_real_ip_from 0.0.0.0/0;\n\s+set_real_ip_from ::/0;\n\s+real_ip_header proxy_protocol;`, result)
+				assert.Regexp(t, `server {\n\s+listen 127.0.0.1:8080;\n+\s+listen 127.0.0.1:8443 ssl;\n+\s+ssl_certificate\s+certs/default.crt;\n\s+ssl_certificate_key certs/default.key;\n+\s+location = /_nginx_healthcheck {\n\s+default_type "text/plain";\n+\s+echo "WORKING";`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						ProxyProtocol:      true,
+						RejectUnknownHosts: true,
+						Ingress:            &v1alpha1.RpaasInstanceIngressSpec{Host: "my-instance.example.com"},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 127.0.0.1:8080;\n+\s+location = /_nginx_healthcheck {`, result)
+				assert.NotRegexp(t, `listen 127.0.0.1:8443`, result)
+				assert.Regexp(t, `listen 8080 default_server proxy_protocol;\n+\s+set_real_ip_from 0.0.0.0/0;\n\s+set_real_ip_from ::/0;\n\s+real_ip_header proxy_protocol;\s+location = /_nginx_healthcheck {`, result)
+				assert.Regexp(t, `listen 8080 proxy_protocol;\n\s+server_name my-instance.example.com;[^}]+set_real_ip_from ::/0;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						ExtraPorts: []int32{9000, 9443},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8080 default_server;\n\s+listen 9000;\n\s+listen 9443;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						HTTP3: true,
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "my-instance-certificates",
+							Items:      []nginxv1alpha1.TLSSecretItem{{CertificateField: "default.crt", KeyField: "default.key"}},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8443 ssl;\n\s+listen 8443 quic reuseport;\n\s+add_header Alt-Svc 'h3=":443"; ma=86400' always;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Host:      "app.tsuru.example.com",
+						RequestID: true,
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `map \$http_x_request_id \$rpaas_request_id {\n\s+default \$request_id;\n\s+"~."\s+\$http_x_request_id;\n\s+}`, result)
+				assert.Regexp(t, `'Agent:\\t\$\{http_user_agent\}\\t\$rpaas_request_id\\t'`, result)
+				assert.Regexp(t, `location / {\n\s+proxy_set_header Host app.tsuru.example.com;(\n.*)+\n\s+proxy_set_header X-Request-ID \$rpaas_request_id;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						AccessLog: &v1alpha1.RpaasInstanceAccessLogSpec{SampleRate: 3, ExcludeSuccessful: true},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `split_clients \$request_id \$rpaas_access_log_sampled {\s+33.33% 1;\s+\*\s+0;\s+}`, result)
+				assert.Regexp(t, `map \$status \$rpaas_access_log_status {\s+~\^2\s+0;\s+default 1;\s+}`, result)
+				assert.Regexp(t, `map "\$rpaas_access_log_sampled:\$rpaas_access_log_status" \$rpaas_access_log {`, result)
+				assert.Regexp(t, `access_log /dev/stdout rpaas_combined if=\$rpaas_access_log;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						AccessLog: &v1alpha1.RpaasInstanceAccessLogSpec{ExcludeSuccessful: true},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.NotContains(t, result, "split_clients")
+				assert.Regexp(t, `map "1:\$rpaas_access_log_status" \$rpaas_access_log {`, result)
+				assert.Regexp(t, `access_log /dev/stdout rpaas_combined if=\$rpaas_access_log;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						RateLimit: &v1alpha1.RpaasInstanceRateLimitSpec{ZoneSize: "10m", RequestsPerSecond: 100, Burst: 20, Connections: 10},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `limit_req_zone \$binary_remote_addr zone=rpaas_limit_req_instance:10m rate=100r/s;`, result)
+				assert.Regexp(t, `limit_conn_zone \$binary_remote_addr zone=rpaas_limit_conn_instance:10m;`, result)
+				assert.Regexp(t, `port_in_redirect off;\n+\s+limit_req zone=rpaas_limit_req_instance burst=20 nodelay;\n+\s+limit_conn rpaas_limit_conn_instance 10;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						RateLimit: &v1alpha1.RpaasInstanceRateLimitSpec{ZoneSize: "1m", Connections: 5},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.NotContains(t, result, "limit_req")
+				assert.Regexp(t, `limit_conn rpaas_limit_conn_instance 5;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Maps: []v1alpha1.RpaasInstanceMap{
+							{
+								Variable: "backend",
+								Source:   "$http_x_version",
+								Default:  "v1",
+								Entries: []v1alpha1.RpaasInstanceMapEntry{
+									{Key: "~*^beta", Value: "v2"},
+								},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `map \$http_x_version \$backend {\n+\s+default "v1";\n+\s+~\*\^beta "v2";\n+\s+}`, result)
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
 				Config: &v1alpha1.NginxConfig{
-					CacheEnabled: v1alpha1.Bool(true),
+					HTTPListenOptions:  "backlog=2048",
+					HTTPSListenOptions: "http2",
+				},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						RejectUnknownHosts: true,
+						Ingress:            &v1alpha1.RpaasInstanceIngressSpec{Host: "my-instance.example.com"},
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "my-instance-certificates",
+							Items: []nginxv1alpha1.TLSSecretItem{
+								{CertificateField: "default.crt", KeyField: "default.key"},
+							},
+						},
+					},
 				},
-				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				require.NoError(t, err)
-				assert.Regexp(t, `\s+location \~ \^/purge/(.+) {
-\s+proxy_cache_purge  rpaas \$1\$is_args\$args;
-`, result)
+				assert.Regexp(t, `listen 8080 default_server backlog=2048;\s+listen 8443 ssl default_server http2;`, result)
+				assert.Regexp(t, `location / {\s+return 444;\s+}`, result)
+				assert.Regexp(t, `listen 8080;\s+server_name my-instance.example.com;`, result)
+				assert.Regexp(t, `listen 8443 ssl;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						RejectUnknownHosts: true,
+						Ingress:            &v1alpha1.RpaasInstanceIngressSpec{Host: "my-instance.example.com"},
+						DefaultCertificate: "legacy",
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "my-instance-certificates",
+							Items: []nginxv1alpha1.TLSSecretItem{
+								{CertificateField: "default.crt", KeyField: "default.key"},
+								{CertificateField: "legacy.crt", KeyField: "legacy.key"},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8443 ssl default_server;\s+ssl_certificate\s+certs/legacy.crt;\s+ssl_certificate_key certs/legacy.key;`, result)
+				assert.Regexp(t, `listen 8443 ssl;\s+ssl_certificate\s+certs/default.crt;\s+ssl_certificate_key certs/default.key;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						DefaultCertificate: "legacy",
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "my-instance-certificates",
+							Items: []nginxv1alpha1.TLSSecretItem{
+								{CertificateField: "default.crt", KeyField: "default.key"},
+								{CertificateField: "legacy.crt", KeyField: "legacy.key"},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8443 ssl;\s+ssl_certificate\s+certs/legacy.crt;\s+ssl_certificate_key certs/legacy.key;`, result)
+				assert.NotContains(t, result, "certs/default.crt")
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Ingress: &v1alpha1.RpaasInstanceIngressSpec{Host: "my-instance.example.com"},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8080 default_server;`, result)
+				assert.NotContains(t, result, "server_name")
+				assert.NotContains(t, result, "return 444;")
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					RequestIDEnabled: v1alpha1.Bool(true),
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `uuid4 \$request_id_uuid;`, result)
+				assert.Regexp(t, `map \$http_x_request_id \$request_id_final {\n\s+default \$request_id_uuid;\n\s+"~\."\s+\$http_x_request_id;\n\s*}`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					CacheEnabled:     v1alpha1.Bool(true),
+					CachePath:        "/path/to/cache/dir",
+					CacheInactive:    "12h",
+					CacheLoaderFiles: 1000,
+					CacheSize:        "300m",
+					CacheZoneSize:    "100m",
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `proxy_cache_path /path/to/cache/dir/nginx levels=1:2 keys_zone=rpaas:100m inactive=12h max_size=300m loader_files=1000;`, result)
+				assert.Regexp(t, `proxy_temp_path  /path/to/cache/dir/nginx_temp 1 2;`, result)
+				assert.Regexp(t, `proxy_cache rpaas;`, result)
+				assert.Regexp(t, `proxy_cache_use_stale error timeout updating invalid_header http_500 http_502 http_503 http_504;`, result)
+				assert.Regexp(t, `proxy_cache_lock on;`, result)
+				assert.Regexp(t, `proxy_cache_lock_age 60s;`, result)
+				assert.Regexp(t, `proxy_cache_lock_timeout 60s;`, result)
+				assert.Regexp(t, `proxy_cache_key \$scheme\$request_uri;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					SyslogEnabled:       v1alpha1.Bool(true),
+					SyslogServerAddress: "syslog.server.example.com",
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `access_log syslog:server=syslog.server.example.com,facility=local6,tag=rpaas rpaas_combined;`, result)
+				assert.Regexp(t, `error_log syslog:server=syslog.server.example.com,facility=local6,tag=rpaas;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					SyslogEnabled:       v1alpha1.Bool(true),
+					SyslogServerAddress: "syslog.server.example.com",
+					SyslogFacility:      "local1",
+					SyslogTag:           "my-tag",
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `access_log syslog:server=syslog.server.example.com,facility=local1,tag=my-tag rpaas_combined;`, result)
+				assert.Regexp(t, `error_log syslog:server=syslog.server.example.com,facility=local1,tag=my-tag;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					CacheEnabled: v1alpha1.Bool(true),
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `\s+location \~ \^/purge/(.+) {
+\s+proxy_cache_purge  rpaas \$1\$is_args\$args;
+`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					VTSEnabled: v1alpha1.Bool(true),
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `vhost_traffic_status_zone;`, result)
+				assert.Regexp(t, `\s+location /status {
+\s+vhost_traffic_status_display;
+\s+vhost_traffic_status_display_format prometheus;
+`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "secret-name",
+							Items: []nginxv1alpha1.TLSSecretItem{
+								{
+									CertificateField: "default.crt",
+									KeyField:         "default.key",
+								},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8443 ssl;`, result)
+				assert.Regexp(t, `ssl_certificate\s+certs/default.crt;`, result)
+				assert.Regexp(t, `ssl_certificate_key certs/default.key;`, result)
+				assert.Regexp(t, `ssl_protocols TLSv1 TLSv1.1 TLSv1.2;`, result)
+				assert.Regexp(t, `ssl_ciphers 'ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES256-SHA384:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES128-SHA:DHE-RSA-AES256-SHA256:DHE-RSA-AES256-SHA:ECDHE-ECDSA-DES-CBC3-SHA:ECDHE-RSA-DES-CBC3-SHA:EDH-RSA-DES-CBC3-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:!DSS';`, result)
+				assert.Regexp(t, `ssl_prefer_server_ciphers on;`, result)
+				assert.Regexp(t, `ssl_session_cache shared:SSL:200m;`, result)
+				assert.Regexp(t, `ssl_session_timeout 1h;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					HTTPSListenOptions: "http2",
+				},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "secret-name",
+							Items: []nginxv1alpha1.TLSSecretItem{
+								{
+									CertificateField: "default.crt",
+									CertificatePath:  "custom_certificate_name.crt",
+									KeyField:         "default.key",
+									KeyPath:          "custom_key_name.key",
+								},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `listen 8443 ssl http2;`, result)
+				assert.Regexp(t, `ssl_certificate\s+certs/custom_certificate_name.crt;`, result)
+				assert.Regexp(t, `ssl_certificate_key certs/custom_key_name.key;`, result)
+				assert.Regexp(t, `ssl_protocols TLSv1 TLSv1.1 TLSv1.2;`, result)
+				assert.Regexp(t, `ssl_ciphers 'ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES256-SHA384:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES128-SHA:DHE-RSA-AES256-SHA256:DHE-RSA-AES256-SHA:ECDHE-ECDSA-DES-CBC3-SHA:ECDHE-RSA-DES-CBC3-SHA:EDH-RSA-DES-CBC3-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:!DSS';`, result)
+				assert.Regexp(t, `ssl_prefer_server_ciphers on;`, result)
+				assert.Regexp(t, `ssl_session_cache shared:SSL:200m;`, result)
+				assert.Regexp(t, `ssl_session_timeout 1h;`, result)
+				assert.NotContains(t, result, "ssl_verify_client")
+				assert.NotContains(t, result, "ssl_dhparam")
+				assert.NotContains(t, result, "ssl_ecdh_curve")
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "secret-name",
+							Items:      []nginxv1alpha1.TLSSecretItem{{CertificateField: "default.crt", KeyField: "default.key"}},
+						},
+						ClientAuth: &v1alpha1.RpaasInstanceClientAuthSpec{CAFile: "client-auth/ca.crt", Verify: v1alpha1.ClientVerifyRequired},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `ssl_session_timeout 1h;\n+\s+ssl_client_certificate /etc/nginx/extra_files/client-auth/ca.crt;\n+\s+ssl_verify_client on;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Certificates: &nginxv1alpha1.TLSSecret{
+							SecretName: "secret-name",
+							Items:      []nginxv1alpha1.TLSSecretItem{{CertificateField: "default.crt", KeyField: "default.key"}},
+						},
+						TLS: &v1alpha1.RpaasInstanceTLSSpec{
+							DHParamFile: "tls/dhparam.pem",
+							ECDHCurves:  []string{"X25519", "secp384r1"},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `ssl_session_timeout 1h;\n+\s+ssl_dhparam /etc/nginx/extra_files/tls/dhparam.pem;\n+\s+ssl_ecdh_curve X25519:secp384r1;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{
+				RootBlock:   "# some custom conf at root context",
+				HttpBlock:   "# some custom conf at http context",
+				ServerBlock: "# some custom conf at server context",
+			}),
+			data: ConfigurationData{
+				Config:   &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `# some custom conf at root context`, result)
+				assert.Regexp(t, `# some custom conf at http context`, result)
+				assert.Regexp(t, `# some custom conf at server context`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{
+				RootBlock: "# I can use any block as a golang template: {{.Config.User}};",
+			}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					User: "another-user",
+				},
+				Instance: &v1alpha1.RpaasInstance{},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `# I can use any block as a golang template: another-user;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Host: "app1.tsuru.example.com",
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				assert.NoError(t, err)
+				assert.Regexp(t, `\s+upstream rpaas_default_upstream {
+\s+server app1.tsuru.example.com;
+\s+}`, result)
+
+				assert.Regexp(t, `\s+location / {
+\s+proxy_set_header Host app1.tsuru.example.com;
+\s+proxy_set_header X-Real-IP \$remote_addr;
+\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
+\s+proxy_set_header X-Forwarded-Proto \$scheme;
+\s+proxy_set_header X-Forwarded-Host \$host;
+\s+proxy_set_header Connection "";
+\s+proxy_http_version 1.1;
+\s+proxy_pass http://rpaas_default_upstream/;
+\s+proxy_redirect ~\^http://rpaas_default_upstream\(:\\d\+\)\?/\(\.\*\)\$ /\$2;
+\s+}`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path:        "/path1",
+								Destination: "app1.tsuru.example.com",
+							},
+							{
+								Path:        "/path2",
+								Destination: "app2.tsuru.example.com",
+								ForceHTTPS:  true,
+							},
+							{
+								Path:        "/path4",
+								Destination: "app4.tsuru.example.com",
+								Buffering:   v1alpha1.Bool(false),
+							},
+							{
+								Path: "/path3",
+								Content: &v1alpha1.Value{
+									Value: "# My custom configuration for /path3",
+								},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				assert.NoError(t, err)
+				assert.Regexp(t, `\s+upstream rpaas_locations__path1 {
+\s+server app1.tsuru.example.com;
+\s+}`, result)
+				assert.Regexp(t, `\s+upstream rpaas_locations__path2 {
+\s+server app2.tsuru.example.com;
+\s+}`, result)
+
+				assert.Regexp(t, `location /path1 {\n+
+\s+proxy_set_header Host app1\.tsuru\.example\.com;
+\s+proxy_set_header X-Real-IP \$remote_addr;
+\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
+\s+proxy_set_header X-Forwarded-Proto \$scheme;
+\s+proxy_set_header X-Forwarded-Host \$host;
+\s+proxy_set_header Connection "";
+\s+proxy_http_version 1.1;
+\s+proxy_pass http://app1.tsuru.example.com/;
+\s+proxy_redirect ~\^http://rpaas_locations__path1\(:\\d\+\)\?/\(\.\*\)\$ /path1\$2;\n+
+\s+}`, result)
+
+				assert.Regexp(t, `location /path2 {\n+
+\s+if \(\$scheme = 'http'\) {
+\s+return 301 https://\$http_host\$request_uri;
+\s+}\n+
+\s+proxy_set_header Host app2\.tsuru\.example\.com;
+\s+proxy_set_header X-Real-IP \$remote_addr;
+\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
+\s+proxy_set_header X-Forwarded-Proto \$scheme;
+\s+proxy_set_header X-Forwarded-Host \$host;
+\s+proxy_set_header Connection "";
+\s+proxy_http_version 1.1;
+\s+proxy_pass http://app2.tsuru.example.com/;
+\s+proxy_redirect ~\^http://rpaas_locations__path2\(:\\d\+\)\?/\(\.\*\)\$ /path2\$2;\n+
+\s+}`, result)
+				assert.NotRegexp(t, `location /path1 {[^}]+proxy_buffering`, result)
+
+				assert.Regexp(t, `location /path4 {\n+
+\s+proxy_set_header Host app4\.tsuru\.example\.com;
+\s+proxy_set_header X-Real-IP \$remote_addr;
+\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
+\s+proxy_set_header X-Forwarded-Proto \$scheme;
+\s+proxy_set_header X-Forwarded-Host \$host;
+\s+proxy_set_header Connection "";
+\s+proxy_http_version 1.1;\n+
+\s+proxy_buffering off;\n+
+\s+proxy_pass http://app4.tsuru.example.com/;
+\s+proxy_redirect ~\^http://rpaas_locations__path4\(:\\d\+\)\?/\(\.\*\)\$ /path4\$2;\n+
+\s+}`, result)
+
+				assert.Regexp(t, `location /path3 {\n+
+\s+# My custom configuration for /path3\n+
+\s+}`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{
+					UpstreamKeepalive: 32,
+				},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Host: "app.tsuru.example.com",
+						Locations: []v1alpha1.Location{
+							{
+								Path:        "/",
+								Destination: "app1.tsuru.example.com",
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				assert.NoError(t, err)
+				assert.Regexp(t, `\s+upstream rpaas_default_upstream {
+\s+server app.tsuru.example.com;
+\s+keepalive 32;
+\s+}`, result)
+				assert.Regexp(t, `\s+upstream rpaas_locations_root {
+\s+server app1.tsuru.example.com;
+\s+keepalive 32;
+\s+}`, result)
+
+				assert.Regexp(t, `location / {\n+
+\s+proxy_set_header Host app1\.tsuru\.example\.com;
+\s+proxy_set_header X-Real-IP \$remote_addr;
+\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
+\s+proxy_set_header X-Forwarded-Proto \$scheme;
+\s+proxy_set_header X-Forwarded-Host \$host;
+\s+proxy_set_header Connection "";
+\s+proxy_http_version 1.1;
+\s+proxy_pass http://app1.tsuru.example.com/;
+\s+proxy_redirect ~\^http://rpaas_locations_root\(:\\d\+\)\?/\(\.\*\)\$ /\$2;
+\s+}`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path:        "/",
+								MatchType:   v1alpha1.LocationMatchTypeExact,
+								Destination: "app1.tsuru.example.com",
+							},
+							{
+								Path:        "/prefix",
+								MatchType:   v1alpha1.LocationMatchTypePrefix,
+								Destination: "app2.tsuru.example.com",
+							},
+							{
+								Path:        `^/api/v[0-9]+/`,
+								MatchType:   v1alpha1.LocationMatchTypeRegex,
+								Destination: "app3.tsuru.example.com",
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				assert.NoError(t, err)
+				assert.Regexp(t, `location = / {\n+
+[^}]+proxy_pass http://app1.tsuru.example.com/;
+\s+proxy_redirect ~\^http://rpaas_locations_root\(:\\d\+\)\?/\(\.\*\)\$ /\$2;\n+
+\s+}`, result)
+				assert.Regexp(t, `location /prefix {\n+
+[^}]+proxy_pass http://app2.tsuru.example.com/;`, result)
+				assert.Regexp(t, `location ~ \^/api/v\[0-9\]\+/ {\n+
+[^}]+proxy_pass http://app3.tsuru.example.com;\n+
+\s+}`, result)
+				assert.Regexp(t, `upstream rpaas_locations___api_v_0-9___ {`, result)
+				assert.Regexp(t, `location / {\n+\s+default_type "text/plain";`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path:          "/sticky",
+								Destination:   "app1.tsuru.example.com",
+								LoadBalancing: v1alpha1.LoadBalancingIPHash,
+							},
+							{
+								Path:          "/least",
+								MatchType:     v1alpha1.LocationMatchTypeRegex,
+								Destination:   "app2.tsuru.example.com",
+								LoadBalancing: v1alpha1.LoadBalancingLeastConn,
+							},
+							{
+								Path:          "/default",
+								Destination:   "app3.tsuru.example.com",
+								LoadBalancing: v1alpha1.LoadBalancingRoundRobin,
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				assert.NoError(t, err)
+				assert.Regexp(t, `upstream rpaas_locations__sticky {\s+ip_hash;\s+server app1.tsuru.example.com;`, result)
+				assert.Regexp(t, `location /sticky {\n+
+[^}]+proxy_pass http://rpaas_locations__sticky/;`, result)
+				assert.Regexp(t, `upstream rpaas_locations__least {\s+least_conn;\s+server app2.tsuru.example.com;`, result)
+				assert.Regexp(t, `location ~ /least {\n+
+[^}]+proxy_pass http://rpaas_locations__least;`, result)
+				assert.Regexp(t, `upstream rpaas_locations__default {\s+server app3.tsuru.example.com;`, result)
+				assert.Regexp(t, `location /default {\n+
+[^}]+proxy_pass http://app3.tsuru.example.com/;`, result)
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					VTSEnabled: v1alpha1.Bool(true),
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path:        "/admin",
+								Destination: "app1.tsuru.example.com",
+								Auth: &v1alpha1.LocationAuth{
+									UserFile: "auth/_admin.htpasswd",
+									Users:    []string{"admin"},
+								},
+							},
+							{
+								Path:        "/public",
+								Destination: "app1.tsuru.example.com",
+							},
+						},
+					},
 				},
-				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
-				require.NoError(t, err)
-				assert.Regexp(t, `vhost_traffic_status_zone;`, result)
-				assert.Regexp(t, `\s+location /status {
-\s+vhost_traffic_status_display;
-\s+vhost_traffic_status_display_format prometheus;
-`, result)
+				assert.NoError(t, err)
+				assert.Regexp(t, `location /admin {\n+\s+auth_basic "Restricted";\n\s+auth_basic_user_file /etc/nginx/extra_files/auth/_admin.htpasswd;`, result)
+				assert.Regexp(t, `location /public {\n+\s+proxy_set_header Host`, result)
+				assert.NotRegexp(t, `location /public {[^}]+auth_basic`, result)
 			},
 		},
 		{
@@ -157,94 +971,115 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 				Config: &v1alpha1.NginxConfig{},
 				Instance: &v1alpha1.RpaasInstance{
 					Spec: v1alpha1.RpaasInstanceSpec{
-						Certificates: &nginxv1alpha1.TLSSecret{
-							SecretName: "secret-name",
-							Items: []nginxv1alpha1.TLSSecretItem{
-								{
-									CertificateField: "default.crt",
-									KeyField:         "default.key",
-								},
+						Locations: []v1alpha1.Location{
+							{
+								Path:        "/internal",
+								Destination: "app1.tsuru.example.com",
+								AllowCIDRs:  []string{"10.0.0.0/8", "2001:db8::/32"},
+								DenyCIDRs:   []string{"192.168.0.0/16"},
+							},
+							{
+								Path:        "/blocked",
+								Destination: "app1.tsuru.example.com",
+								DenyCIDRs:   []string{"172.16.0.0/12"},
 							},
 						},
 					},
 				},
 			},
 			assertion: func(t *testing.T, result string, err error) {
-				require.NoError(t, err)
-				assert.Regexp(t, `listen 8443 ssl;`, result)
-				assert.Regexp(t, `ssl_certificate\s+certs/default.crt;`, result)
-				assert.Regexp(t, `ssl_certificate_key certs/default.key;`, result)
-				assert.Regexp(t, `ssl_protocols TLSv1 TLSv1.1 TLSv1.2;`, result)
-				assert.Regexp(t, `ssl_ciphers 'ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES256-SHA384:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES128-SHA:DHE-RSA-AES256-SHA256:DHE-RSA-AES256-SHA:ECDHE-ECDSA-DES-CBC3-SHA:ECDHE-RSA-DES-CBC3-SHA:EDH-RSA-DES-CBC3-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:!DSS';`, result)
-				assert.Regexp(t, `ssl_prefer_server_ciphers on;`, result)
-				assert.Regexp(t, `ssl_session_cache shared:SSL:200m;`, result)
-				assert.Regexp(t, `ssl_session_timeout 1h;`, result)
+				assert.NoError(t, err)
+				assert.Regexp(t, `location /internal {\n+\s+deny 192.168.0.0/16;\n+\s+allow 10.0.0.0/8;\n+\s+allow 2001:db8::/32;\n+\s+deny all;`, result)
+				assert.Regexp(t, `location /blocked {\n+\s+deny 172.16.0.0/12;\n+\s+proxy_set_header Host`, result)
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					HTTPSListenOptions: "http2",
-				},
+				Config: &v1alpha1.NginxConfig{},
 				Instance: &v1alpha1.RpaasInstance{
 					Spec: v1alpha1.RpaasInstanceSpec{
-						Certificates: &nginxv1alpha1.TLSSecret{
-							SecretName: "secret-name",
-							Items: []nginxv1alpha1.TLSSecretItem{
-								{
-									CertificateField: "default.crt",
-									CertificatePath:  "custom_certificate_name.crt",
-									KeyField:         "default.key",
-									KeyPath:          "custom_key_name.key",
-								},
+						Locations: []v1alpha1.Location{
+							{
+								Path:                      "/secure",
+								Destination:               "app1.tsuru.example.com:8443",
+								UpstreamClientCertificate: "upstream",
+							},
+							{
+								Path:        "/plain",
+								Destination: "app2.tsuru.example.com",
 							},
 						},
 					},
 				},
 			},
 			assertion: func(t *testing.T, result string, err error) {
-				require.NoError(t, err)
-				assert.Regexp(t, `listen 8443 ssl http2;`, result)
-				assert.Regexp(t, `ssl_certificate\s+certs/custom_certificate_name.crt;`, result)
-				assert.Regexp(t, `ssl_certificate_key certs/custom_key_name.key;`, result)
-				assert.Regexp(t, `ssl_protocols TLSv1 TLSv1.1 TLSv1.2;`, result)
-				assert.Regexp(t, `ssl_ciphers 'ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES256-SHA384:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES128-SHA:DHE-RSA-AES256-SHA256:DHE-RSA-AES256-SHA:ECDHE-ECDSA-DES-CBC3-SHA:ECDHE-RSA-DES-CBC3-SHA:EDH-RSA-DES-CBC3-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:!DSS';`, result)
-				assert.Regexp(t, `ssl_prefer_server_ciphers on;`, result)
-				assert.Regexp(t, `ssl_session_cache shared:SSL:200m;`, result)
-				assert.Regexp(t, `ssl_session_timeout 1h;`, result)
+				assert.NoError(t, err)
+				assert.Regexp(t, `location /secure {[^}]+proxy_ssl_certificate     certs/upstream.crt;\n+\s+proxy_ssl_certificate_key certs/upstream.key;\n+\s+proxy_ssl_server_name on;\n+\s+proxy_ssl_name app1.tsuru.example.com;`, result)
+				assert.Regexp(t, `location /secure {[^}]+proxy_pass https://app1.tsuru.example.com:8443/;`, result)
+				assert.Regexp(t, `location /plain {[^}]+proxy_pass http://app2.tsuru.example.com/;`, result)
+				assert.NotRegexp(t, `location /plain {[^}]+proxy_ssl_certificate`, result)
+				assert.NotRegexp(t, `location /secure {[^}]+proxy_ssl_verify`, result)
 			},
 		},
 		{
-			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{
-				RootBlock:   "# some custom conf at root context",
-				HttpBlock:   "# some custom conf at http context",
-				ServerBlock: "# some custom conf at server context",
-			}),
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config:   &v1alpha1.NginxConfig{},
-				Instance: &v1alpha1.RpaasInstance{},
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path:              "/verified",
+								Destination:       "app1.tsuru.example.com:8443",
+								UpstreamTLSVerify: true,
+								UpstreamTrustedCA: "upstream-ca.crt",
+							},
+							{
+								Path:              "/system-ca",
+								Destination:       "app2.tsuru.example.com",
+								UpstreamTLSVerify: true,
+							},
+						},
+					},
+				},
 			},
 			assertion: func(t *testing.T, result string, err error) {
-				require.NoError(t, err)
-				assert.Regexp(t, `# some custom conf at root context`, result)
-				assert.Regexp(t, `# some custom conf at http context`, result)
-				assert.Regexp(t, `# some custom conf at server context`, result)
+				assert.NoError(t, err)
+				assert.Regexp(t, `location /verified {[^}]+proxy_ssl_verify on;\n+\s+proxy_ssl_trusted_certificate /etc/nginx/extra_files/upstream-ca.crt;\n+\s+proxy_ssl_server_name on;\n+\s+proxy_ssl_name app1.tsuru.example.com;`, result)
+				assert.Regexp(t, `location /verified {[^}]+proxy_pass https://app1.tsuru.example.com:8443/;`, result)
+				assert.NotRegexp(t, `location /verified {[^}]+proxy_ssl_certificate `, result)
+				assert.Regexp(t, `location /system-ca {[^}]+proxy_ssl_trusted_certificate /etc/ssl/certs/ca-certificates.crt;`, result)
+				assert.Regexp(t, `location /system-ca {[^}]+proxy_pass https://app2.tsuru.example.com/;`, result)
 			},
 		},
 		{
-			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{
-				RootBlock: "# I can use any block as a golang template: {{.Config.User}};",
-			}),
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					User: "another-user",
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path:       "/",
+								MatchType:  v1alpha1.LocationMatchTypeExact,
+								StaticFile: "index.html",
+							},
+							{
+								Path:       "/maintenance",
+								StaticFile: "www/maintenance.html",
+								Auth:       &v1alpha1.LocationAuth{UserFile: "htpasswd_maintenance"},
+							},
+						},
+					},
 				},
-				Instance: &v1alpha1.RpaasInstance{},
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				require.NoError(t, err)
-				assert.Regexp(t, `# I can use any block as a golang template: another-user;`, result)
+				assert.Regexp(t, `location = / {\n+\s+root /etc/nginx/extra_files;\n\s+try_files /index.html =404;\n+\s+}`, result)
+				assert.Regexp(t, `location /maintenance {[^}]+auth_basic_user_file /etc/nginx/extra_files/htpasswd_maintenance;[^}]+root /etc/nginx/extra_files;\n\s+try_files /www/maintenance.html =404;`, result)
+				assert.NotRegexp(t, `location = / {[^}]+proxy_pass`, result)
+				assert.NotRegexp(t, `upstream rpaas_locations_`, result)
 			},
 		},
 		{
@@ -253,27 +1088,34 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 				Config: &v1alpha1.NginxConfig{},
 				Instance: &v1alpha1.RpaasInstance{
 					Spec: v1alpha1.RpaasInstanceSpec{
-						Host: "app1.tsuru.example.com",
+						Locations: []v1alpha1.Location{
+							{
+								Path:        "/api",
+								Destination: "api.tsuru.example.com",
+								CORS: &v1alpha1.LocationCORS{
+									AllowOrigins:     []string{"https://app.example.com", "http://localhost:3000"},
+									AllowMethods:     []string{"GET", "PUT", "DELETE"},
+									AllowHeaders:     []string{"Authorization", "X-Requested-With"},
+									AllowCredentials: true,
+									MaxAge:           600,
+								},
+							},
+							{
+								Path:       "/web-fonts",
+								StaticFile: "fonts.woff2",
+								CORS:       &v1alpha1.LocationCORS{AllowOrigins: []string{"*"}},
+							},
+						},
 					},
 				},
 			},
 			assertion: func(t *testing.T, result string, err error) {
-				assert.NoError(t, err)
-				assert.Regexp(t, `\s+upstream rpaas_default_upstream {
-\s+server app1.tsuru.example.com;
-\s+}`, result)
-
-				assert.Regexp(t, `\s+location / {
-\s+proxy_set_header Host app1.tsuru.example.com;
-\s+proxy_set_header X-Real-IP \$remote_addr;
-\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
-\s+proxy_set_header X-Forwarded-Proto \$scheme;
-\s+proxy_set_header X-Forwarded-Host \$host;
-\s+proxy_set_header Connection "";
-\s+proxy_http_version 1.1;
-\s+proxy_pass http://rpaas_default_upstream/;
-\s+proxy_redirect ~\^http://rpaas_default_upstream\(:\\d\+\)\?/\(\.\*\)\$ /\$2;
-\s+}`, result)
+				require.NoError(t, err)
+				assert.Regexp(t, `map \$http_origin \$rpaas_cors_origin__api {\n+\s+"https://app.example.com" \$http_origin;\n+\s+"http://localhost:3000" \$http_origin;\n+\s+}`, result)
+				assert.Regexp(t, `map \$http_origin \$rpaas_cors_origin__web_fonts {\n+\s+default "\*";\n+\s+}`, result)
+				assert.Regexp(t, `location /api {\n+\s+if \(\$request_method = 'OPTIONS'\) {\n\s+add_header Access-Control-Allow-Origin \$rpaas_cors_origin__api always;\n+\s+add_header Access-Control-Allow-Credentials "true" always;\n+\s+add_header Access-Control-Allow-Methods "GET, PUT, DELETE" always;\n+\s+add_header Access-Control-Allow-Headers "Authorization, X-Requested-With" always;\n+\s+add_header Access-Control-Max-Age 600 always;\n+\s+add_header Vary Origin always;\n\s+return 204;\n\s+}\n\s+add_header Access-Control-Allow-Origin \$rpaas_cors_origin__api always;\n+\s+add_header Access-Control-Allow-Credentials "true" always;\n+\s+add_header Vary Origin always;`, result)
+				assert.Regexp(t, `location /api {[^}]+}[^}]+proxy_pass http://api.tsuru.example.com/;`, result)
+				assert.Regexp(t, `location /web-fonts {\n+\s+if \(\$request_method = 'OPTIONS'\) {\n\s+add_header Access-Control-Allow-Origin \$rpaas_cors_origin__web_fonts always;\n+\s+add_header Vary Origin always;\n\s+return 204;\n\s+}\n\s+add_header Access-Control-Allow-Origin \$rpaas_cors_origin__web_fonts always;\n+\s+add_header Vary Origin always;\n+\s+root /etc/nginx/extra_files;`, result)
 			},
 		},
 		{
@@ -284,18 +1126,45 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 					Spec: v1alpha1.RpaasInstanceSpec{
 						Locations: []v1alpha1.Location{
 							{
-								Path:        "/path1",
+								Path:        "/api.v1",
 								Destination: "app1.tsuru.example.com",
+								Canary:      &v1alpha1.LocationCanary{Destination: "app1-canary.tsuru.example.com", Header: "X-Canary", Value: "true"},
 							},
 							{
-								Path:        "/path2",
+								Path:        "/",
 								Destination: "app2.tsuru.example.com",
-								ForceHTTPS:  true,
+								Canary:      &v1alpha1.LocationCanary{Destination: "10.0.0.2:8080", Cookie: "beta_tester", Value: "yes"},
 							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `upstream rpaas_canary__api.v1 {\n+\s+server app1-canary.tsuru.example.com;`, result)
+				assert.Regexp(t, `map \$http_x_canary \$rpaas_canary_upstream__api_v1 {\n+\s+"true" rpaas_canary__api.v1;\n+\s+default rpaas_locations__api.v1;\n+\s+}`, result)
+				assert.Regexp(t, `map \$http_x_canary \$rpaas_canary_host__api_v1 {\n+\s+"true" "app1-canary.tsuru.example.com";\n+\s+default "app1.tsuru.example.com";\n+\s+}`, result)
+				assert.Regexp(t, `location /api.v1 {[^}]+proxy_set_header Host \$rpaas_canary_host__api_v1;`, result)
+				assert.Regexp(t, `location /api.v1 {[^}]+rewrite "\^/api\\\.v1\(\.\*\)\$" /\$1 break;\n+\s+proxy_pass http://\$rpaas_canary_upstream__api_v1;`, result)
+				assert.Regexp(t, `location /api.v1 {[^}]+proxy_redirect ~\^http://rpaas_canary__api.v1\(:\\d\+\)\?/\(\.\*\)\$ /api.v1\$2;`, result)
+				assert.Regexp(t, `map \$cookie_beta_tester \$rpaas_canary_upstream_root {\n+\s+"yes" rpaas_canary_root;\n+\s+default rpaas_locations_root;`, result)
+				assert.Regexp(t, `location / {[^}]+proxy_pass http://\$rpaas_canary_upstream_root;`, result)
+			},
+		},
+		{
+			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
 							{
-								Path: "/path3",
-								Content: &v1alpha1.Value{
-									Value: "# My custom configuration for /path3",
+								Path: "/pool",
+								Destinations: []v1alpha1.LocationDestination{
+									{Address: "app1.tsuru.example.com", Weight: 3},
+									{Address: "10.0.0.2:8080"},
+									{Address: "10.0.0.3:8080", MaxFails: func(n int32) *int32 { return &n }(3), FailTimeout: "1m30s"},
+									{Address: "10.0.0.4:8080", MaxFails: func(n int32) *int32 { return &n }(0), FailTimeout: "1500ms"},
 								},
 							},
 						},
@@ -304,58 +1173,26 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				assert.NoError(t, err)
-				assert.Regexp(t, `\s+upstream rpaas_locations__path1 {
-\s+server app1.tsuru.example.com;
-\s+}`, result)
-				assert.Regexp(t, `\s+upstream rpaas_locations__path2 {
-\s+server app2.tsuru.example.com;
-\s+}`, result)
-
-				assert.Regexp(t, `location /path1 {\n+
-\s+proxy_set_header Host app1\.tsuru\.example\.com;
-\s+proxy_set_header X-Real-IP \$remote_addr;
-\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
-\s+proxy_set_header X-Forwarded-Proto \$scheme;
-\s+proxy_set_header X-Forwarded-Host \$host;
-\s+proxy_set_header Connection "";
-\s+proxy_http_version 1.1;
-\s+proxy_pass http://app1.tsuru.example.com/;
-\s+proxy_redirect ~\^http://rpaas_locations__path1\(:\\d\+\)\?/\(\.\*\)\$ /path1\$2;\n+
-\s+}`, result)
-
-				assert.Regexp(t, `location /path2 {\n+
-\s+if \(\$scheme = 'http'\) {
-\s+return 301 https://\$http_host\$request_uri;
-\s+}\n+
-\s+proxy_set_header Host app2\.tsuru\.example\.com;
-\s+proxy_set_header X-Real-IP \$remote_addr;
-\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
-\s+proxy_set_header X-Forwarded-Proto \$scheme;
-\s+proxy_set_header X-Forwarded-Host \$host;
-\s+proxy_set_header Connection "";
-\s+proxy_http_version 1.1;
-\s+proxy_pass http://app2.tsuru.example.com/;
-\s+proxy_redirect ~\^http://rpaas_locations__path2\(:\\d\+\)\?/\(\.\*\)\$ /path2\$2;\n+
-\s+}`, result)
-
-				assert.Regexp(t, `location /path3 {\n+
-\s+# My custom configuration for /path3\n+
-\s+}`, result)
+				assert.Regexp(t, `upstream rpaas_locations__pool {\s+server app1.tsuru.example.com weight=3;\s+server 10.0.0.2:8080;\s+server 10.0.0.3:8080 max_fails=3 fail_timeout=90s;\s+server 10.0.0.4:8080 max_fails=0 fail_timeout=1500ms;`, result)
+				assert.Regexp(t, `location /pool {\n+
+[^}]+proxy_set_header Host app1.tsuru.example.com;
+[^}]+proxy_pass http://rpaas_locations__pool/;`, result)
 			},
 		},
 		{
 			renderer: NewRpaasConfigurationRenderer(ConfigurationBlocks{}),
 			data: ConfigurationData{
-				Config: &v1alpha1.NginxConfig{
-					UpstreamKeepalive: 32,
-				},
+				Config: &v1alpha1.NginxConfig{},
 				Instance: &v1alpha1.RpaasInstance{
 					Spec: v1alpha1.RpaasInstanceSpec{
-						Host: "app.tsuru.example.com",
 						Locations: []v1alpha1.Location{
 							{
-								Path:        "/",
-								Destination: "app1.tsuru.example.com",
+								Path:         "/v6-pool",
+								Destinations: []v1alpha1.LocationDestination{{Address: "[2001:db8::1]:8080"}, {Address: "[2001:db8::2]"}},
+							},
+							{
+								Path:        "/v6",
+								Destination: "[2001:db8::3]:8080",
 							},
 						},
 					},
@@ -363,26 +1200,8 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 			},
 			assertion: func(t *testing.T, result string, err error) {
 				assert.NoError(t, err)
-				assert.Regexp(t, `\s+upstream rpaas_default_upstream {
-\s+server app.tsuru.example.com;
-\s+keepalive 32;
-\s+}`, result)
-				assert.Regexp(t, `\s+upstream rpaas_locations_root {
-\s+server app1.tsuru.example.com;
-\s+keepalive 32;
-\s+}`, result)
-
-				assert.Regexp(t, `location / {\n+
-\s+proxy_set_header Host app1\.tsuru\.example\.com;
-\s+proxy_set_header X-Real-IP \$remote_addr;
-\s+proxy_set_header X-Forwarded-For \$proxy_add_x_forwarded_for;
-\s+proxy_set_header X-Forwarded-Proto \$scheme;
-\s+proxy_set_header X-Forwarded-Host \$host;
-\s+proxy_set_header Connection "";
-\s+proxy_http_version 1.1;
-\s+proxy_pass http://app1.tsuru.example.com/;
-\s+proxy_redirect ~\^http://rpaas_locations_root\(:\\d\+\)\?/\(\.\*\)\$ /\$2;
-\s+}`, result)
+				assert.Regexp(t, `upstream rpaas_locations__v6-pool {\s+server \[2001:db8::1\]:8080;\s+server \[2001:db8::2\];`, result)
+				assert.Regexp(t, `proxy_pass http://\[2001:db8::3\]:8080/;`, result)
 			},
 		},
 		{
@@ -440,6 +1259,11 @@ func Test_buildLocationKey(t *testing.T) {
 			path:     "/custom/path",
 			expected: "rpaas_locations__custom_path",
 		},
+		{
+			name:     "when using the default prefix and regex path",
+			path:     `^/api/v[0-9]+/(.*)$`,
+			expected: "rpaas_locations___api_v_0-9____.___",
+		},
 		{
 			name:        "when using the default prefix with no path",
 			expected:    "cannot build location key due path is missing",
@@ -466,6 +1290,13 @@ func Test_buildLocationKey(t *testing.T) {
 	}
 }
 
+func Test_buildVariableName(t *testing.T) {
+	assert.Equal(t, "rpaas_canary_upstream_root", buildVariableName("rpaas_canary_upstream_", "/"))
+	assert.Equal(t, "rpaas_canary_upstream__api_v1", buildVariableName("rpaas_canary_upstream_", "/api.v1"))
+	assert.Equal(t, "rpaas_canary_host__my_app_v1", buildVariableName("rpaas_canary_host_", "/my-app/v1"))
+	assert.Equal(t, "rpaas_canary_host___api_v_0_9________", buildVariableName("rpaas_canary_host_", `^/api/v[0-9]+/(.*)$`))
+}
+
 func Test_hasRootPath(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -499,6 +1330,16 @@ func Test_hasRootPath(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "when locations has the root path with exact match type",
+			locations: []v1alpha1.Location{
+				{
+					Path:        "/",
+					MatchType:   v1alpha1.LocationMatchTypeExact,
+					Destination: "app.tsuru.example.com",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -508,3 +1349,56 @@ func Test_hasRootPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_limitZoneName(t *testing.T) {
+	assert.Equal(t, "rpaas_limit_req_instance", limitZoneName("req", "instance"))
+	assert.Equal(t, "rpaas_limit_conn_instance", limitZoneName("conn", "instance"))
+	assert.Equal(t, "rpaas_limit_req__instance", limitZoneName("req", "/instance"))
+	assert.Equal(t, "rpaas_limit_conn__api_v1", limitZoneName("conn", "/api/v1"))
+}
+
+func Test_sslProtocols(t *testing.T) {
+	protocols, err := sslProtocols(&v1alpha1.NginxConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "TLSv1 TLSv1.1 TLSv1.2", protocols)
+
+	protocols, err = sslProtocols(&v1alpha1.NginxConfig{TLSMinVersion: "TLSv1.2"})
+	require.NoError(t, err)
+	assert.Equal(t, "TLSv1.2 TLSv1.3", protocols)
+
+	_, err = sslProtocols(&v1alpha1.NginxConfig{TLSMinVersion: "SSLv3"})
+	assert.EqualError(t, err, `unknown TLS version "SSLv3": must be one of TLSv1, TLSv1.1, TLSv1.2, TLSv1.3`)
+}
+
+func Test_CompareTLSVersions(t *testing.T) {
+	result, err := CompareTLSVersions("TLSv1", "TLSv1.2")
+	require.NoError(t, err)
+	assert.Equal(t, -1, result)
+
+	result, err = CompareTLSVersions("TLSv1.3", "TLSv1.2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result)
+
+	result, err = CompareTLSVersions("TLSv1.2", "TLSv1.2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, result)
+
+	_, err = CompareTLSVersions("TLSv1.2", "TLSv2")
+	assert.Error(t, err)
+}
+
+func Test_ConfigID(t *testing.T) {
+	renderer := NewRpaasConfigurationRenderer(ConfigurationBlocks{})
+	config, err := renderer.Render(ConfigurationData{Config: &v1alpha1.NginxConfig{}, Instance: &v1alpha1.RpaasInstance{}})
+	require.NoError(t, err)
+	assert.NotContains(t, config, configIDPlaceholder)
+	id := ConfigID(config)
+	assert.Regexp(t, `^[0-9a-f]{16}$`, id)
+	assert.Contains(t, config, `return 200 "`+id+`";`)
+
+	other, err := renderer.Render(ConfigurationData{Config: &v1alpha1.NginxConfig{User: "www-data"}, Instance: &v1alpha1.RpaasInstance{}})
+	require.NoError(t, err)
+	assert.NotEqual(t, id, ConfigID(other))
+
+	assert.Equal(t, "", ConfigID("events {}"))
+}
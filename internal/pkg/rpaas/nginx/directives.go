@@ -0,0 +1,246 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nginx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Directives returns the names of the directives, in order of appearance,
+// used by an NGINX configuration snippet. The Lua code of "*_by_lua_block"
+// directives is skipped, as it isn't NGINX syntax.
+func Directives(config string) ([]string, error) {
+	tokens, err := tokenize(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var directives []string
+	depth := 0
+	statementStart := true
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.special && tok.value == ";":
+			statementStart = true
+		case tok.special && tok.value == "{":
+			depth++
+			statementStart = true
+		case tok.special && tok.value == "}":
+			if depth == 0 {
+				return nil, fmt.Errorf("unexpected \"}\" on line %d", tok.line)
+			}
+			depth--
+			statementStart = true
+		case statementStart:
+			directives = append(directives, tok.value)
+			statementStart = false
+		}
+	}
+
+	if depth > 0 {
+		return nil, fmt.Errorf("unexpected end of configuration, expecting \"}\"")
+	}
+
+	if !statementStart {
+		return nil, fmt.Errorf("unexpected end of configuration, expecting \";\" or \"}\"")
+	}
+
+	return directives, nil
+}
+
+type token struct {
+	value   string
+	line    int
+	special bool
+}
+
+func tokenize(config string) ([]token, error) {
+	var tokens []token
+	var word strings.Builder
+	line := 1
+	inWord := false
+	luaBlock := false
+
+	flush := func() {
+		if !inWord {
+			return
+		}
+		value := word.String()
+		tokens = append(tokens, token{value: value, line: line})
+		word.Reset()
+		inWord = false
+		if strings.HasSuffix(value, "_by_lua_block") {
+			luaBlock = true
+		}
+	}
+
+	for i := 0; i < len(config); i++ {
+		c := config[i]
+		switch {
+		case c == '\n':
+			flush()
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '#' && !inWord:
+			for i < len(config) && config[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '"' || c == '\'':
+			end, lines, err := skipQuoted(config, i)
+			if err != nil {
+				return nil, fmt.Errorf("%v on line %d", err, line)
+			}
+			word.WriteString(config[i+1 : end])
+			inWord = true
+			line += lines
+			i = end
+		case c == '{' && strings.HasSuffix(word.String(), "$"):
+			// Variable enclosed in braces, e.g. "${var}".
+			end := strings.IndexByte(config[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated variable on line %d", line)
+			}
+			word.WriteString(config[i : i+end+1])
+			i += end
+		case c == ';' || c == '{' || c == '}':
+			flush()
+			tokens = append(tokens, token{value: string(c), line: line, special: true})
+			if c == '{' && luaBlock {
+				end, lines, err := skipLuaBlock(config, i)
+				if err != nil {
+					return nil, fmt.Errorf("%v on line %d", err, line)
+				}
+				line += lines
+				i = end - 1
+			}
+			luaBlock = false
+		case c == '\\' && i+1 < len(config):
+			word.WriteByte(config[i+1])
+			inWord = true
+			i++
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+
+	return tokens, nil
+}
+
+// skipQuoted returns the position of the quote closing the string starting
+// at start, along with the number of line breaks within it.
+func skipQuoted(config string, start int) (int, int, error) {
+	quote := config[start]
+	lines := 0
+	for i := start + 1; i < len(config); i++ {
+		switch config[i] {
+		case '\\':
+			i++
+		case '\n':
+			lines++
+		case quote:
+			return i, lines, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated string")
+}
+
+// skipLuaBlock returns the position of the brace closing the Lua block
+// opened at start, along with the number of line breaks within it.
+func skipLuaBlock(config string, start int) (int, int, error) {
+	depth := 0
+	lines := 0
+	for i := start; i < len(config); i++ {
+		switch c := config[i]; c {
+		case '\n':
+			lines++
+		case '"', '\'':
+			end, n, err := skipQuoted(config, i)
+			if err != nil {
+				return 0, 0, err
+			}
+			lines += n
+			i = end
+		case '[':
+			end, n, ok, err := skipLongBracket(config, i)
+			if err != nil {
+				return 0, 0, err
+			}
+			if ok {
+				lines += n
+				i = end
+			}
+		case '-':
+			if !strings.HasPrefix(config[i:], "--") {
+				break
+			}
+			end, n, ok, err := skipLongBracket(config, i+2)
+			if err != nil {
+				return 0, 0, err
+			}
+			if ok {
+				lines += n
+				i = end
+				break
+			}
+			for i < len(config) && config[i] != '\n' {
+				i++
+			}
+			i--
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, lines, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated Lua block")
+}
+
+// skipLongBracket returns the position of the last character of the Lua long
+// bracket (e.g. "[[ ... ]]" or "[==[ ... ]==]") opened at start, along with
+// the number of line breaks within it. ok is false when no long bracket is
+// opened at start.
+func skipLongBracket(config string, start int) (int, int, bool, error) {
+	if start >= len(config) || config[start] != '[' {
+		return 0, 0, false, nil
+	}
+	i := start + 1
+	for i < len(config) && config[i] == '=' {
+		i++
+	}
+	if i >= len(config) || config[i] != '[' {
+		return 0, 0, false, nil
+	}
+	closing := "]" + strings.Repeat("=", i-start-1) + "]"
+	end := strings.Index(config[i+1:], closing)
+	if end < 0 {
+		return 0, 0, true, fmt.Errorf("unterminated Lua long bracket")
+	}
+	end += i + 1
+	return end + len(closing) - 1, strings.Count(config[start:end], "\n"), true, nil
+}
+
+// ValidateLuaBlock checks the Lua code doesn't close the block it's placed
+// in, e.g. "init_by_lua_block { ... }", which would let NGINX directives
+// be written out of it.
+func ValidateLuaBlock(code string) error {
+	block := "{" + code + "\n}"
+	end, _, err := skipLuaBlock(block, 0)
+	if err != nil {
+		return err
+	}
+	if end != len(block)-1 {
+		return fmt.Errorf("unexpected \"}\" closing the Lua block")
+	}
+	return nil
+}
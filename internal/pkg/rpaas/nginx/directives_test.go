@@ -0,0 +1,112 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nginx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectives(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected []string
+		err      string
+	}{
+		{
+			config: "",
+		},
+		{
+			config:   "# a comment; with { braces }\ngzip on;\ngzip_types text/plain text/css;",
+			expected: []string{"gzip", "gzip_types"},
+		},
+		{
+			config:   "location ~ ^/(a|b)$ {\n    add_header X-Hash \"#not a comment;\";\n    proxy_pass http://${host}$uri;\n}",
+			expected: []string{"location", "add_header", "proxy_pass"},
+		},
+		{
+			config:   "content_by_lua_block {\n    local t = {a = \"}\"} -- }\n    ngx.say(t.a)\n}\nreturn 200;",
+			expected: []string{"content_by_lua_block", "return"},
+		},
+		{
+			config:   "access_by_lua_block {\n    local s = [==[ \" } ]==] --[[ } \n } ]]\n}\ngzip on;",
+			expected: []string{"access_by_lua_block", "gzip"},
+		},
+		{
+			config: "server { listen 80;",
+			err:    `unexpected end of configuration, expecting "}"`,
+		},
+		{
+			config: "gzip on;\n}",
+			err:    `unexpected "}" on line 2`,
+		},
+		{
+			config: "gzip on",
+			err:    `unexpected end of configuration, expecting ";" or "}"`,
+		},
+		{
+			config: "add_header X-Test 'value;",
+			err:    `unterminated string on line 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.config, func(t *testing.T) {
+			directives, err := Directives(tt.config)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, directives)
+		})
+	}
+}
+
+func TestValidateLuaBlock(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{
+			code: "",
+		},
+		{
+			code: "local t = {a = \"}\"} -- }\nngx.log(ngx.INFO, t.a)",
+		},
+		{
+			code: "local s = [[ \" ]] -- trailing comment",
+		},
+		{
+			code: "local t = {}\n}\nlua_code_cache off;\ninit_by_lua_block {",
+			err:  `unexpected "}" closing the Lua block`,
+		},
+		{
+			code: "local s = [[ \" ]] }\nlua_code_cache off;\ninit_by_lua_block { local t = [[ \" ]]",
+			err:  `unexpected "}" closing the Lua block`,
+		},
+		{
+			code: "local t = {",
+			err:  "unterminated Lua block",
+		},
+		{
+			code: "local s = [=[ ]]",
+			err:  "unterminated Lua long bracket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := ValidateLuaBlock(tt.code)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
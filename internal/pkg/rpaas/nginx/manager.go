@@ -6,7 +6,12 @@ package nginx
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -18,6 +23,10 @@ const (
 	defaultPurgeLocation      = "/purge"
 	defaultPurgeLocationMatch = "^/purge/(.+)"
 	defaultVTSLocationMatch   = "/status"
+	defaultConfigIDLocation   = "/config-id"
+	defaultVersionLocation    = "/nginx-version"
+
+	defaultDestinationCheckLocation = "/check-destination"
 )
 
 type NginxManager struct {
@@ -46,6 +55,12 @@ func managePort() uint16 {
 	return defaultManagePort
 }
 
+// IsReservedPort reports whether the generated configuration already listens
+// on port, either for HTTP, HTTPS or the manage server.
+func IsReservedPort(port int32) bool {
+	return port == 8080 || port == 8443 || port == int32(managePort())
+}
+
 func purgeLocationMatch() string {
 	return defaultPurgeLocationMatch
 }
@@ -54,27 +69,57 @@ func vtsLocationMatch() string {
 	return defaultVTSLocationMatch
 }
 
-func (m NginxManager) PurgeCache(host, purgePath string, preservePath bool) error {
+func configIDLocation() string {
+	return defaultConfigIDLocation
+}
+
+func versionLocation() string {
+	return defaultVersionLocation
+}
+
+func destinationCheckLocation() string {
+	return defaultDestinationCheckLocation
+}
+
+// CacheKeyVariants holds the parts of a cache key other than the request
+// path. They only take effect when they match the proxy_cache_key of the
+// instance, otherwise the purge requests won't hit any cached object.
+type CacheKeyVariants struct {
+	// AllQueryStrings purges every query string variant of the path, by
+	// appending a wildcard to the purge key.
+	AllQueryStrings bool
+}
+
+func (m NginxManager) PurgeCache(host, purgePath string, preservePath bool, variants CacheKeyVariants) error {
 	for _, encoding := range []string{"gzip", "identity"} {
 		headers := map[string]string{"Accept-Encoding": encoding}
 
-		if preservePath {
-			path := fmt.Sprintf("%s%s", defaultPurgeLocation, purgePath)
+		for _, path := range purgeKeyPaths(purgePath, preservePath, variants.AllQueryStrings) {
 			if err := m.purgeRequest(host, path, headers); err != nil {
 				return err
 			}
-		} else {
-			for _, scheme := range []string{"http", "https"} {
-				path := fmt.Sprintf("%s/%s%s", defaultPurgeLocation, scheme, purgePath)
-				if err := m.purgeRequest(host, path, headers); err != nil {
-					return err
-				}
-			}
 		}
 	}
 	return nil
 }
 
+func purgeKeyPaths(purgePath string, preservePath, allQueryStrings bool) []string {
+	suffix := ""
+	if allQueryStrings {
+		suffix = "*"
+	}
+
+	if preservePath {
+		return []string{fmt.Sprintf("%s%s%s", defaultPurgeLocation, purgePath, suffix)}
+	}
+
+	var paths []string
+	for _, scheme := range []string{"http", "https"} {
+		paths = append(paths, fmt.Sprintf("%s/%s%s%s", defaultPurgeLocation, scheme, purgePath, suffix))
+	}
+	return paths
+}
+
 func (m NginxManager) purgeRequest(host, path string, headers map[string]string) error {
 	resp, err := m.requestNginx(host, path, headers)
 	if err != nil {
@@ -104,3 +149,81 @@ func (m NginxManager) requestNginx(host, path string, headers map[string]string)
 	}
 	return resp, nil
 }
+
+// LoadedConfigID returns the identifier of the configuration currently loaded
+// by the NGINX server, which differs from the latest rendered one when it
+// failed to reload.
+func (m NginxManager) LoadedConfigID(host string) (string, error) {
+	resp, err := m.requestNginx(host, defaultConfigIDLocation, nil)
+	if err != nil {
+		return "", NginxError{Msg: fmt.Sprintf("cannot get the loaded configuration - error requesting nginx server: %v", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", NginxError{Msg: fmt.Sprintf("cannot get the loaded configuration - unexpected status code from nginx server: %d", resp.StatusCode)}
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", NginxError{Msg: fmt.Sprintf("cannot get the loaded configuration - error reading response: %v", err)}
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Version returns the version of the NGINX server, as reported by its
+// $nginx_version variable.
+func (m NginxManager) Version(host string) (string, error) {
+	resp, err := m.requestNginx(host, defaultVersionLocation, nil)
+	if err != nil {
+		return "", NginxError{Msg: fmt.Sprintf("cannot get the nginx version - error requesting nginx server: %v", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", NginxError{Msg: fmt.Sprintf("cannot get the nginx version - unexpected status code from nginx server: %d", resp.StatusCode)}
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", NginxError{Msg: fmt.Sprintf("cannot get the nginx version - error reading response: %v", err)}
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// CheckDestination asks the NGINX server to open a TCP connection to the
+// destination, formatted as "host[:port]" (port 80 by default), returning
+// how long it took. Host names are resolved beforehand, as the NGINX
+// configuration doesn't define a resolver for the Lua sockets.
+func (m NginxManager) CheckDestination(host, destination string) (time.Duration, error) {
+	destHost, destPort, err := net.SplitHostPort(destination)
+	if err != nil {
+		destHost, destPort = strings.Trim(destination, "[]"), "80"
+	}
+	if net.ParseIP(destHost) == nil {
+		addrs, err := net.LookupHost(destHost)
+		if err != nil {
+			return 0, NginxError{Msg: fmt.Sprintf("cannot resolve destination host %q: %v", destHost, err)}
+		}
+		destHost = addrs[0]
+	}
+
+	query := url.Values{"host": []string{destHost}, "port": []string{destPort}}
+	resp, err := m.requestNginx(host, defaultDestinationCheckLocation+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, NginxError{Msg: fmt.Sprintf("cannot check the destination - error requesting nginx server: %v", err)}
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, NginxError{Msg: fmt.Sprintf("cannot check the destination - error reading response: %v", err)}
+	}
+	body := strings.TrimSpace(string(data))
+	if resp.StatusCode == http.StatusBadGateway {
+		return 0, NginxError{Msg: fmt.Sprintf("cannot connect to destination %q: %s", destination, body)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, NginxError{Msg: fmt.Sprintf("cannot check the destination - unexpected status code from nginx server: %d", resp.StatusCode)}
+	}
+	seconds, err := strconv.ParseFloat(body, 64)
+	if err != nil {
+		return 0, NginxError{Msg: fmt.Sprintf("cannot check the destination - invalid response from nginx server: %q", body)}
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -10,7 +10,9 @@ import (
 	"net/url"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,6 +21,7 @@ func TestNginxManager_PurgeCache(t *testing.T) {
 		description   string
 		purgePath     string
 		preservePath  bool
+		variants      CacheKeyVariants
 		assertion     func(*testing.T, error)
 		nginxResponse http.HandlerFunc
 	}{
@@ -104,6 +107,22 @@ func TestNginxManager_PurgeCache(t *testing.T) {
 				}
 			},
 		},
+		{
+			description:  "makes a request with a wildcard key when all query strings should be purged",
+			purgePath:    "/index.html",
+			preservePath: true,
+			variants:     CacheKeyVariants{AllQueryStrings: true},
+			assertion: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+			nginxResponse: func(w http.ResponseWriter, r *http.Request) {
+				if r.RequestURI == "/purge/index.html*" {
+					w.WriteHeader(http.StatusOK)
+				} else {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			},
+		},
 	}
 
 	for _, tt := range testCases {
@@ -118,8 +137,155 @@ func TestNginxManager_PurgeCache(t *testing.T) {
 			require.NoError(t, err)
 			nginx.managePort = uint16(port)
 
-			err = nginx.PurgeCache(url.Hostname(), tt.purgePath, tt.preservePath)
+			err = nginx.PurgeCache(url.Hostname(), tt.purgePath, tt.preservePath, tt.variants)
 			tt.assertion(t, err)
 		})
 	}
 }
+
+func TestNginxManager_LoadedConfigID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI != "/config-id" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("0123456789abcdef"))
+	}))
+	defer server.Close()
+
+	url, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	nginx := NewNginxManager()
+	port, err := strconv.ParseUint(url.Port(), 10, 16)
+	require.NoError(t, err)
+	nginx.managePort = uint16(port)
+
+	id, err := nginx.LoadedConfigID(url.Hostname())
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", id)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	_, err = nginx.LoadedConfigID(url.Hostname())
+	assert.EqualError(t, err, "cannot get the loaded configuration - unexpected status code from nginx server: 404")
+}
+
+func TestNginxManager_Version(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI != "/nginx-version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("1.18.0"))
+	}))
+	defer server.Close()
+
+	url, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	nginx := NewNginxManager()
+	port, err := strconv.ParseUint(url.Port(), 10, 16)
+	require.NoError(t, err)
+	nginx.managePort = uint16(port)
+
+	version, err := nginx.Version(url.Hostname())
+	require.NoError(t, err)
+	assert.Equal(t, "1.18.0", version)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	_, err = nginx.Version(url.Hostname())
+	assert.EqualError(t, err, "cannot get the nginx version - unexpected status code from nginx server: 404")
+}
+
+func TestNginxManager_CheckDestination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/check-destination" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("port") == "8081" {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("connection refused\n"))
+			return
+		}
+		assert.Equal(t, "10.1.1.1", r.URL.Query().Get("host"))
+		w.Write([]byte("0.012\n"))
+	}))
+	defer server.Close()
+
+	url, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	nginx := NewNginxManager()
+	port, err := strconv.ParseUint(url.Port(), 10, 16)
+	require.NoError(t, err)
+	nginx.managePort = uint16(port)
+
+	latency, err := nginx.CheckDestination(url.Hostname(), "10.1.1.1:8080")
+	require.NoError(t, err)
+	assert.Equal(t, 12*time.Millisecond, latency)
+
+	latency, err = nginx.CheckDestination(url.Hostname(), "10.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, 12*time.Millisecond, latency)
+
+	_, err = nginx.CheckDestination(url.Hostname(), "10.1.1.1:8081")
+	assert.EqualError(t, err, `cannot connect to destination "10.1.1.1:8081": connection refused`)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	_, err = nginx.CheckDestination(url.Hostname(), "10.1.1.1")
+	assert.EqualError(t, err, "cannot check the destination - unexpected status code from nginx server: 404")
+}
+
+func Test_purgeKeyPaths(t *testing.T) {
+	tests := []struct {
+		name            string
+		purgePath       string
+		preservePath    bool
+		allQueryStrings bool
+		expected        []string
+	}{
+		{
+			name:         "preserving the path",
+			purgePath:    "/index.html",
+			preservePath: true,
+			expected:     []string{"/purge/index.html"},
+		},
+		{
+			name:      "prefixing the path with each scheme",
+			purgePath: "/index.html",
+			expected:  []string{"/purge/http/index.html", "/purge/https/index.html"},
+		},
+		{
+			name:         "preserving an explicit query string",
+			purgePath:    "/index.html?lang=en",
+			preservePath: true,
+			expected:     []string{"/purge/index.html?lang=en"},
+		},
+		{
+			name:            "wildcard when preserving the path",
+			purgePath:       "/index.html",
+			preservePath:    true,
+			allQueryStrings: true,
+			expected:        []string{"/purge/index.html*"},
+		},
+		{
+			name:            "wildcard for each scheme",
+			purgePath:       "/index.html",
+			allQueryStrings: true,
+			expected:        []string{"/purge/http/index.html*", "/purge/https/index.html*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, purgeKeyPaths(tt.purgePath, tt.preservePath, tt.allQueryStrings))
+		})
+	}
+}
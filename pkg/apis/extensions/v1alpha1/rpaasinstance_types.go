@@ -8,6 +8,7 @@ import (
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // RpaasInstanceSpec defines the desired state of RpaasInstance
@@ -29,6 +30,12 @@ type RpaasInstanceSpec struct {
 	// +optional
 	Host string `json:"host,omitempty"`
 
+	// ExternalHostname is the DNS name the instance is reachable by, which
+	// is published on the Service for ExternalDNS. Unlike Host, it's not
+	// related to the bound application.
+	// +optional
+	ExternalHostname string `json:"externalHostname,omitempty"`
+
 	// Blocks are configuration file fragments added to the generated nginx
 	// config.
 	Blocks map[BlockType]Value `json:"blocks,omitempty"`
@@ -44,6 +51,22 @@ type RpaasInstanceSpec struct {
 	// +optional
 	Certificates *nginxv1alpha1.TLSSecret `json:"certificates,omitempty"`
 
+	// DefaultCertificate is the name of the certificate served by the
+	// default server, i.e. to the clients whose SNI matches no server name.
+	// Defaults to the "default" certificate.
+	// +optional
+	DefaultCertificate string `json:"defaultCertificate,omitempty"`
+
+	// ClientAuth requires the clients connecting over HTTPS to present a
+	// certificate issued by a trusted CA (mTLS).
+	// +optional
+	ClientAuth *RpaasInstanceClientAuthSpec `json:"clientAuth,omitempty"`
+
+	// TLS holds extra settings for the TLS handshakes, such as the DH
+	// parameters and the ECDH curves.
+	// +optional
+	TLS *RpaasInstanceTLSSpec `json:"tls,omitempty"`
+
 	// Service to expose the nginx instance
 	// +optional
 	Service *nginxv1alpha1.NginxService `json:"service,omitempty"`
@@ -64,11 +87,160 @@ type RpaasInstanceSpec struct {
 	// for this instance.
 	// +optional
 	Autoscale *RpaasInstanceAutoscaleSpec `json:"autoscale,omitempty"`
+
+	// ScaleToZero lets the instance be scaled down to no pods while idle,
+	// relying on an activator to wake it up on incoming requests. It can't
+	// be combined with an autoscaler keeping a minimum number of replicas.
+	// +optional
+	ScaleToZero *RpaasInstanceScaleToZeroSpec `json:"scaleToZero,omitempty"`
+
+	// HideServerTokens toggles off the emission of the NGINX version on
+	// error pages and in the "Server" response header field. When unset,
+	// the version is hidden.
+	// +optional
+	HideServerTokens *bool `json:"hideServerTokens,omitempty"`
+
+	// ProxyProtocol enables the PROXY protocol on the NGINX listeners, so
+	// the real client address is kept behind L4 load balancers. Connections
+	// from the loopback address, as made by the health checker, don't use it.
+	// +optional
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+
+	// RequestID makes NGINX send the X-Request-ID header to the upstreams
+	// and log it, generating one when the client doesn't send it.
+	// +optional
+	RequestID bool `json:"requestID,omitempty"`
+
+	// HTTP3 makes NGINX also serve HTTP/3 (QUIC) on the HTTPS port,
+	// advertising it to clients through the Alt-Svc header.
+	// +optional
+	HTTP3 bool `json:"http3,omitempty"`
+
+	// ExtraPorts are additional ports, besides the HTTP and HTTPS ones, on
+	// which NGINX serves plain HTTP. As the ports of the Service managed by
+	// nginx-operator are fixed, they're exposed through a Service of their
+	// own, named after the instance with the "-extra-ports" suffix, sharing
+	// the load balancer IP of the former.
+	// +optional
+	ExtraPorts []int32 `json:"extraPorts,omitempty"`
+
+	// RejectUnknownHosts restricts the NGINX server to the instance's
+	// Ingress host, closing the connection (444) of requests for any other
+	// host. Defaults to serving every host.
+	// +optional
+	RejectUnknownHosts bool `json:"rejectUnknownHosts,omitempty"`
+
+	// NodeSelector restricts the NGINX pods to the nodes matching all of
+	// these labels. It's merged into the pod template's node affinity.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// TopologySpreadConstraints describes how the NGINX pods should be
+	// spread across topology domains (e.g. zones). Defaults to none.
+	// +optional
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget limits the number of NGINX pods taken down at the
+	// same time by voluntary disruptions. When nil, no PDB is created.
+	// +optional
+	PodDisruptionBudget *RpaasInstancePodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// Env holds environment variables set on the NGINX worker processes,
+	// e.g. for Lua or njs code, through the env directive of the main
+	// configuration.
+	// +optional
+	Env map[string]string `json:"env,omitempty"`
+
+	// Ingress exposes the instance through an Ingress controller. When nil,
+	// no Ingress is created.
+	// +optional
+	Ingress *RpaasInstanceIngressSpec `json:"ingress,omitempty"`
+
+	// HealthCheckPath is the path used by the readiness and liveness probes,
+	// served by NGINX itself. Defaults to "/_nginx_healthcheck".
+	// +optional
+	HealthCheckPath string `json:"healthCheckPath,omitempty"`
+
+	// HealthCheckStatus is the HTTP status returned on HealthCheckPath.
+	// Defaults to 200.
+	// +optional
+	HealthCheckStatus int `json:"healthCheckStatus,omitempty"`
+
+	// Compression overrides the default gzip settings of the instance.
+	// +optional
+	Compression *RpaasInstanceCompressionSpec `json:"compression,omitempty"`
+
+	// AccessLog reduces the access log volume by sampling or filtering the
+	// logged requests. When nil, every request is logged.
+	// +optional
+	AccessLog *RpaasInstanceAccessLogSpec `json:"accessLog,omitempty"`
+
+	// RateLimit caps the requests rate and concurrent connections of each
+	// client address on the whole instance.
+	// +optional
+	RateLimit *RpaasInstanceRateLimitSpec `json:"rateLimit,omitempty"`
+
+	// Maps are NGINX map directives rendered at http scope, so their
+	// variables can be used by routes and blocks.
+	// +optional
+	Maps []RpaasInstanceMap `json:"maps,omitempty"`
+
+	// StatusCallbackURL is an HTTPS endpoint notified whenever the instance
+	// becomes ready or fails to be reconciled.
+	// +optional
+	StatusCallbackURL string `json:"statusCallbackURL,omitempty"`
+}
+
+type UnsatisfiableConstraintAction string
+
+const (
+	DoNotSchedule  = UnsatisfiableConstraintAction("DoNotSchedule")
+	ScheduleAnyway = UnsatisfiableConstraintAction("ScheduleAnyway")
+)
+
+// TopologySpreadConstraint mirrors the upstream Kubernetes type of the same
+// name, which isn't available in the API version we depend on. Until then,
+// it's rendered as a pod anti-affinity over TopologyKey: with DoNotSchedule,
+// a required one, which places at most one pod in each domain and leaves
+// any replica beyond the number of domains pending; with ScheduleAnyway, a
+// preferred one, so the scheduler spreads pods on a best-effort basis.
+type TopologySpreadConstraint struct {
+	// MaxSkew is the maximum permitted difference of pods between any two
+	// topology domains. It isn't honored by the pod anti-affinity the
+	// constraint is rendered as, but is kept for the upstream type.
+	MaxSkew int32 `json:"maxSkew"`
+
+	// TopologyKey is the node label whose values define the domains.
+	TopologyKey string `json:"topologyKey"`
+
+	// WhenUnsatisfiable indicates how to deal with a pod that doesn't
+	// satisfy the constraint.
+	WhenUnsatisfiable UnsatisfiableConstraintAction `json:"whenUnsatisfiable"`
 }
 
 // RpaasInstanceStatus defines the observed state of RpaasInstance
 // +k8s:openapi-gen=true
-type RpaasInstanceStatus struct{}
+type RpaasInstanceStatus struct {
+	// ObservedGeneration is the most recent generation of the instance
+	// whose spec was applied by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase is the result of the last reconciliation, either Ready or Failed.
+	// +optional
+	Phase RpaasInstancePhase `json:"phase,omitempty"`
+
+	// Message describes why the last reconciliation failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type RpaasInstancePhase string
+
+const (
+	RpaasInstancePhaseReady  = RpaasInstancePhase("Ready")
+	RpaasInstancePhaseFailed = RpaasInstancePhase("Failed")
+)
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -82,6 +254,12 @@ type RpaasInstance struct {
 	Spec   RpaasInstanceSpec   `json:"spec,omitempty"`
 }
 
+// PendingReconcile tells whether the current spec wasn't applied by the
+// operator yet.
+func (i *RpaasInstance) PendingReconcile() bool {
+	return i.Generation > i.Status.ObservedGeneration
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // RpaasInstanceList contains a list of RpaasInstance
@@ -101,11 +279,147 @@ const (
 	BlockTypeLuaWorker = "lua-worker"
 )
 
+type LocationMatchType string
+
+const (
+	LocationMatchTypePrefix LocationMatchType = "prefix"
+	LocationMatchTypeExact  LocationMatchType = "exact"
+	LocationMatchTypeRegex  LocationMatchType = "regex"
+)
+
+type LoadBalancingMethod string
+
+const (
+	LoadBalancingRoundRobin LoadBalancingMethod = "round_robin"
+	LoadBalancingLeastConn  LoadBalancingMethod = "least_conn"
+	LoadBalancingIPHash     LoadBalancingMethod = "ip_hash"
+)
+
 type Location struct {
-	Path        string `json:"path"`
-	Destination string `json:"destination,omitempty"`
-	Content     *Value `json:"content,omitempty"`
-	ForceHTTPS  bool   `json:"forceHTTPS,omitempty"`
+	Path string `json:"path"`
+	// MatchType defines how the Path is compared against the request URI:
+	// prefix, exact or regex. Defaults to prefix.
+	// +optional
+	MatchType   LocationMatchType `json:"matchType,omitempty"`
+	Destination string            `json:"destination,omitempty"`
+	// Destinations forms a pool of upstream servers, used instead of the
+	// single Destination.
+	// +optional
+	Destinations []LocationDestination `json:"destinations,omitempty"`
+	Content      *Value                `json:"content,omitempty"`
+	ForceHTTPS   bool                  `json:"forceHTTPS,omitempty"`
+	// StaticFile is the name of the extra file served on every request
+	// to the location, e.g. an "index.html".
+	// +optional
+	StaticFile string `json:"staticFile,omitempty"`
+	// Buffering toggles the proxy buffering on this location. When unset,
+	// the NGINX's default is inherited.
+	// +optional
+	Buffering *bool `json:"buffering,omitempty"`
+	// LoadBalancing defines how requests are distributed among the
+	// destination addresses: round_robin, least_conn or ip_hash. Defaults
+	// to round_robin.
+	// +optional
+	LoadBalancing LoadBalancingMethod `json:"loadBalancing,omitempty"`
+	// Auth protects the location with HTTP basic authentication.
+	// +optional
+	Auth *LocationAuth `json:"auth,omitempty"`
+	// AllowCIDRs restricts the location to clients from these networks.
+	// +optional
+	AllowCIDRs []string `json:"allowCIDRs,omitempty"`
+	// DenyCIDRs rejects the clients from these networks.
+	// +optional
+	DenyCIDRs []string `json:"denyCIDRs,omitempty"`
+	// UpstreamClientCertificate is the name of the instance certificate
+	// presented to the destination. When set, the destination is reached
+	// over HTTPS.
+	// +optional
+	UpstreamClientCertificate string `json:"upstreamClientCertificate,omitempty"`
+	// UpstreamTLSVerify verifies the certificate presented by the
+	// destination, which is then reached over HTTPS. Defaults to false.
+	// +optional
+	UpstreamTLSVerify bool `json:"upstreamTLSVerify,omitempty"`
+	// UpstreamTrustedCA is the name of the extra file holding the CA bundle
+	// used to verify the destination's certificate. Defaults to the system
+	// CA bundle.
+	// +optional
+	UpstreamTrustedCA string `json:"upstreamTrustedCA,omitempty"`
+	// Canary routes the requests carrying a given header or cookie to
+	// another destination.
+	// +optional
+	Canary *LocationCanary `json:"canary,omitempty"`
+	// CORS answers the cross-origin requests to the location, including
+	// the preflight (OPTIONS) ones, on behalf of the destination.
+	// +optional
+	CORS *LocationCORS `json:"cors,omitempty"`
+}
+
+// LocationCORS holds the Cross-Origin Resource Sharing settings of a
+// location.
+type LocationCORS struct {
+	// AllowOrigins are the origins, e.g. "https://app.example.com", allowed
+	// to access the location. "*" allows any of them.
+	AllowOrigins []string `json:"allowOrigins"`
+	// AllowMethods are the methods allowed on the preflight requests. When
+	// unset, only the CORS-safelisted ones (GET, HEAD and POST) are.
+	// +optional
+	AllowMethods []string `json:"allowMethods,omitempty"`
+	// AllowHeaders are the request headers allowed besides the
+	// CORS-safelisted ones.
+	// +optional
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+	// AllowCredentials lets the requests carry cookies and authorization
+	// headers. It can't be used along with the "*" origin.
+	// +optional
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
+	// MaxAge is how long, in seconds, the preflight responses can be
+	// cached. When unset, the browser's default is used.
+	// +optional
+	MaxAge int32 `json:"maxAge,omitempty"`
+}
+
+// LocationCanary matches the requests sent to the canary destination by
+// either a header or a cookie.
+type LocationCanary struct {
+	// Destination is the host, optionally followed by the port, of the
+	// canary server.
+	Destination string `json:"destination"`
+	// Header is the name of the request header to match.
+	// +optional
+	Header string `json:"header,omitempty"`
+	// Cookie is the name of the request cookie to match.
+	// +optional
+	Cookie string `json:"cookie,omitempty"`
+	// Value is what the header or cookie must be equal to.
+	Value string `json:"value"`
+}
+
+// LocationAuth holds the HTTP basic authentication settings of a location.
+type LocationAuth struct {
+	// UserFile is the extra file, in htpasswd format, holding the
+	// credentials of the users allowed to access the location.
+	UserFile string `json:"userFile"`
+	// Users are the names of the users found in UserFile.
+	// +optional
+	Users []string `json:"users,omitempty"`
+}
+
+// LocationDestination is a member of the upstream pool of a location.
+type LocationDestination struct {
+	// Address is the host, optionally followed by the port, of the server.
+	Address string `json:"address"`
+	// Weight of the server in the pool. When unset, the NGINX's default
+	// (1) is used.
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
+	// MaxFails is the number of unsuccessful attempts, within FailTimeout,
+	// after which the server is considered unavailable. Zero disables it.
+	// +optional
+	MaxFails *int32 `json:"maxFails,omitempty"`
+	// FailTimeout is both the time window for MaxFails and how long the
+	// server stays unavailable, written as a duration (e.g. "30s").
+	// +optional
+	FailTimeout string `json:"failTimeout,omitempty"`
 }
 
 type ValueSource struct {
@@ -140,6 +454,169 @@ type RpaasInstanceAutoscaleSpec struct {
 	// int32(80) equals to 80%.
 	// +optional
 	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Metrics contains additional metrics (resource, pods or external ones)
+	// used to calculate the desired replica count.
+	// +optional
+	Metrics []RpaasInstanceAutoscaleMetric `json:"metrics,omitempty"`
+}
+
+// RpaasInstancePodDisruptionBudgetSpec describes the PodDisruptionBudget of
+// the instance's pods. Exactly one of its fields must be set.
+type RpaasInstancePodDisruptionBudgetSpec struct {
+	// MinAvailable is the number (or percentage) of pods that must remain
+	// available during a voluntary disruption.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number (or percentage) of pods that may be
+	// unavailable during a voluntary disruption.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// RpaasInstanceIngressSpec describes the Ingress routing traffic to the
+// instance's Service.
+type RpaasInstanceIngressSpec struct {
+	// ClassName is the ingress class which should handle this Ingress.
+	ClassName string `json:"className"`
+	// Host is the DNS name the Ingress serves.
+	Host string `json:"host"`
+	// TLSSecretName is the Secret holding the certificate used by the
+	// Ingress controller to terminate TLS.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+type ClientVerifyMode string
+
+const (
+	ClientVerifyRequired ClientVerifyMode = "required"
+	ClientVerifyOptional ClientVerifyMode = "optional"
+)
+
+// RpaasInstanceClientAuthSpec describes how client certificates are verified.
+type RpaasInstanceClientAuthSpec struct {
+	// CAFile is the extra file holding the PEM-encoded certificates of the
+	// CAs trusted to issue client certificates.
+	CAFile string `json:"caFile"`
+	// Verify is either required, rejecting the clients without a valid
+	// certificate, or optional. Defaults to required.
+	// +optional
+	Verify ClientVerifyMode `json:"verify,omitempty"`
+}
+
+// RpaasInstanceTLSSpec describes extra settings for the TLS handshakes.
+type RpaasInstanceTLSSpec struct {
+	// DHParamFile is the extra file holding the PEM-encoded DH parameters
+	// used by the DHE ciphers.
+	// +optional
+	DHParamFile string `json:"dhParamFile,omitempty"`
+	// ECDHCurves lists the curves used by the ECDHE ciphers, in order of
+	// preference.
+	// +optional
+	ECDHCurves []string `json:"ecdhCurves,omitempty"`
+}
+
+// RpaasInstanceCompressionSpec describes how responses are compressed.
+type RpaasInstanceCompressionSpec struct {
+	// Enabled toggles the response compression.
+	Enabled bool `json:"enabled"`
+	// MinLength is the minimum response length (in bytes) to be compressed.
+	// +optional
+	MinLength int `json:"minLength,omitempty"`
+	// MimeTypes are the response MIME types to be compressed, besides
+	// "text/html" which is always compressed by NGINX.
+	// +optional
+	MimeTypes []string `json:"mimeTypes,omitempty"`
+	// Brotli enables the brotli compression along with gzip. It requires
+	// the ngx_brotli module in the NGINX image.
+	// +optional
+	Brotli bool `json:"brotli,omitempty"`
+}
+
+// RpaasInstanceAccessLogSpec describes which requests are access logged.
+type RpaasInstanceAccessLogSpec struct {
+	// SampleRate logs only 1 in SampleRate requests. Zero or one logs every
+	// request.
+	// +optional
+	SampleRate int32 `json:"sampleRate,omitempty"`
+	// ExcludeSuccessful skips the requests answered with a 2xx status.
+	// +optional
+	ExcludeSuccessful bool `json:"excludeSuccessful,omitempty"`
+}
+
+// RpaasInstanceRateLimitSpec describes the limits applied to every client
+// address.
+type RpaasInstanceRateLimitSpec struct {
+	// ZoneSize is the size of the shared memory zones holding the clients'
+	// state (e.g. "10m").
+	// +optional
+	ZoneSize string `json:"zoneSize,omitempty"`
+	// RequestsPerSecond is the rate of requests allowed. Zero disables the
+	// requests limit.
+	// +optional
+	RequestsPerSecond int32 `json:"requestsPerSecond,omitempty"`
+	// Burst is the number of requests exceeding the rate which are still
+	// served.
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+	// Connections is the number of concurrent connections allowed. Zero
+	// disables the connections limit.
+	// +optional
+	Connections int32 `json:"connections,omitempty"`
+}
+
+// RpaasInstanceScaleToZeroSpec describes when an idle instance is scaled down
+// to zero replicas.
+type RpaasInstanceScaleToZeroSpec struct {
+	// IdleTimeoutSeconds is how long the instance must go without any
+	// request before being scaled to zero. Defaults to 900 (15 minutes).
+	// +optional
+	IdleTimeoutSeconds int32 `json:"idleTimeoutSeconds,omitempty"`
+}
+
+// RpaasInstanceMap is a NGINX map directive.
+type RpaasInstanceMap struct {
+	// Variable is the name, without the leading "$", of the variable set by
+	// the map.
+	Variable string `json:"variable"`
+	// Source is the expression compared against the entries' keys (e.g.
+	// "$http_user_agent").
+	Source string `json:"source"`
+	// Default is the value of the variable when none of the entries
+	// matches the source.
+	// +optional
+	Default string `json:"default,omitempty"`
+	// Entries pair the source values with the resulting values, in the
+	// order they are rendered.
+	Entries []RpaasInstanceMapEntry `json:"entries"`
+}
+
+// RpaasInstanceMapEntry is a single key/value pair of a map. The key may be a
+// regular expression (prefixed by "~").
+type RpaasInstanceMapEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type AutoscaleMetricType string
+
+const (
+	AutoscaleMetricTypeResource AutoscaleMetricType = "Resource"
+	AutoscaleMetricTypePods     AutoscaleMetricType = "Pods"
+	AutoscaleMetricTypeExternal AutoscaleMetricType = "External"
+)
+
+// RpaasInstanceAutoscaleMetric describes a single metric used by the
+// HorizontalPodAutoscaler.
+type RpaasInstanceAutoscaleMetric struct {
+	// Type is the kind of metric source: Resource, Pods or External.
+	Type AutoscaleMetricType `json:"type"`
+	// Name is the name of the metric, e.g. cpu, memory or requests_per_second.
+	Name string `json:"name"`
+	// Target is the desired value of the metric. On Resource metrics it's the
+	// average utilization percentage over all the pods, otherwise the average
+	// value per pod.
+	Target int32 `json:"target"`
 }
 
 func init() {
@@ -7,6 +7,7 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // RpaasPlanSpec defines the desired state of RpaasPlan
@@ -71,6 +72,10 @@ type NginxConfig struct {
 	HTTPListenOptions  string `json:"httpListenOptions,omitempty"`
 	HTTPSListenOptions string `json:"httpsListenOptions,omitempty"`
 
+	// TLSMinVersion is the oldest TLS version accepted on HTTPS, e.g.
+	// "TLSv1.2". Defaults to TLSv1 up to TLSv1.2 when empty.
+	TLSMinVersion string `json:"tlsMinVersion,omitempty"`
+
 	VTSEnabled *bool `json:"vtsEnabled,omitempty"`
 
 	SyslogEnabled       *bool  `json:"syslogEnabled,omitempty"`
@@ -78,7 +83,10 @@ type NginxConfig struct {
 	SyslogFacility      string `json:"syslogFacility,omitempty"`
 	SyslogTag           string `json:"syslogTag,omitempty"`
 
-	WorkerProcesses   int `json:"workerProcesses,omitempty"`
+	// WorkerProcesses is either a positive number or "auto". Defaults to 1
+	// when empty.
+	WorkerProcesses *intstr.IntOrString `json:"workerProcesses,omitempty"`
+	// WorkerConnections defaults to 1024 when empty.
 	WorkerConnections int `json:"workerConnections,omitempty"`
 }
 
@@ -12,16 +12,54 @@ import (
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Location) DeepCopyInto(out *Location) {
 	*out = *in
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]LocationDestination, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Content != nil {
 		in, out := &in.Content, &out.Content
 		*out = new(Value)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Buffering != nil {
+		in, out := &in.Buffering, &out.Buffering
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(LocationAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowCIDRs != nil {
+		in, out := &in.AllowCIDRs, &out.AllowCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DenyCIDRs != nil {
+		in, out := &in.DenyCIDRs, &out.DenyCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(LocationCanary)
+		**out = **in
+	}
+	if in.CORS != nil {
+		in, out := &in.CORS, &out.CORS
+		*out = new(LocationCORS)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -35,6 +73,95 @@ func (in *Location) DeepCopy() *Location {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationAuth) DeepCopyInto(out *LocationAuth) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationAuth.
+func (in *LocationAuth) DeepCopy() *LocationAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationCORS) DeepCopyInto(out *LocationCORS) {
+	*out = *in
+	if in.AllowOrigins != nil {
+		in, out := &in.AllowOrigins, &out.AllowOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowMethods != nil {
+		in, out := &in.AllowMethods, &out.AllowMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowHeaders != nil {
+		in, out := &in.AllowHeaders, &out.AllowHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationCORS.
+func (in *LocationCORS) DeepCopy() *LocationCORS {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationCORS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationCanary) DeepCopyInto(out *LocationCanary) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationCanary.
+func (in *LocationCanary) DeepCopy() *LocationCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationDestination) DeepCopyInto(out *LocationDestination) {
+	*out = *in
+	if in.MaxFails != nil {
+		in, out := &in.MaxFails, &out.MaxFails
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationDestination.
+func (in *LocationDestination) DeepCopy() *LocationDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NginxConfig) DeepCopyInto(out *NginxConfig) {
 	*out = *in
@@ -58,6 +185,11 @@ func (in *NginxConfig) DeepCopyInto(out *NginxConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.WorkerProcesses != nil {
+		in, out := &in.WorkerProcesses, &out.WorkerProcesses
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
 	return
 }
 
@@ -99,6 +231,38 @@ func (in *RpaasInstance) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceAccessLogSpec) DeepCopyInto(out *RpaasInstanceAccessLogSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceAccessLogSpec.
+func (in *RpaasInstanceAccessLogSpec) DeepCopy() *RpaasInstanceAccessLogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceAccessLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceAutoscaleMetric) DeepCopyInto(out *RpaasInstanceAutoscaleMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceAutoscaleMetric.
+func (in *RpaasInstanceAutoscaleMetric) DeepCopy() *RpaasInstanceAutoscaleMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceAutoscaleMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RpaasInstanceAutoscaleSpec) DeepCopyInto(out *RpaasInstanceAutoscaleSpec) {
 	*out = *in
@@ -117,6 +281,11 @@ func (in *RpaasInstanceAutoscaleSpec) DeepCopyInto(out *RpaasInstanceAutoscaleSp
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]RpaasInstanceAutoscaleMetric, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -130,6 +299,59 @@ func (in *RpaasInstanceAutoscaleSpec) DeepCopy() *RpaasInstanceAutoscaleSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceClientAuthSpec) DeepCopyInto(out *RpaasInstanceClientAuthSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceClientAuthSpec.
+func (in *RpaasInstanceClientAuthSpec) DeepCopy() *RpaasInstanceClientAuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceClientAuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceCompressionSpec) DeepCopyInto(out *RpaasInstanceCompressionSpec) {
+	*out = *in
+	if in.MimeTypes != nil {
+		in, out := &in.MimeTypes, &out.MimeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceCompressionSpec.
+func (in *RpaasInstanceCompressionSpec) DeepCopy() *RpaasInstanceCompressionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceCompressionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceIngressSpec) DeepCopyInto(out *RpaasInstanceIngressSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceIngressSpec.
+func (in *RpaasInstanceIngressSpec) DeepCopy() *RpaasInstanceIngressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceIngressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RpaasInstanceList) DeepCopyInto(out *RpaasInstanceList) {
 	*out = *in
@@ -163,6 +385,101 @@ func (in *RpaasInstanceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceMap) DeepCopyInto(out *RpaasInstanceMap) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]RpaasInstanceMapEntry, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceMap.
+func (in *RpaasInstanceMap) DeepCopy() *RpaasInstanceMap {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceMapEntry) DeepCopyInto(out *RpaasInstanceMapEntry) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceMapEntry.
+func (in *RpaasInstanceMapEntry) DeepCopy() *RpaasInstanceMapEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceMapEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstancePodDisruptionBudgetSpec) DeepCopyInto(out *RpaasInstancePodDisruptionBudgetSpec) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstancePodDisruptionBudgetSpec.
+func (in *RpaasInstancePodDisruptionBudgetSpec) DeepCopy() *RpaasInstancePodDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstancePodDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceRateLimitSpec) DeepCopyInto(out *RpaasInstanceRateLimitSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceRateLimitSpec.
+func (in *RpaasInstanceRateLimitSpec) DeepCopy() *RpaasInstanceRateLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceRateLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceScaleToZeroSpec) DeepCopyInto(out *RpaasInstanceScaleToZeroSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceScaleToZeroSpec.
+func (in *RpaasInstanceScaleToZeroSpec) DeepCopy() *RpaasInstanceScaleToZeroSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceScaleToZeroSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RpaasInstanceSpec) DeepCopyInto(out *RpaasInstanceSpec) {
 	*out = *in
@@ -195,6 +512,16 @@ func (in *RpaasInstanceSpec) DeepCopyInto(out *RpaasInstanceSpec) {
 		*out = new(nginxv1alpha1.TLSSecret)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClientAuth != nil {
+		in, out := &in.ClientAuth, &out.ClientAuth
+		*out = new(RpaasInstanceClientAuthSpec)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(RpaasInstanceTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Service != nil {
 		in, out := &in.Service, &out.Service
 		*out = new(nginxv1alpha1.NginxService)
@@ -216,6 +543,72 @@ func (in *RpaasInstanceSpec) DeepCopyInto(out *RpaasInstanceSpec) {
 		*out = new(RpaasInstanceAutoscaleSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ScaleToZero != nil {
+		in, out := &in.ScaleToZero, &out.ScaleToZero
+		*out = new(RpaasInstanceScaleToZeroSpec)
+		**out = **in
+	}
+	if in.HideServerTokens != nil {
+		in, out := &in.HideServerTokens, &out.HideServerTokens
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ExtraPorts != nil {
+		in, out := &in.ExtraPorts, &out.ExtraPorts
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]TopologySpreadConstraint, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(RpaasInstancePodDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(RpaasInstanceIngressSpec)
+		**out = **in
+	}
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = new(RpaasInstanceCompressionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AccessLog != nil {
+		in, out := &in.AccessLog, &out.AccessLog
+		*out = new(RpaasInstanceAccessLogSpec)
+		**out = **in
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RpaasInstanceRateLimitSpec)
+		**out = **in
+	}
+	if in.Maps != nil {
+		in, out := &in.Maps, &out.Maps
+		*out = make([]RpaasInstanceMap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -245,6 +638,27 @@ func (in *RpaasInstanceStatus) DeepCopy() *RpaasInstanceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RpaasInstanceTLSSpec) DeepCopyInto(out *RpaasInstanceTLSSpec) {
+	*out = *in
+	if in.ECDHCurves != nil {
+		in, out := &in.ECDHCurves, &out.ECDHCurves
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RpaasInstanceTLSSpec.
+func (in *RpaasInstanceTLSSpec) DeepCopy() *RpaasInstanceTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RpaasInstanceTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RpaasPlan) DeepCopyInto(out *RpaasPlan) {
 	*out = *in
@@ -328,6 +742,22 @@ func (in *RpaasPlanSpec) DeepCopy() *RpaasPlanSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpreadConstraint) DeepCopyInto(out *TopologySpreadConstraint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpreadConstraint.
+func (in *TopologySpreadConstraint) DeepCopy() *TopologySpreadConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpreadConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Value) DeepCopyInto(out *Value) {
 	*out = *in
@@ -0,0 +1,138 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaasinstance
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	defaultCallbackQueueSize = 100
+	defaultCallbackTimeout   = 10 * time.Second
+
+	callbackSignatureHeader = "X-Rpaas-Signature"
+)
+
+var defaultCallbackBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+}
+
+// statusCallback is the payload POSTed to an instance's status callback URL.
+type statusCallback struct {
+	Instance   string                      `json:"instance"`
+	Namespace  string                      `json:"namespace"`
+	Phase      v1alpha1.RpaasInstancePhase `json:"phase"`
+	Message    string                      `json:"message,omitempty"`
+	Generation int64                       `json:"generation"`
+	Timestamp  time.Time                   `json:"timestamp"`
+}
+
+type callbackRequest struct {
+	url     string
+	payload statusCallback
+}
+
+// callbackNotifier delivers the status callbacks in background, so the
+// reconciliation never waits for the remote endpoints. Callbacks are dropped
+// when the queue is full.
+type callbackNotifier struct {
+	client  *http.Client
+	backoff wait.Backoff
+	queue   chan callbackRequest
+}
+
+func newCallbackNotifier(queueSize int) *callbackNotifier {
+	return &callbackNotifier{
+		client:  &http.Client{Timeout: defaultCallbackTimeout},
+		backoff: defaultCallbackBackoff,
+		queue:   make(chan callbackRequest, queueSize),
+	}
+}
+
+// Start implements manager.Runnable, delivering the queued callbacks until
+// stop is closed.
+func (n *callbackNotifier) Start(stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case req := <-n.queue:
+			n.deliver(req)
+		}
+	}
+}
+
+// Notify enqueues the callback, returning false when the queue is full.
+func (n *callbackNotifier) Notify(url string, payload statusCallback) bool {
+	select {
+	case n.queue <- callbackRequest{url: url, payload: payload}:
+		return true
+	default:
+		log.Info("Status callback queue is full, dropping notification", "Instance", payload.Instance, "Phase", payload.Phase)
+		return false
+	}
+}
+
+func (n *callbackNotifier) deliver(req callbackRequest) {
+	err := wait.ExponentialBackoff(n.backoff, func() (bool, error) {
+		if err := n.send(context.TODO(), req); err != nil {
+			log.Error(err, "Could not send status callback", "Instance", req.payload.Instance, "URL", req.url)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		log.Error(err, "Giving up on status callback", "Instance", req.payload.Instance, "URL", req.url)
+	}
+}
+
+func (n *callbackNotifier) send(ctx context.Context, req callbackRequest) error {
+	body, err := json.Marshal(req.payload)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, req.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if secret := config.Get().CallbackSecret; secret != "" {
+		httpReq.Header.Set(callbackSignatureHeader, "sha256="+signCallback([]byte(secret), body))
+	}
+
+	rsp, err := n.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+func signCallback(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
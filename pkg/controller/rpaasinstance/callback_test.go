@@ -0,0 +1,59 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaasinstance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsuru/rpaas-operator/config"
+	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func Test_callbackNotifier_deliver(t *testing.T) {
+	config.Set(config.RpaasConfig{CallbackSecret: "my-secret"})
+	defer config.Set(config.RpaasConfig{})
+
+	var attempts int
+	var received statusCallback
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "sha256="+signCallback([]byte("my-secret"), body), r.Header.Get(callbackSignatureHeader))
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	notifier := newCallbackNotifier(1)
+	notifier.client = srv.Client()
+	notifier.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 5}
+
+	payload := statusCallback{Instance: "my-instance", Namespace: "default", Phase: v1alpha1.RpaasInstancePhaseReady, Generation: 2}
+	notifier.deliver(callbackRequest{url: srv.URL, payload: payload})
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, payload, received)
+}
+
+func Test_callbackNotifier_Notify(t *testing.T) {
+	notifier := newCallbackNotifier(1)
+	assert.True(t, notifier.Notify("https://callback.example.com", statusCallback{Instance: "instance1"}))
+	assert.False(t, notifier.Notify("https://callback.example.com", statusCallback{Instance: "instance2"}))
+	req := <-notifier.queue
+	assert.Equal(t, "instance1", req.payload.Instance)
+}
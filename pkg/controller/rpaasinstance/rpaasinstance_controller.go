@@ -7,12 +7,12 @@ package rpaasinstance
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 
-	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/sirupsen/logrus"
 	nginxV1alpha1 "github.com/tsuru/nginx-operator/pkg/apis/nginx/v1alpha1"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/nginx"
@@ -21,13 +21,15 @@ import (
 	"github.com/tsuru/rpaas-operator/pkg/util"
 	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	k8sResources "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -46,12 +48,16 @@ var log = logf.Log.WithName("controller_rpaasinstance")
 // Add creates a new RpaasInstance Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	notifier := newCallbackNotifier(defaultCallbackQueueSize)
+	if err := mgr.Add(notifier); err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, notifier))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileRpaasInstance{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, notifier *callbackNotifier) reconcile.Reconciler {
+	return &ReconcileRpaasInstance{client: mgr.GetClient(), scheme: mgr.GetScheme(), notifier: notifier}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -80,6 +86,16 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		IsController: true,
 		OwnerType:    &extensionsv1alpha1.RpaasInstance{},
 	})
+	if err != nil {
+		return err
+	}
+
+	// The extra ports Service waits for the load balancer IP of the Service
+	// created by nginx-operator, whose Nginx object is named after the instance.
+	err = c.Watch(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &nginxV1alpha1.Nginx{},
+	})
 
 	return err
 }
@@ -92,6 +108,9 @@ type ReconcileRpaasInstance struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// notifier sends the status transitions to the instance's callback URL.
+	// It's optional.
+	notifier *callbackNotifier
 }
 
 // Reconcile reads that state of the cluster for a RpaasInstance object and makes changes based on the state read
@@ -110,50 +129,109 @@ func (r *ReconcileRpaasInstance) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, err
 	}
 
+	err = r.reconcileInstance(instance)
+	if statusErr := r.updateStatus(context.TODO(), instance, err); statusErr != nil && err == nil {
+		err = statusErr
+	}
+
+	return reconcile.Result{}, err
+}
+
+func (r *ReconcileRpaasInstance) reconcileInstance(instance *v1alpha1.RpaasInstance) error {
 	planName := types.NamespacedName{
 		Name:      instance.Spec.PlanName,
 		Namespace: instance.Namespace,
 	}
 	plan := &v1alpha1.RpaasPlan{}
-	err = r.client.Get(context.TODO(), planName, plan)
+	err := r.client.Get(context.TODO(), planName, plan)
 	if err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 	if instance.Spec.PlanTemplate != nil {
-		plan.Spec, err = mergePlans(plan.Spec, *instance.Spec.PlanTemplate)
+		plan.Spec, err = util.MergePlans(plan.Spec, *instance.Spec.PlanTemplate)
 		if err != nil {
-			return reconcile.Result{}, err
+			return err
 		}
 	}
 	rendered, err := r.renderTemplate(instance, plan)
 	if err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 	configMap := newConfigMap(instance, rendered)
 	err = r.reconcileConfigMap(configMap)
 	if err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 	configList, err := r.listConfigs(instance)
 	if err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 	if shouldDeleteOldConfig(instance, configList) {
 		if err = r.deleteOldConfig(instance, configList); err != nil {
-			return reconcile.Result{}, err
+			return err
 		}
 	}
 	nginx := newNginx(instance, plan, configMap)
 
 	if err = r.reconcileNginx(nginx); err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 
 	if err = r.reconcileHPA(context.TODO(), *instance, *nginx); err != nil {
-		return reconcile.Result{}, err
+		return err
+	}
+
+	if err = r.reconcilePDB(context.TODO(), *instance); err != nil {
+		return err
 	}
 
-	return reconcile.Result{}, nil
+	if err = r.reconcileIngress(context.TODO(), *instance); err != nil {
+		return err
+	}
+
+	if err = r.reconcileExtraPortsService(context.TODO(), *instance); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateStatus records the result of the reconciliation, along with the
+// instance generation just applied so clients can tell whether their changes
+// are live. Phase transitions are sent to the instance's callback URL.
+func (r *ReconcileRpaasInstance) updateStatus(ctx context.Context, instance *v1alpha1.RpaasInstance, reconcileErr error) error {
+	status := instance.Status
+	if reconcileErr != nil {
+		status.Phase = v1alpha1.RpaasInstancePhaseFailed
+		status.Message = reconcileErr.Error()
+	} else {
+		status.Phase = v1alpha1.RpaasInstancePhaseReady
+		status.Message = ""
+		status.ObservedGeneration = instance.Generation
+	}
+
+	if status == instance.Status {
+		return nil
+	}
+
+	transition := status.Phase != instance.Status.Phase
+	instance.Status = status
+	if err := r.client.Status().Update(ctx, instance); err != nil {
+		return err
+	}
+
+	if transition && r.notifier != nil && instance.Spec.StatusCallbackURL != "" {
+		r.notifier.Notify(instance.Spec.StatusCallbackURL, statusCallback{
+			Instance:   instance.Name,
+			Namespace:  instance.Namespace,
+			Phase:      status.Phase,
+			Message:    status.Message,
+			Generation: instance.Generation,
+			Timestamp:  time.Now().UTC(),
+		})
+	}
+
+	return nil
 }
 
 func (r *ReconcileRpaasInstance) reconcileHPA(ctx context.Context, instance v1alpha1.RpaasInstance, nginx nginxV1alpha1.Nginx) error {
@@ -216,28 +294,207 @@ func (r *ReconcileRpaasInstance) reconcileHPA(ctx context.Context, instance v1al
 	return nil
 }
 
-func mergePlans(base v1alpha1.RpaasPlanSpec, override v1alpha1.RpaasPlanSpec) (v1alpha1.RpaasPlanSpec, error) {
-	baseData, err := json.Marshal(base)
-	if err != nil {
-		return base, err
+func (r *ReconcileRpaasInstance) reconcilePDB(ctx context.Context, instance v1alpha1.RpaasInstance) error {
+	logger := log.WithName("reconcilePDB").
+		WithValues("RpaasInstance", types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+
+	logger.V(4).Info("Starting reconciliation of PodDisruptionBudget")
+	defer logger.V(4).Info("Finishing reconciliation of PodDisruptionBudget")
+
+	var pdb policyv1beta1.PodDisruptionBudget
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &pdb)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		if instance.Spec.PodDisruptionBudget == nil {
+			logger.V(4).Info("Skipping PodDisruptionBudget reconciliation: both PDB resource and desired spec not found")
+			return nil
+		}
+
+		logger.V(4).Info("Creating PodDisruptionBudget resource")
+
+		pdb = newPDB(instance)
+		if err = r.client.Create(ctx, &pdb); err != nil {
+			logger.Error(err, "Unable to create the PodDisruptionBudget resource")
+			return err
+		}
+
+		return nil
 	}
-	overrideData, err := json.Marshal(override)
+
 	if err != nil {
-		return base, err
+		logger.Error(err, "Unable to get the PodDisruptionBudget resource")
+		return err
+	}
+
+	if instance.Spec.PodDisruptionBudget == nil {
+		logger.V(4).Info("Deleting PodDisruptionBudget resource")
+		if err = r.client.Delete(ctx, &pdb); err != nil {
+			logger.Error(err, "Unable to delete the PodDisruptionBudget resource")
+			return err
+		}
+
+		return nil
+	}
+
+	newerPDB := newPDB(instance)
+	if !reflect.DeepEqual(pdb.Spec, newerPDB.Spec) {
+		logger.V(4).Info("Updating the PodDisruptionBudget spec")
+
+		// PodDisruptionBudget specs are immutable in this API version, so
+		// the resource must be recreated.
+		if err = r.client.Delete(ctx, &pdb); err != nil {
+			logger.Error(err, "Unable to delete the PodDisruptionBudget resource")
+			return err
+		}
+		if err = r.client.Create(ctx, &newerPDB); err != nil {
+			logger.Error(err, "Unable to create the PodDisruptionBudget resource")
+			return err
+		}
 	}
-	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(baseData, overrideData, baseData)
+
+	return nil
+}
+
+func (r *ReconcileRpaasInstance) reconcileIngress(ctx context.Context, instance v1alpha1.RpaasInstance) error {
+	logger := log.WithName("reconcileIngress").
+		WithValues("RpaasInstance", types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+
+	logger.V(4).Info("Starting reconciliation of Ingress")
+	defer logger.V(4).Info("Finishing reconciliation of Ingress")
+
+	var ingress extensionsv1beta1.Ingress
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &ingress)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		if instance.Spec.Ingress == nil {
+			logger.V(4).Info("Skipping Ingress reconciliation: both Ingress resource and desired spec not found")
+			return nil
+		}
+
+		logger.V(4).Info("Creating Ingress resource")
+
+		ingress = newIngress(instance)
+		if err = r.client.Create(ctx, &ingress); err != nil {
+			logger.Error(err, "Unable to create the Ingress resource")
+			return err
+		}
+
+		return nil
+	}
+
 	if err != nil {
-		return base, err
+		logger.Error(err, "Unable to get the Ingress resource")
+		return err
+	}
+
+	if instance.Spec.Ingress == nil {
+		logger.V(4).Info("Deleting Ingress resource")
+		if err = r.client.Delete(ctx, &ingress); err != nil {
+			logger.Error(err, "Unable to delete the Ingress resource")
+			return err
+		}
+
+		return nil
 	}
-	merged, err := jsonpatch.MergePatch(baseData, patch)
+
+	newerIngress := newIngress(instance)
+	if !reflect.DeepEqual(ingress.Spec, newerIngress.Spec) || !reflect.DeepEqual(ingress.Annotations, newerIngress.Annotations) {
+		logger.V(4).Info("Updating the Ingress spec")
+
+		ingress.Annotations = newerIngress.Annotations
+		ingress.Spec = newerIngress.Spec
+		if err = r.client.Update(ctx, &ingress); err != nil {
+			logger.Error(err, "Unable to update the Ingress resource")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileRpaasInstance) reconcileExtraPortsService(ctx context.Context, instance v1alpha1.RpaasInstance) error {
+	logger := log.WithName("reconcileExtraPortsService").
+		WithValues("RpaasInstance", types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+
+	logger.V(4).Info("Starting reconciliation of extra ports Service")
+	defer logger.V(4).Info("Finishing reconciliation of extra ports Service")
+
+	var service corev1.Service
+	err := r.client.Get(ctx, types.NamespacedName{Name: extraPortsServiceName(instance), Namespace: instance.Namespace}, &service)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		if !needsExtraPortsService(instance) {
+			logger.V(4).Info("Skipping extra ports Service reconciliation: both Service resource and extra ports not found")
+			return nil
+		}
+
+		loadBalancerIP, err := r.nginxServiceLoadBalancerIP(ctx, instance)
+		if err != nil {
+			logger.Error(err, "Unable to get the load balancer IP of the Nginx Service resource")
+			return err
+		}
+
+		service = newExtraPortsService(instance, loadBalancerIP)
+		if service.Spec.Type == corev1.ServiceTypeLoadBalancer && loadBalancerIP == "" {
+			logger.V(4).Info("Skipping extra ports Service creation: the load balancer IP of the Nginx Service is still pending")
+			return nil
+		}
+
+		logger.V(4).Info("Creating extra ports Service resource")
+
+		if err = r.client.Create(ctx, &service); err != nil {
+			logger.Error(err, "Unable to create the extra ports Service resource")
+			return err
+		}
+
+		return nil
+	}
+
 	if err != nil {
-		return base, err
+		logger.Error(err, "Unable to get the extra ports Service resource")
+		return err
+	}
+
+	if !needsExtraPortsService(instance) {
+		logger.V(4).Info("Deleting extra ports Service resource")
+		if err = r.client.Delete(ctx, &service); err != nil {
+			logger.Error(err, "Unable to delete the extra ports Service resource")
+			return err
+		}
+
+		return nil
 	}
-	err = json.Unmarshal(merged, &base)
+
+	loadBalancerIP, err := r.nginxServiceLoadBalancerIP(ctx, instance)
 	if err != nil {
-		return base, err
+		logger.Error(err, "Unable to get the load balancer IP of the Nginx Service resource")
+		return err
+	}
+	if loadBalancerIP == "" {
+		loadBalancerIP = service.Spec.LoadBalancerIP
 	}
-	return base, nil
+
+	newerService := newExtraPortsService(instance, loadBalancerIP)
+	// Keeps the node ports allocated to the ports still in use.
+	for i, newPort := range newerService.Spec.Ports {
+		for _, port := range service.Spec.Ports {
+			if port.Port == newPort.Port {
+				newerService.Spec.Ports[i].NodePort = port.NodePort
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(service.Spec.Ports, newerService.Spec.Ports) || service.Spec.Type != newerService.Spec.Type || service.Spec.LoadBalancerIP != newerService.Spec.LoadBalancerIP || !reflect.DeepEqual(service.Annotations, newerService.Annotations) {
+		logger.V(4).Info("Updating the extra ports Service spec")
+
+		service.Annotations = newerService.Annotations
+		service.Spec.Type = newerService.Spec.Type
+		service.Spec.LoadBalancerIP = newerService.Spec.LoadBalancerIP
+		service.Spec.Ports = newerService.Spec.Ports
+		if err = r.client.Update(ctx, &service); err != nil {
+			logger.Error(err, "Unable to update the extra ports Service resource")
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *ReconcileRpaasInstance) reconcileConfigMap(configMap *corev1.ConfigMap) error {
@@ -441,12 +698,311 @@ func newNginx(instance *v1alpha1.RpaasInstance, plan *v1alpha1.RpaasPlan, config
 				Kind: nginxV1alpha1.ConfigKindConfigMap,
 			},
 			Resources:       plan.Spec.Resources,
-			Service:         instance.Spec.Service,
-			HealthcheckPath: "/_nginx_healthcheck",
+			Service:         newNginxService(instance),
+			HealthcheckPath: nginx.HealthcheckPath(instance),
 			ExtraFiles:      instance.Spec.ExtraFiles,
 			Certificates:    instance.Spec.Certificates,
 			Cache:           cacheConfig,
-			PodTemplate:     instance.Spec.PodTemplate,
+			PodTemplate:     newNginxPodTemplate(instance),
+		},
+	}
+}
+
+// nginxResourceNameLabel is set by nginx-operator on every pod of an Nginx
+// resource.
+const nginxResourceNameLabel = "nginx.tsuru.io/resource-name"
+
+const (
+	proxyProtocolServiceAnnotation = "service.beta.kubernetes.io/aws-load-balancer-proxy-protocol"
+	externalDNSAnnotationPrefix    = "external-dns.alpha.kubernetes.io/"
+	externalDNSHostnameAnnotation  = externalDNSAnnotationPrefix + "hostname"
+)
+
+func newNginxService(instance *v1alpha1.RpaasInstance) *nginxV1alpha1.NginxService {
+	proxyProtocol := instance.Spec.Service != nil && instance.Spec.ProxyProtocol
+	if !proxyProtocol && instance.Spec.ExternalHostname == "" {
+		return instance.Spec.Service
+	}
+	service := &nginxV1alpha1.NginxService{}
+	if instance.Spec.Service != nil {
+		service = instance.Spec.Service.DeepCopy()
+	}
+	if service.Annotations == nil {
+		service.Annotations = make(map[string]string)
+	}
+	if proxyProtocol {
+		service.Annotations[proxyProtocolServiceAnnotation] = "*"
+	}
+	if instance.Spec.ExternalHostname != "" {
+		service.Annotations[externalDNSHostnameAnnotation] = instance.Spec.ExternalHostname
+	}
+	return service
+}
+
+func newNginxPodTemplate(instance *v1alpha1.RpaasInstance) nginxV1alpha1.NginxPodTemplateSpec {
+	if len(instance.Spec.NodeSelector) == 0 && len(instance.Spec.TopologySpreadConstraints) == 0 {
+		return instance.Spec.PodTemplate
+	}
+	podTemplate := *instance.Spec.PodTemplate.DeepCopy()
+	if len(instance.Spec.NodeSelector) > 0 {
+		podTemplate.Affinity = mergeNodeSelector(podTemplate.Affinity, instance.Spec.NodeSelector)
+	}
+	if len(instance.Spec.TopologySpreadConstraints) > 0 {
+		podTemplate.Affinity = mergeTopologySpreadConstraints(podTemplate.Affinity, instance.Name, instance.Spec.TopologySpreadConstraints)
+	}
+	return podTemplate
+}
+
+// mergeTopologySpreadConstraints approximates each constraint with a pod
+// anti-affinity among the instance's pods over the constraint's topology
+// key: a required one for DoNotSchedule and a preferred one otherwise.
+func mergeTopologySpreadConstraints(affinity *corev1.Affinity, name string, constraints []v1alpha1.TopologySpreadConstraint) *corev1.Affinity {
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	antiAffinity := affinity.PodAntiAffinity
+	for _, c := range constraints {
+		term := corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{nginxResourceNameLabel: name},
+			},
+			TopologyKey: c.TopologyKey,
+		}
+		if c.WhenUnsatisfiable == v1alpha1.DoNotSchedule {
+			antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+			continue
+		}
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          100,
+			PodAffinityTerm: term,
+		})
+	}
+	return affinity
+}
+
+// mergeNodeSelector adds a required node affinity term for each label of
+// nodeSelector, keeping any node affinity already set in the pod template.
+func mergeNodeSelector(affinity *corev1.Affinity, nodeSelector map[string]string) *corev1.Affinity {
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	var keys []string
+	for key := range nodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for i := range required.NodeSelectorTerms {
+		for _, key := range keys {
+			required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions, corev1.NodeSelectorRequirement{
+				Key:      key,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{nodeSelector[key]},
+			})
+		}
+	}
+
+	return affinity
+}
+
+func newPDB(instance v1alpha1.RpaasInstance) policyv1beta1.PodDisruptionBudget {
+	return policyv1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(&instance, schema.GroupVersionKind{
+					Group:   v1alpha1.SchemeGroupVersion.Group,
+					Version: v1alpha1.SchemeGroupVersion.Version,
+					Kind:    "RpaasInstance",
+				}),
+			},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable:   instance.Spec.PodDisruptionBudget.MinAvailable,
+			MaxUnavailable: instance.Spec.PodDisruptionBudget.MaxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{nginxResourceNameLabel: instance.Name},
+			},
+		},
+	}
+}
+
+// ingressClassAnnotation selects the Ingress controller in API versions
+// without the ingressClassName field.
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+func newIngress(instance v1alpha1.RpaasInstance) extensionsv1beta1.Ingress {
+	spec := instance.Spec.Ingress
+	ingress := extensionsv1beta1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Ingress",
+			APIVersion: "extensions/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(&instance, schema.GroupVersionKind{
+					Group:   v1alpha1.SchemeGroupVersion.Group,
+					Version: v1alpha1.SchemeGroupVersion.Version,
+					Kind:    "RpaasInstance",
+				}),
+			},
+			Annotations: map[string]string{ingressClassAnnotation: spec.ClassName},
+		},
+		Spec: extensionsv1beta1.IngressSpec{
+			Rules: []extensionsv1beta1.IngressRule{
+				{
+					Host: spec.Host,
+					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
+							Paths: []extensionsv1beta1.HTTPIngressPath{
+								{
+									Backend: extensionsv1beta1.IngressBackend{
+										// Service created by nginx-operator for the Nginx resource.
+										ServiceName: instance.Name + "-service",
+										ServicePort: intstr.FromInt(80),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		ingress.Spec.TLS = []extensionsv1beta1.IngressTLS{
+			{Hosts: []string{spec.Host}, SecretName: spec.TLSSecretName},
+		}
+	}
+	return ingress
+}
+
+func extraPortsServiceName(instance v1alpha1.RpaasInstance) string {
+	return instance.Name + "-extra-ports"
+}
+
+// nginxServiceLoadBalancerIP returns the IP of the load balancer in front of
+// the Service created by nginx-operator, so the extra ports can share it.
+// It's empty while the load balancer isn't provisioned.
+func (r *ReconcileRpaasInstance) nginxServiceLoadBalancerIP(ctx context.Context, instance v1alpha1.RpaasInstance) (string, error) {
+	if instance.Spec.Service != nil && instance.Spec.Service.LoadBalancerIP != "" {
+		return instance.Spec.Service.LoadBalancerIP, nil
+	}
+	var service corev1.Service
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Name + "-service", Namespace: instance.Namespace}, &service)
+	if k8sErrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP, nil
+		}
+	}
+	return "", nil
+}
+
+// needsExtraPortsService tells whether the instance listens on any port the
+// Service created by nginx-operator doesn't expose.
+func needsExtraPortsService(instance v1alpha1.RpaasInstance) bool {
+	return len(instance.Spec.ExtraPorts) > 0 || instance.Spec.HTTP3
+}
+
+// newExtraPortsService returns the Service exposing the instance's extra
+// ports, alongside the one created by nginx-operator for HTTP and HTTPS.
+// As that one only has TCP ports, the HTTP/3 (UDP) port is exposed here too.
+// It shares the load balancer IP of the nginx-operator Service, which
+// clients reach through the Alt-Svc header, and leaves the DNS records to it.
+func newExtraPortsService(instance v1alpha1.RpaasInstance, loadBalancerIP string) corev1.Service {
+	nginxService := newNginxService(&instance)
+	if nginxService == nil {
+		nginxService = &nginxV1alpha1.NginxService{}
+	}
+
+	serviceType := nginxService.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	if serviceType != corev1.ServiceTypeLoadBalancer {
+		loadBalancerIP = ""
+	}
+
+	var annotations map[string]string
+	for k, v := range nginxService.Annotations {
+		if strings.HasPrefix(k, externalDNSAnnotationPrefix) {
+			continue
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[k] = v
+	}
+
+	var ports []corev1.ServicePort
+	for _, port := range instance.Spec.ExtraPorts {
+		ports = append(ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("extra-%d", port),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port,
+			TargetPort: intstr.FromInt(int(port)),
+		})
+	}
+	if instance.Spec.HTTP3 {
+		ports = append(ports, corev1.ServicePort{
+			Name:       "http3",
+			Protocol:   corev1.ProtocolUDP,
+			Port:       443,
+			TargetPort: intstr.FromInt(8443),
+		})
+	}
+
+	return corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      extraPortsServiceName(instance),
+			Namespace: instance.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(&instance, schema.GroupVersionKind{
+					Group:   v1alpha1.SchemeGroupVersion.Group,
+					Version: v1alpha1.SchemeGroupVersion.Version,
+					Kind:    "RpaasInstance",
+				}),
+			},
+			Labels:      nginxService.Labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:           serviceType,
+			Ports:          ports,
+			Selector:       map[string]string{nginxResourceNameLabel: instance.Name},
+			LoadBalancerIP: loadBalancerIP,
 		},
 	}
 }
@@ -480,6 +1036,12 @@ func newHPA(instance v1alpha1.RpaasInstance, nginx nginxV1alpha1.Nginx) autoscal
 		})
 	}
 
+	for _, m := range instance.Spec.Autoscale.Metrics {
+		if metric, ok := newHPAMetricSpec(m); ok {
+			metrics = append(metrics, metric)
+		}
+	}
+
 	minReplicas := instance.Spec.Autoscale.MinReplicas
 	if minReplicas == nil && instance.Spec.Replicas != nil {
 		minReplicas = instance.Spec.Replicas
@@ -514,6 +1076,47 @@ func newHPA(instance v1alpha1.RpaasInstance, nginx nginxV1alpha1.Nginx) autoscal
 	}
 }
 
+func newHPAMetricSpec(m v1alpha1.RpaasInstanceAutoscaleMetric) (autoscalingv2beta2.MetricSpec, bool) {
+	target := m.Target
+	averageValue := k8sResources.NewQuantity(int64(m.Target), k8sResources.DecimalSI)
+	switch m.Type {
+	case v1alpha1.AutoscaleMetricTypeResource:
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta2.ResourceMetricSource{
+				Name: corev1.ResourceName(m.Name),
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:               autoscalingv2beta2.UtilizationMetricType,
+					AverageUtilization: &target,
+				},
+			},
+		}, true
+	case v1alpha1.AutoscaleMetricTypePods:
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.PodsMetricSourceType,
+			Pods: &autoscalingv2beta2.PodsMetricSource{
+				Metric: autoscalingv2beta2.MetricIdentifier{Name: m.Name},
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:         autoscalingv2beta2.AverageValueMetricType,
+					AverageValue: averageValue,
+				},
+			},
+		}, true
+	case v1alpha1.AutoscaleMetricTypeExternal:
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ExternalMetricSourceType,
+			External: &autoscalingv2beta2.ExternalMetricSource{
+				Metric: autoscalingv2beta2.MetricIdentifier{Name: m.Name},
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:         autoscalingv2beta2.AverageValueMetricType,
+					AverageValue: averageValue,
+				},
+			},
+		}, true
+	}
+	return autoscalingv2beta2.MetricSpec{}, false
+}
+
 func shouldDeleteOldConfig(instance *v1alpha1.RpaasInstance, configList *corev1.ConfigMapList) bool {
 	limit := defaultConfigHistoryLimit
 
@@ -6,6 +6,7 @@ package rpaasinstance
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,55 +15,214 @@ import (
 	"github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
 	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-func Test_mergePlans(t *testing.T) {
+func Test_newNginxService(t *testing.T) {
 	tests := []struct {
-		base     v1alpha1.RpaasPlanSpec
-		override v1alpha1.RpaasPlanSpec
-		expected v1alpha1.RpaasPlanSpec
+		name     string
+		instance *v1alpha1.RpaasInstance
+		expected *nginxv1alpha1.NginxService
 	}{
 		{
-			base:     v1alpha1.RpaasPlanSpec{},
-			override: v1alpha1.RpaasPlanSpec{},
-			expected: v1alpha1.RpaasPlanSpec{},
+			name:     "without service",
+			instance: &v1alpha1.RpaasInstance{},
 		},
 		{
-			base:     v1alpha1.RpaasPlanSpec{Image: "img0", Description: "a", Config: v1alpha1.NginxConfig{User: "root", CacheEnabled: v1alpha1.Bool(true)}},
-			override: v1alpha1.RpaasPlanSpec{Image: "img1"},
-			expected: v1alpha1.RpaasPlanSpec{Image: "img1", Description: "a", Config: v1alpha1.NginxConfig{User: "root", CacheEnabled: v1alpha1.Bool(true)}},
+			name: "without PROXY protocol",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					Service: &nginxv1alpha1.NginxService{Type: corev1.ServiceTypeLoadBalancer},
+				},
+			},
+			expected: &nginxv1alpha1.NginxService{Type: corev1.ServiceTypeLoadBalancer},
+		},
+		{
+			name: "with PROXY protocol",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					ProxyProtocol: true,
+					Service: &nginxv1alpha1.NginxService{
+						Type:        corev1.ServiceTypeLoadBalancer,
+						Annotations: map[string]string{"some-annotation": "some-value"},
+					},
+				},
+			},
+			expected: &nginxv1alpha1.NginxService{
+				Type: corev1.ServiceTypeLoadBalancer,
+				Annotations: map[string]string{
+					"some-annotation": "some-value",
+					"service.beta.kubernetes.io/aws-load-balancer-proxy-protocol": "*",
+				},
+			},
 		},
 		{
-			base:     v1alpha1.RpaasPlanSpec{Image: "img0", Description: "a", Config: v1alpha1.NginxConfig{User: "root", CacheSize: "10", CacheEnabled: v1alpha1.Bool(true)}},
-			override: v1alpha1.RpaasPlanSpec{Image: "img1", Config: v1alpha1.NginxConfig{User: "ubuntu"}},
-			expected: v1alpha1.RpaasPlanSpec{Image: "img1", Description: "a", Config: v1alpha1.NginxConfig{User: "ubuntu", CacheSize: "10", CacheEnabled: v1alpha1.Bool(true)}},
+			name: "with external hostname",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					ExternalHostname: "my-instance.example.com",
+					Service:          &nginxv1alpha1.NginxService{Type: corev1.ServiceTypeLoadBalancer},
+				},
+			},
+			expected: &nginxv1alpha1.NginxService{
+				Type: corev1.ServiceTypeLoadBalancer,
+				Annotations: map[string]string{
+					"external-dns.alpha.kubernetes.io/hostname": "my-instance.example.com",
+				},
+			},
 		},
 		{
-			base:     v1alpha1.RpaasPlanSpec{Image: "img0", Description: "a", Config: v1alpha1.NginxConfig{User: "root", CacheSize: "10", CacheEnabled: v1alpha1.Bool(true)}},
-			override: v1alpha1.RpaasPlanSpec{Image: "img1", Config: v1alpha1.NginxConfig{User: "ubuntu", CacheEnabled: v1alpha1.Bool(false)}},
-			expected: v1alpha1.RpaasPlanSpec{Image: "img1", Description: "a", Config: v1alpha1.NginxConfig{User: "ubuntu", CacheSize: "10", CacheEnabled: v1alpha1.Bool(false)}},
+			name: "with external hostname but without service",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					ExternalHostname: "my-instance.example.com",
+				},
+			},
+			expected: &nginxv1alpha1.NginxService{
+				Annotations: map[string]string{
+					"external-dns.alpha.kubernetes.io/hostname": "my-instance.example.com",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newNginxService(tt.instance))
+		})
+	}
+}
+
+func Test_newNginxPodTemplate(t *testing.T) {
+	teamAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "tsuru.io/pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"team-one"}},
+						},
+					},
+				},
+			},
 		},
+	}
 
+	tests := []struct {
+		name     string
+		instance *v1alpha1.RpaasInstance
+		expected nginxv1alpha1.NginxPodTemplateSpec
+	}{
+		{
+			name: "without node selector",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					PodTemplate: nginxv1alpha1.NginxPodTemplateSpec{Affinity: teamAffinity},
+				},
+			},
+			expected: nginxv1alpha1.NginxPodTemplateSpec{Affinity: teamAffinity},
+		},
+		{
+			name: "with node selector and no affinity",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					NodeSelector: map[string]string{"disktype": "ssd"},
+				},
+			},
+			expected: nginxv1alpha1.NginxPodTemplateSpec{
+				Affinity: &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{
+								{
+									MatchExpressions: []corev1.NodeSelectorRequirement{
+										{Key: "disktype", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
-			base:     v1alpha1.RpaasPlanSpec{Image: "img0", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")}}},
-			override: v1alpha1.RpaasPlanSpec{Image: "img1", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("200Mi")}}},
-			expected: v1alpha1.RpaasPlanSpec{Image: "img1", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("200Mi")}}},
+			name: "with node selector and team affinity",
+			instance: &v1alpha1.RpaasInstance{
+				Spec: v1alpha1.RpaasInstanceSpec{
+					NodeSelector: map[string]string{"zone": "a", "disktype": "ssd"},
+					PodTemplate:  nginxv1alpha1.NginxPodTemplateSpec{Affinity: teamAffinity},
+				},
+			},
+			expected: nginxv1alpha1.NginxPodTemplateSpec{
+				Affinity: &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{
+								{
+									MatchExpressions: []corev1.NodeSelectorRequirement{
+										{Key: "tsuru.io/pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"team-one"}},
+										{Key: "disktype", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}},
+										{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with topology spread constraints",
+			instance: &v1alpha1.RpaasInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-instance"},
+				Spec: v1alpha1.RpaasInstanceSpec{
+					TopologySpreadConstraints: []v1alpha1.TopologySpreadConstraint{
+						{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: v1alpha1.DoNotSchedule},
+						{MaxSkew: 2, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: v1alpha1.ScheduleAnyway},
+					},
+				},
+			},
+			expected: nginxv1alpha1.NginxPodTemplateSpec{
+				Affinity: &corev1.Affinity{
+					PodAntiAffinity: &corev1.PodAntiAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+							{
+								LabelSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{"nginx.tsuru.io/resource-name": "my-instance"},
+								},
+								TopologyKey: "topology.kubernetes.io/zone",
+							},
+						},
+						PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+							{
+								Weight: 100,
+								PodAffinityTerm: corev1.PodAffinityTerm{
+									LabelSelector: &metav1.LabelSelector{
+										MatchLabels: map[string]string{"nginx.tsuru.io/resource-name": "my-instance"},
+									},
+									TopologyKey: "kubernetes.io/hostname",
+								},
+							},
+						},
+					},
+				},
+			},
 		},
 	}
 
 	for _, tt := range tests {
-		t.Run("", func(t *testing.T) {
-			result, err := mergePlans(tt.base, tt.override)
-			require.NoError(t, err)
-			assert.Equal(t, result, tt.expected)
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newNginxPodTemplate(tt.instance))
 		})
 	}
+	assert.Len(t, teamAffinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions, 1)
 }
 
 func Test_reconcileHPA(t *testing.T) {
@@ -124,7 +284,22 @@ func Test_reconcileHPA(t *testing.T) {
 		},
 	}
 
-	resources := []runtime.Object{instance1, instance2, nginx1, nginx2, hpa2}
+	instance3 := newEmptyRpaasInstance()
+	instance3.Name = "instance-3"
+	instance3.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+		MaxReplicas: int32(10),
+		MinReplicas: int32Ptr(2),
+		Metrics: []v1alpha1.RpaasInstanceAutoscaleMetric{
+			{Type: v1alpha1.AutoscaleMetricTypeResource, Name: "memory", Target: 80},
+			{Type: v1alpha1.AutoscaleMetricTypePods, Name: "requests_per_second", Target: 100},
+			{Type: v1alpha1.AutoscaleMetricTypeExternal, Name: "queue_size", Target: 30},
+		},
+	}
+
+	nginx3 := nginx1.DeepCopy()
+	nginx3.Name = "instance-3"
+
+	resources := []runtime.Object{instance1, instance2, instance3, nginx1, nginx2, nginx3, hpa2}
 
 	tests := []struct {
 		name      string
@@ -232,6 +407,35 @@ func Test_reconcileHPA(t *testing.T) {
 				}, got.Spec.Metrics[1])
 			},
 		},
+		{
+			name:     "when autoscale spec has custom metrics",
+			instance: *instance3,
+			nginx:    *nginx3,
+			assertion: func(t *testing.T, err error, got *autoscalingv2beta2.HorizontalPodAutoscaler) {
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				require.Len(t, got.Spec.Metrics, 3)
+				assert.Equal(t, autoscalingv2beta2.MetricSpec{
+					Type: autoscalingv2beta2.ResourceMetricSourceType,
+					Resource: &autoscalingv2beta2.ResourceMetricSource{
+						Name: corev1.ResourceMemory,
+						Target: autoscalingv2beta2.MetricTarget{
+							Type:               autoscalingv2beta2.UtilizationMetricType,
+							AverageUtilization: int32Ptr(80),
+						},
+					},
+				}, got.Spec.Metrics[0])
+				assert.Equal(t, autoscalingv2beta2.PodsMetricSourceType, got.Spec.Metrics[1].Type)
+				require.NotNil(t, got.Spec.Metrics[1].Pods)
+				assert.Equal(t, "requests_per_second", got.Spec.Metrics[1].Pods.Metric.Name)
+				assert.Equal(t, autoscalingv2beta2.AverageValueMetricType, got.Spec.Metrics[1].Pods.Target.Type)
+				assert.Equal(t, "100", got.Spec.Metrics[1].Pods.Target.AverageValue.String())
+				assert.Equal(t, autoscalingv2beta2.ExternalMetricSourceType, got.Spec.Metrics[2].Type)
+				require.NotNil(t, got.Spec.Metrics[2].External)
+				assert.Equal(t, "queue_size", got.Spec.Metrics[2].External.Metric.Name)
+				assert.Equal(t, "30", got.Spec.Metrics[2].External.Target.AverageValue.String())
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -255,6 +459,359 @@ func Test_reconcileHPA(t *testing.T) {
 	}
 }
 
+func Test_reconcilePDB(t *testing.T) {
+	minAvailable := intstr.FromInt(1)
+	maxUnavailable := intstr.FromString("50%")
+
+	instance1 := newEmptyRpaasInstance()
+	instance1.Name = "instance-1"
+	instance1.Spec.PodDisruptionBudget = &v1alpha1.RpaasInstancePodDisruptionBudgetSpec{MinAvailable: &minAvailable}
+
+	instance2 := newEmptyRpaasInstance()
+	instance2.Name = "instance-2"
+
+	pdb2 := newPDB(*instance1)
+	pdb2.Name = "instance-2"
+
+	instance3 := newEmptyRpaasInstance()
+	instance3.Name = "instance-3"
+	instance3.Spec.PodDisruptionBudget = &v1alpha1.RpaasInstancePodDisruptionBudgetSpec{MaxUnavailable: &maxUnavailable}
+
+	pdb3 := newPDB(*instance1)
+	pdb3.Name = "instance-3"
+
+	resources := []runtime.Object{instance1, instance2, instance3, &pdb2, &pdb3}
+
+	tests := []struct {
+		name      string
+		instance  v1alpha1.RpaasInstance
+		assertion func(t *testing.T, err error, got *policyv1beta1.PodDisruptionBudget)
+	}{
+		{
+			name:     "when there is no PDB resource but the spec is provided",
+			instance: *instance1,
+			assertion: func(t *testing.T, err error, got *policyv1beta1.PodDisruptionBudget) {
+				require.NoError(t, err)
+				assert.Equal(t, &minAvailable, got.Spec.MinAvailable)
+				assert.Nil(t, got.Spec.MaxUnavailable)
+				assert.Equal(t, map[string]string{"nginx.tsuru.io/resource-name": "instance-1"}, got.Spec.Selector.MatchLabels)
+				require.Len(t, got.OwnerReferences, 1)
+				assert.Equal(t, "instance-1", got.OwnerReferences[0].Name)
+			},
+		},
+		{
+			name:     "when there is PDB resource but the spec is nil",
+			instance: *instance2,
+			assertion: func(t *testing.T, err error, got *policyv1beta1.PodDisruptionBudget) {
+				require.Error(t, err)
+				assert.True(t, k8sErrors.IsNotFound(err))
+			},
+		},
+		{
+			name:     "when there is PDB resource but differs from the spec",
+			instance: *instance3,
+			assertion: func(t *testing.T, err error, got *policyv1beta1.PodDisruptionBudget) {
+				require.NoError(t, err)
+				assert.Nil(t, got.Spec.MinAvailable)
+				assert.Equal(t, &maxUnavailable, got.Spec.MaxUnavailable)
+				assert.Equal(t, map[string]string{"nginx.tsuru.io/resource-name": "instance-3"}, got.Spec.Selector.MatchLabels)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewFakeClientWithScheme(newScheme(), resources...)
+			reconciler := &ReconcileRpaasInstance{
+				client: k8sClient,
+				scheme: newScheme(),
+			}
+
+			err := reconciler.reconcilePDB(context.TODO(), tt.instance)
+			require.NoError(t, err)
+
+			pdb := new(policyv1beta1.PodDisruptionBudget)
+			err = k8sClient.Get(context.TODO(), types.NamespacedName{Name: tt.instance.Name, Namespace: tt.instance.Namespace}, pdb)
+			tt.assertion(t, err, pdb)
+		})
+	}
+}
+
+func Test_reconcileIngress(t *testing.T) {
+	instance1 := newEmptyRpaasInstance()
+	instance1.Name = "instance-1"
+	instance1.Spec.Ingress = &v1alpha1.RpaasInstanceIngressSpec{ClassName: "nginx", Host: "instance-1.example.com", TLSSecretName: "instance-1-tls"}
+
+	instance2 := newEmptyRpaasInstance()
+	instance2.Name = "instance-2"
+
+	ingress2 := newIngress(*instance1)
+	ingress2.Name = "instance-2"
+
+	instance3 := newEmptyRpaasInstance()
+	instance3.Name = "instance-3"
+	instance3.Spec.Ingress = &v1alpha1.RpaasInstanceIngressSpec{ClassName: "traefik", Host: "new.example.com"}
+
+	ingress3 := newIngress(*instance1)
+	ingress3.Name = "instance-3"
+
+	resources := []runtime.Object{instance1, instance2, instance3, &ingress2, &ingress3}
+
+	tests := []struct {
+		name      string
+		instance  v1alpha1.RpaasInstance
+		assertion func(t *testing.T, err error, got *extensionsv1beta1.Ingress)
+	}{
+		{
+			name:     "when there is no Ingress resource but the spec is provided",
+			instance: *instance1,
+			assertion: func(t *testing.T, err error, got *extensionsv1beta1.Ingress) {
+				require.NoError(t, err)
+				assert.Equal(t, "nginx", got.Annotations["kubernetes.io/ingress.class"])
+				require.Len(t, got.Spec.Rules, 1)
+				assert.Equal(t, "instance-1.example.com", got.Spec.Rules[0].Host)
+				require.NotNil(t, got.Spec.Rules[0].HTTP)
+				require.Len(t, got.Spec.Rules[0].HTTP.Paths, 1)
+				assert.Equal(t, extensionsv1beta1.IngressBackend{ServiceName: "instance-1-service", ServicePort: intstr.FromInt(80)}, got.Spec.Rules[0].HTTP.Paths[0].Backend)
+				assert.Equal(t, []extensionsv1beta1.IngressTLS{{Hosts: []string{"instance-1.example.com"}, SecretName: "instance-1-tls"}}, got.Spec.TLS)
+				require.Len(t, got.OwnerReferences, 1)
+				assert.Equal(t, "instance-1", got.OwnerReferences[0].Name)
+			},
+		},
+		{
+			name:     "when there is Ingress resource but the spec is nil",
+			instance: *instance2,
+			assertion: func(t *testing.T, err error, got *extensionsv1beta1.Ingress) {
+				require.Error(t, err)
+				assert.True(t, k8sErrors.IsNotFound(err))
+			},
+		},
+		{
+			name:     "when there is Ingress resource but differs from the spec",
+			instance: *instance3,
+			assertion: func(t *testing.T, err error, got *extensionsv1beta1.Ingress) {
+				require.NoError(t, err)
+				assert.Equal(t, "traefik", got.Annotations["kubernetes.io/ingress.class"])
+				require.Len(t, got.Spec.Rules, 1)
+				assert.Equal(t, "new.example.com", got.Spec.Rules[0].Host)
+				assert.Equal(t, "instance-3-service", got.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName)
+				assert.Nil(t, got.Spec.TLS)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewFakeClientWithScheme(newScheme(), resources...)
+			reconciler := &ReconcileRpaasInstance{
+				client: k8sClient,
+				scheme: newScheme(),
+			}
+
+			err := reconciler.reconcileIngress(context.TODO(), tt.instance)
+			require.NoError(t, err)
+
+			ingress := new(extensionsv1beta1.Ingress)
+			err = k8sClient.Get(context.TODO(), types.NamespacedName{Name: tt.instance.Name, Namespace: tt.instance.Namespace}, ingress)
+			tt.assertion(t, err, ingress)
+		})
+	}
+}
+
+func Test_reconcileExtraPortsService(t *testing.T) {
+	instance1 := newEmptyRpaasInstance()
+	instance1.Name = "instance-1"
+	instance1.Spec.Service = &nginxv1alpha1.NginxService{Type: corev1.ServiceTypeLoadBalancer, LoadBalancerIP: "10.1.1.1"}
+	instance1.Spec.ExtraPorts = []int32{9000, 9443}
+
+	instance2 := newEmptyRpaasInstance()
+	instance2.Name = "instance-2"
+
+	service2 := newExtraPortsService(*instance1, "")
+	service2.Name = "instance-2-extra-ports"
+
+	instance3 := newEmptyRpaasInstance()
+	instance3.Name = "instance-3"
+	instance3.Spec.Service = &nginxv1alpha1.NginxService{Type: corev1.ServiceTypeLoadBalancer}
+	instance3.Spec.ExtraPorts = []int32{9443}
+
+	service3 := newExtraPortsService(*instance1, "")
+	service3.Name = "instance-3-extra-ports"
+	service3.Spec.Ports[1].NodePort = 31443
+
+	instance4 := newEmptyRpaasInstance()
+	instance4.Name = "instance-4"
+	instance4.Spec.HTTP3 = true
+
+	instance5 := newEmptyRpaasInstance()
+	instance5.Name = "instance-5"
+	instance5.Spec.HTTP3 = true
+	instance5.Spec.ExternalHostname = "instance-5.example.com"
+	instance5.Spec.Service = &nginxv1alpha1.NginxService{
+		Type:        corev1.ServiceTypeLoadBalancer,
+		Annotations: map[string]string{"external-dns.alpha.kubernetes.io/ttl": "60", "my-annotation": "value"},
+	}
+
+	nginxService5 := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-5-service", Namespace: instance5.Namespace},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.5.5.5"}}},
+		},
+	}
+
+	instance6 := newEmptyRpaasInstance()
+	instance6.Name = "instance-6"
+	instance6.Spec.HTTP3 = true
+	instance6.Spec.Service = &nginxv1alpha1.NginxService{Type: corev1.ServiceTypeLoadBalancer}
+
+	resources := []runtime.Object{instance1, instance2, instance3, instance4, instance5, instance6, &service2, &service3, nginxService5}
+
+	tests := []struct {
+		name      string
+		instance  v1alpha1.RpaasInstance
+		assertion func(t *testing.T, err error, got *corev1.Service)
+	}{
+		{
+			name:     "when there is no Service resource but extra ports are provided",
+			instance: *instance1,
+			assertion: func(t *testing.T, err error, got *corev1.Service) {
+				require.NoError(t, err)
+				assert.Equal(t, corev1.ServiceTypeLoadBalancer, got.Spec.Type)
+				assert.Equal(t, "10.1.1.1", got.Spec.LoadBalancerIP)
+				assert.Equal(t, map[string]string{"nginx.tsuru.io/resource-name": "instance-1"}, got.Spec.Selector)
+				assert.Equal(t, []corev1.ServicePort{
+					{Name: "extra-9000", Protocol: corev1.ProtocolTCP, Port: 9000, TargetPort: intstr.FromInt(9000)},
+					{Name: "extra-9443", Protocol: corev1.ProtocolTCP, Port: 9443, TargetPort: intstr.FromInt(9443)},
+				}, got.Spec.Ports)
+				require.Len(t, got.OwnerReferences, 1)
+				assert.Equal(t, "instance-1", got.OwnerReferences[0].Name)
+			},
+		},
+		{
+			name:     "when there is Service resource but no extra ports",
+			instance: *instance2,
+			assertion: func(t *testing.T, err error, got *corev1.Service) {
+				require.Error(t, err)
+				assert.True(t, k8sErrors.IsNotFound(err))
+			},
+		},
+		{
+			name:     "when a port was removed from the extra ports",
+			instance: *instance3,
+			assertion: func(t *testing.T, err error, got *corev1.Service) {
+				require.NoError(t, err)
+				assert.Equal(t, []corev1.ServicePort{
+					{Name: "extra-9443", Protocol: corev1.ProtocolTCP, Port: 9443, TargetPort: intstr.FromInt(9443), NodePort: 31443},
+				}, got.Spec.Ports)
+			},
+		},
+		{
+			name:     "when HTTP/3 is enabled without extra ports",
+			instance: *instance4,
+			assertion: func(t *testing.T, err error, got *corev1.Service) {
+				require.NoError(t, err)
+				assert.Equal(t, corev1.ServiceTypeClusterIP, got.Spec.Type)
+				assert.Equal(t, "", got.Spec.LoadBalancerIP)
+				assert.Equal(t, []corev1.ServicePort{
+					{Name: "http3", Protocol: corev1.ProtocolUDP, Port: 443, TargetPort: intstr.FromInt(8443)},
+				}, got.Spec.Ports)
+			},
+		},
+		{
+			name:     "when the Nginx Service already has a load balancer",
+			instance: *instance5,
+			assertion: func(t *testing.T, err error, got *corev1.Service) {
+				require.NoError(t, err)
+				assert.Equal(t, corev1.ServiceTypeLoadBalancer, got.Spec.Type)
+				assert.Equal(t, "10.5.5.5", got.Spec.LoadBalancerIP)
+				assert.Equal(t, map[string]string{"my-annotation": "value"}, got.Annotations)
+			},
+		},
+		{
+			name:     "when the load balancer of the Nginx Service is still pending",
+			instance: *instance6,
+			assertion: func(t *testing.T, err error, got *corev1.Service) {
+				require.Error(t, err)
+				assert.True(t, k8sErrors.IsNotFound(err))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewFakeClientWithScheme(newScheme(), resources...)
+			reconciler := &ReconcileRpaasInstance{
+				client: k8sClient,
+				scheme: newScheme(),
+			}
+
+			err := reconciler.reconcileExtraPortsService(context.TODO(), tt.instance)
+			require.NoError(t, err)
+
+			service := new(corev1.Service)
+			err = k8sClient.Get(context.TODO(), types.NamespacedName{Name: tt.instance.Name + "-extra-ports", Namespace: tt.instance.Namespace}, service)
+			tt.assertion(t, err, service)
+		})
+	}
+}
+
+func Test_updateStatus(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	instance.Generation = 3
+	instance.Status.ObservedGeneration = 2
+	instance.Spec.StatusCallbackURL = "https://callback.example.com/rpaas"
+	assert.True(t, instance.PendingReconcile())
+
+	k8sClient := fake.NewFakeClientWithScheme(newScheme(), instance)
+	notifier := newCallbackNotifier(10)
+	reconciler := &ReconcileRpaasInstance{
+		client:   k8sClient,
+		scheme:   newScheme(),
+		notifier: notifier,
+	}
+
+	err := reconciler.updateStatus(context.TODO(), instance, errors.New("plan not found"))
+	require.NoError(t, err)
+
+	got := new(v1alpha1.RpaasInstance)
+	err = k8sClient.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, got)
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.RpaasInstanceStatus{
+		ObservedGeneration: 2,
+		Phase:              v1alpha1.RpaasInstancePhaseFailed,
+		Message:            "plan not found",
+	}, got.Status)
+	assert.True(t, got.PendingReconcile())
+	require.Len(t, notifier.queue, 1)
+	req := <-notifier.queue
+	assert.Equal(t, "https://callback.example.com/rpaas", req.url)
+	assert.Equal(t, v1alpha1.RpaasInstancePhaseFailed, req.payload.Phase)
+	assert.Equal(t, "plan not found", req.payload.Message)
+
+	err = reconciler.updateStatus(context.TODO(), got, errors.New("plan not found again"))
+	require.NoError(t, err)
+	assert.Len(t, notifier.queue, 0)
+
+	err = reconciler.updateStatus(context.TODO(), got, nil)
+	require.NoError(t, err)
+	err = k8sClient.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, got)
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.RpaasInstanceStatus{
+		ObservedGeneration: 3,
+		Phase:              v1alpha1.RpaasInstancePhaseReady,
+	}, got.Status)
+	assert.False(t, got.PendingReconcile())
+	require.Len(t, notifier.queue, 1)
+	req = <-notifier.queue
+	assert.Equal(t, statusCallback{
+		Instance:   "my-instance",
+		Namespace:  "default",
+		Phase:      v1alpha1.RpaasInstancePhaseReady,
+		Generation: 3,
+		Timestamp:  req.payload.Timestamp,
+	}, req.payload)
+}
+
 func int32Ptr(n int32) *int32 {
 	return &n
 }
@@ -278,5 +835,8 @@ func newScheme() *runtime.Scheme {
 	autoscalingv2beta2.SchemeBuilder.AddToScheme(scheme)
 	v1alpha1.SchemeBuilder.AddToScheme(scheme)
 	nginxv1alpha1.SchemeBuilder.AddToScheme(scheme)
+	policyv1beta1.SchemeBuilder.AddToScheme(scheme)
+	extensionsv1beta1.SchemeBuilder.AddToScheme(scheme)
+	corev1.SchemeBuilder.AddToScheme(scheme)
 	return scheme
 }
@@ -0,0 +1,39 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	rpaasv1alpha1 "github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+)
+
+// MergePlans layers the fields set on override over the base plan spec, as
+// done for the plan templates of instances and the flavors.
+func MergePlans(base rpaasv1alpha1.RpaasPlanSpec, override rpaasv1alpha1.RpaasPlanSpec) (rpaasv1alpha1.RpaasPlanSpec, error) {
+	baseData, err := json.Marshal(base)
+	if err != nil {
+		return base, err
+	}
+	overrideData, err := json.Marshal(override)
+	if err != nil {
+		return base, err
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(baseData, overrideData, baseData)
+	if err != nil {
+		return base, err
+	}
+	merged, err := jsonpatch.MergePatch(baseData, patch)
+	if err != nil {
+		return base, err
+	}
+	err = json.Unmarshal(merged, &base)
+	if err != nil {
+		return base, err
+	}
+	return base, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rpaasv1alpha1 "github.com/tsuru/rpaas-operator/pkg/apis/extensions/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMergePlans(t *testing.T) {
+	tests := []struct {
+		base     rpaasv1alpha1.RpaasPlanSpec
+		override rpaasv1alpha1.RpaasPlanSpec
+		expected rpaasv1alpha1.RpaasPlanSpec
+	}{
+		{
+			base:     rpaasv1alpha1.RpaasPlanSpec{},
+			override: rpaasv1alpha1.RpaasPlanSpec{},
+			expected: rpaasv1alpha1.RpaasPlanSpec{},
+		},
+		{
+			base:     rpaasv1alpha1.RpaasPlanSpec{Image: "img0", Description: "a", Config: rpaasv1alpha1.NginxConfig{User: "root", CacheEnabled: rpaasv1alpha1.Bool(true)}},
+			override: rpaasv1alpha1.RpaasPlanSpec{Image: "img1"},
+			expected: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Description: "a", Config: rpaasv1alpha1.NginxConfig{User: "root", CacheEnabled: rpaasv1alpha1.Bool(true)}},
+		},
+		{
+			base:     rpaasv1alpha1.RpaasPlanSpec{Image: "img0", Description: "a", Config: rpaasv1alpha1.NginxConfig{User: "root", CacheSize: "10", CacheEnabled: rpaasv1alpha1.Bool(true)}},
+			override: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Config: rpaasv1alpha1.NginxConfig{User: "ubuntu"}},
+			expected: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Description: "a", Config: rpaasv1alpha1.NginxConfig{User: "ubuntu", CacheSize: "10", CacheEnabled: rpaasv1alpha1.Bool(true)}},
+		},
+		{
+			base:     rpaasv1alpha1.RpaasPlanSpec{Image: "img0", Description: "a", Config: rpaasv1alpha1.NginxConfig{User: "root", CacheSize: "10", CacheEnabled: rpaasv1alpha1.Bool(true)}},
+			override: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Config: rpaasv1alpha1.NginxConfig{User: "ubuntu", CacheEnabled: rpaasv1alpha1.Bool(false)}},
+			expected: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Description: "a", Config: rpaasv1alpha1.NginxConfig{User: "ubuntu", CacheSize: "10", CacheEnabled: rpaasv1alpha1.Bool(false)}},
+		},
+
+		{
+			base:     rpaasv1alpha1.RpaasPlanSpec{Image: "img0", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")}}},
+			override: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("200Mi")}}},
+			expected: rpaasv1alpha1.RpaasPlanSpec{Image: "img1", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("200Mi")}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			result, err := MergePlans(tt.base, tt.override)
+			require.NoError(t, err)
+			assert.Equal(t, result, tt.expected)
+		})
+	}
+}
@@ -17,6 +17,56 @@ import (
 
 // GetValue retrieves the content inside the Value object.
 func GetValue(ctx context.Context, c client.Client, defaultNamespace string, v *rpaasv1alpha1.Value) (string, error) {
+	return getValue(defaultNamespace, v, func(name types.NamespacedName) (*corev1.ConfigMap, error) {
+		var cm corev1.ConfigMap
+		if err := c.Get(ctx, name, &cm); err != nil {
+			return nil, err
+		}
+		return &cm, nil
+	})
+}
+
+// ValueResolver retrieves the content of several Value objects, reading each
+// referenced ConfigMap only once.
+type ValueResolver struct {
+	client           client.Client
+	defaultNamespace string
+	configMaps       map[types.NamespacedName]*corev1.ConfigMap
+	errors           map[types.NamespacedName]error
+}
+
+func NewValueResolver(c client.Client, defaultNamespace string) *ValueResolver {
+	return &ValueResolver{
+		client:           c,
+		defaultNamespace: defaultNamespace,
+		configMaps:       make(map[types.NamespacedName]*corev1.ConfigMap),
+		errors:           make(map[types.NamespacedName]error),
+	}
+}
+
+// GetValue retrieves the content inside the Value object, as the GetValue
+// function does.
+func (r *ValueResolver) GetValue(ctx context.Context, v *rpaasv1alpha1.Value) (string, error) {
+	return getValue(r.defaultNamespace, v, func(name types.NamespacedName) (*corev1.ConfigMap, error) {
+		if cm, ok := r.configMaps[name]; ok {
+			return cm, nil
+		}
+		if err, ok := r.errors[name]; ok {
+			return nil, err
+		}
+		var cm corev1.ConfigMap
+		if err := r.client.Get(ctx, name, &cm); err != nil {
+			r.errors[name] = err
+			return nil, err
+		}
+		r.configMaps[name] = &cm
+		return &cm, nil
+	})
+}
+
+type configMapGetter func(name types.NamespacedName) (*corev1.ConfigMap, error)
+
+func getValue(defaultNamespace string, v *rpaasv1alpha1.Value, getConfigMap configMapGetter) (string, error) {
 	if v == nil {
 		return "", fmt.Errorf("value cannot be nil")
 	}
@@ -25,10 +75,10 @@ func GetValue(ctx context.Context, c client.Client, defaultNamespace string, v *
 		return v.Value, nil
 	}
 
-	return getValueFromConfigMap(ctx, c, defaultNamespace, v.ValueFrom)
+	return getValueFromConfigMap(defaultNamespace, v.ValueFrom, getConfigMap)
 }
 
-func getValueFromConfigMap(ctx context.Context, c client.Client, namespace string, vs *rpaasv1alpha1.ValueSource) (string, error) {
+func getValueFromConfigMap(namespace string, vs *rpaasv1alpha1.ValueSource, getConfigMap configMapGetter) (string, error) {
 	if vs == nil || vs.ConfigMapKeyRef == nil {
 		return "", fmt.Errorf("value source is missing")
 	}
@@ -43,8 +93,8 @@ func getValueFromConfigMap(ctx context.Context, c client.Client, namespace strin
 		Name:      vs.ConfigMapKeyRef.Name,
 		Namespace: namespace,
 	}
-	var cm corev1.ConfigMap
-	if err := c.Get(ctx, cmName, &cm); err != nil {
+	cm, err := getConfigMap(cmName)
+	if err != nil {
 		if isOptional && k8sErrors.IsNotFound(err) {
 			return "", nil
 		}
@@ -15,6 +15,7 @@ import (
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -222,3 +223,60 @@ func TestGetValue(t *testing.T) {
 		})
 	}
 }
+
+type countingClient struct {
+	client.Client
+	gets int
+}
+
+func (c *countingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	c.gets++
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestValueResolver(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+
+	configMapValue := func(name, key string, optional bool) *rpaasv1alpha1.Value {
+		return &rpaasv1alpha1.Value{
+			ValueFrom: &rpaasv1alpha1.ValueSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Key:                  key,
+					Optional:             &optional,
+				},
+			},
+		}
+	}
+
+	k8sClient := &countingClient{Client: fake.NewFakeClientWithScheme(scheme, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", Namespace: "default"},
+		Data:       map[string]string{"a": "value a", "b": "value b"},
+	})}
+	resolver := NewValueResolver(k8sClient, "default")
+
+	value, err := resolver.GetValue(context.TODO(), configMapValue("my-configmap", "a", false))
+	assert.NoError(t, err)
+	assert.Equal(t, "value a", value)
+
+	value, err = resolver.GetValue(context.TODO(), configMapValue("my-configmap", "b", false))
+	assert.NoError(t, err)
+	assert.Equal(t, "value b", value)
+
+	_, err = resolver.GetValue(context.TODO(), configMapValue("my-configmap", "c", false))
+	assert.EqualError(t, err, `key "c" cannot be found in configmap default/my-configmap`)
+
+	value, err = resolver.GetValue(context.TODO(), &rpaasv1alpha1.Value{Value: "inline"})
+	assert.NoError(t, err)
+	assert.Equal(t, "inline", value)
+	assert.Equal(t, 1, k8sClient.gets)
+
+	value, err = resolver.GetValue(context.TODO(), configMapValue("missing-configmap", "a", true))
+	assert.NoError(t, err)
+	assert.Equal(t, "", value)
+
+	_, err = resolver.GetValue(context.TODO(), configMapValue("missing-configmap", "a", false))
+	assert.True(t, k8sErrors.IsNotFound(err))
+	assert.Equal(t, 2, k8sClient.gets)
+}